@@ -0,0 +1,123 @@
+package apperror
+
+import "app/src/i18n"
+
+// Kind is the kind of a domain error. The status and error code returned to the client follow from the kind
+type Kind int
+
+const (
+	KindValidation Kind = iota + 1
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindGone
+	KindConflict
+	KindPolicyViolation
+	KindTooManyRequests
+)
+
+// Error is an error that services report to the client.
+// The text is kept as a message key and its values, and is built in the request's language when the response is written.
+// Errors without a kind (such as database failures) are treated as internal errors and their message is not returned to the client
+type Error struct {
+	Kind Kind
+	// Code overrides the default error code of the kind (e.g. RISK_CONFIRMATION_REQUIRED)
+	Code   string
+	Key    string
+	Params i18n.Params
+	// Details holds the error details (errors per input field, conflicting dates and so on)
+	Details interface{}
+}
+
+// Localizer is implemented by details whose content depends on the language (such as the reasons for conflicting dates)
+type Localizer interface {
+	Localize(locale string) interface{}
+}
+
+// FieldError is an error for a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the English text for logs
+func (e *Error) Error() string {
+	return e.Message(i18n.English)
+}
+
+// Message returns the text in the given language
+func (e *Error) Message(locale string) string {
+	return i18n.T(locale, e.Key, e.Params)
+}
+
+// Is treats copies made with WithCode, WithParams and WithDetails as equal to the original error
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code && t.Key == e.Key
+}
+
+// WithCode returns a copy with the given error code
+func (e *Error) WithCode(code string) *Error {
+	copied := *e
+	copied.Code = code
+	return &copied
+}
+
+// WithParams returns a copy with the values embedded in the message
+func (e *Error) WithParams(params i18n.Params) *Error {
+	copied := *e
+	copied.Params = params
+	return &copied
+}
+
+// WithDetails returns a copy with the details attached. Shared error variables are never modified
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// New creates an error from a kind and a message key
+func New(kind Kind, key string) *Error {
+	return &Error{Kind: kind, Key: key}
+}
+
+// Validation is an invalid input
+func Validation(key string) *Error {
+	return New(KindValidation, key)
+}
+
+// Unauthorized is a failed authentication
+func Unauthorized(key string) *Error {
+	return New(KindUnauthorized, key)
+}
+
+// Forbidden is an authenticated request that is not allowed to do the operation
+func Forbidden(key string) *Error {
+	return New(KindForbidden, key)
+}
+
+// NotFound is a missing resource
+func NotFound(key string) *Error {
+	return New(KindNotFound, key)
+}
+
+// Gone is a resource that can no longer be used, such as after it expired
+func Gone(key string) *Error {
+	return New(KindGone, key)
+}
+
+// Conflict is a reservation slot or unique value that clashes with another
+func Conflict(key string) *Error {
+	return New(KindConflict, key)
+}
+
+// PolicyViolation is a valid input that a business rule, such as a status or deadline, does not allow
+func PolicyViolation(key string) *Error {
+	return New(KindPolicyViolation, key)
+}
+
+// TooManyRequests is a request over a rate limit
+func TooManyRequests(key string) *Error {
+	return New(KindTooManyRequests, key)
+}
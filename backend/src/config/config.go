@@ -1,39 +1,83 @@
 package config
 
 import (
+	"app/src/i18n"
 	"app/src/utils"
 
 	"github.com/spf13/viper"
 )
 
 var (
-	IsProd              bool
-	AppHost             string
-	AppPort             int
-	DBHost              string
-	DBUser              string
-	DBPassword          string
-	DBName              string
-	DBPort              int
-	JWTSecret           string
-	JWTAccessExp        int
-	JWTRefreshExp       int
-	JWTResetPasswordExp int
-	JWTVerifyEmailExp   int
-	SMTPHost            string
-	SMTPPort            int
-	SMTPUsername        string
-	SMTPPassword        string
-	EmailFrom           string
+	IsProd               bool
+	AppHost              string
+	AppPort              int
+	AppURL               string
+	DBHost               string
+	DBUser               string
+	DBPassword           string
+	DBName               string
+	DBPort               int
+	JWTSecret            string
+	JWTAccessExp         int
+	JWTRefreshExp        int
+	JWTResetPasswordExp  int
+	JWTVerifyEmailExp    int
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string
+	SMTPPassword         string
+	EmailFrom            string
+	WaitlistOfferHold    int
+	CustomerChangeCutoff int
+	LoginSender          string
+	SMSProvider          string
+	SMSFrom              string
+	TwilioAccountSID     string
+	TwilioAuthToken      string
+	LoginCodeExp         int
+	LoginCodeMaxAttempts int
+	LoginCodeMaxRequests int
+	NoShowGrace          int
+	LateCancelWindow     int
+	RiskLookbackDays     int
+	RiskWarnScore        int
+	RiskConfirmScore     int
+	TracingExporter      string
+	TracingEndpoint      string
+	TracingSampleRatio   float64
+	TracingServiceName   string
+	LogFormat            string
+	DBSlowQuery          int
+	DBConnectAttempts    int
+	DBConnectBackoff     int
+	HealthHeapThreshold  int
+	HealthCheckTimeout   int
+	DefaultLocale        string
 )
 
 func init() {
 	loadConfig()
 
+	// logging configuration
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("DB_SLOW_QUERY_MS", 200)
+	LogFormat = viper.GetString("LOG_FORMAT")
+	DBSlowQuery = viper.GetInt("DB_SLOW_QUERY_MS")
+	utils.SetLogFormat(LogFormat)
+
+	// language of responses and notifications when the request or customer does not specify one
+	viper.SetDefault("DEFAULT_LOCALE", "ja")
+	DefaultLocale = viper.GetString("DEFAULT_LOCALE")
+	if !i18n.Supported(DefaultLocale) {
+		utils.Log.Warnf("DEFAULT_LOCALE %q is not supported, using %s", DefaultLocale, i18n.Default())
+	}
+	i18n.SetDefault(DefaultLocale)
+
 	// server configuration
 	IsProd = viper.GetString("APP_ENV") == "prod"
 	AppHost = viper.GetString("APP_HOST")
 	AppPort = viper.GetInt("APP_PORT")
+	AppURL = viper.GetString("APP_URL")
 
 	// database configuration
 	DBHost = viper.GetString("DB_HOST")
@@ -41,6 +85,10 @@ func init() {
 	DBPassword = viper.GetString("DB_PASSWORD")
 	DBName = viper.GetString("DB_NAME")
 	DBPort = viper.GetInt("DB_PORT")
+	viper.SetDefault("DB_CONNECT_MAX_ATTEMPTS", 10)
+	viper.SetDefault("DB_CONNECT_BACKOFF_MS", 500)
+	DBConnectAttempts = viper.GetInt("DB_CONNECT_MAX_ATTEMPTS")
+	DBConnectBackoff = viper.GetInt("DB_CONNECT_BACKOFF_MS")
 
 	// jwt configuration
 	JWTSecret = viper.GetString("JWT_SECRET")
@@ -55,6 +103,68 @@ func init() {
 	SMTPUsername = viper.GetString("SMTP_USERNAME")
 	SMTPPassword = viper.GetString("SMTP_PASSWORD")
 	EmailFrom = viper.GetString("EMAIL_FROM")
+
+	// waitlist configuration
+	viper.SetDefault("WAITLIST_OFFER_HOLD_MINUTES", 30)
+	WaitlistOfferHold = viper.GetInt("WAITLIST_OFFER_HOLD_MINUTES")
+
+	// customer self-service configuration
+	viper.SetDefault("CUSTOMER_CHANGE_CUTOFF_HOURS", 24)
+	CustomerChangeCutoff = viper.GetInt("CUSTOMER_CHANGE_CUTOFF_HOURS")
+
+	// passwordless customer login configuration
+	viper.SetDefault("LOGIN_SENDER", "stub")
+	viper.SetDefault("LOGIN_CODE_EXP_MINUTES", 10)
+	viper.SetDefault("LOGIN_CODE_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_CODE_MAX_REQUESTS_PER_HOUR", 5)
+	LoginSender = viper.GetString("LOGIN_SENDER")
+	LoginCodeExp = viper.GetInt("LOGIN_CODE_EXP_MINUTES")
+	LoginCodeMaxAttempts = viper.GetInt("LOGIN_CODE_MAX_ATTEMPTS")
+	LoginCodeMaxRequests = viper.GetInt("LOGIN_CODE_MAX_REQUESTS_PER_HOUR")
+	if IsProd && LoginSender != "notification" {
+		utils.Log.Fatal("LOGIN_SENDER must be notification in production")
+	}
+
+	// SMS delivery for login codes and waitlist offers
+	viper.SetDefault("SMS_PROVIDER", "none")
+	SMSProvider = viper.GetString("SMS_PROVIDER")
+	SMSFrom = viper.GetString("SMS_FROM")
+	TwilioAccountSID = viper.GetString("TWILIO_ACCOUNT_SID")
+	TwilioAuthToken = viper.GetString("TWILIO_AUTH_TOKEN")
+	if SMSProvider == "twilio" && (SMSFrom == "" || TwilioAccountSID == "" || TwilioAuthToken == "") {
+		utils.Log.Fatal("SMS_FROM, TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN must be set when SMS_PROVIDER is twilio")
+	}
+
+	// no-show and late cancellation configuration
+	viper.SetDefault("NO_SHOW_GRACE_MINUTES", 30)
+	viper.SetDefault("LATE_CANCEL_WINDOW_HOURS", 24)
+	viper.SetDefault("CUSTOMER_RISK_LOOKBACK_DAYS", 365)
+	viper.SetDefault("CUSTOMER_RISK_WARN_SCORE", 2)
+	viper.SetDefault("CUSTOMER_RISK_CONFIRM_SCORE", 4)
+	NoShowGrace = viper.GetInt("NO_SHOW_GRACE_MINUTES")
+	LateCancelWindow = viper.GetInt("LATE_CANCEL_WINDOW_HOURS")
+	RiskLookbackDays = viper.GetInt("CUSTOMER_RISK_LOOKBACK_DAYS")
+	RiskWarnScore = viper.GetInt("CUSTOMER_RISK_WARN_SCORE")
+	RiskConfirmScore = viper.GetInt("CUSTOMER_RISK_CONFIRM_SCORE")
+
+	// tracing configuration
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_OTLP_ENDPOINT", "http://localhost:4318")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("TRACING_SERVICE_NAME", "beauty-salon-api")
+	TracingExporter = viper.GetString("TRACING_EXPORTER")
+	TracingEndpoint = viper.GetString("TRACING_OTLP_ENDPOINT")
+	TracingSampleRatio = viper.GetFloat64("TRACING_SAMPLE_RATIO")
+	TracingServiceName = viper.GetString("TRACING_SERVICE_NAME")
+
+	// liveness and readiness probe configuration
+	viper.SetDefault("HEALTH_HEAP_THRESHOLD_MB", 300)
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT_MS", 2000)
+	HealthHeapThreshold = viper.GetInt("HEALTH_HEAP_THRESHOLD_MB")
+	HealthCheckTimeout = viper.GetInt("HEALTH_CHECK_TIMEOUT_MS")
+
+	// customer personal data encryption
+	loadFieldEncryption()
 }
 
 func loadConfig() {
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"app/src/utils"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// loadFieldEncryption loads the keys used to encrypt the personal data of customers.
+// FIELD_ENCRYPTION_KEYS is a comma separated list of "version:base64 of a 32 byte key", and new values are
+// encrypted with FIELD_ENCRYPTION_KEY_VERSION (the highest version when omitted).
+// When unset in development a fixed development key is used
+func loadFieldEncryption() {
+	keys := map[int][]byte{}
+	current := 0
+	for _, entry := range strings.Split(viper.GetString("FIELD_ENCRYPTION_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		versionText, encoded, ok := strings.Cut(entry, ":")
+		version, err := strconv.Atoi(versionText)
+		if !ok || err != nil || version <= 0 {
+			utils.Log.Fatalf("FIELD_ENCRYPTION_KEYS has an invalid entry: %q", versionText)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			utils.Log.Fatalf("FIELD_ENCRYPTION_KEYS version %d is not valid base64", version)
+		}
+		keys[version] = key
+		current = max(current, version)
+	}
+	if version := viper.GetInt("FIELD_ENCRYPTION_KEY_VERSION"); version > 0 {
+		current = version
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(viper.GetString("BLIND_INDEX_KEY"))
+	if err != nil {
+		utils.Log.Fatal("BLIND_INDEX_KEY is not valid base64")
+	}
+
+	if len(keys) == 0 || len(indexKey) == 0 {
+		if IsProd {
+			utils.Log.Fatal("FIELD_ENCRYPTION_KEYS and BLIND_INDEX_KEY must be set in production")
+		}
+		utils.Log.Warn("FIELD_ENCRYPTION_KEYS or BLIND_INDEX_KEY is not set, using development keys")
+		developmentKey := sha256.Sum256([]byte("development field encryption key"))
+		developmentIndexKey := sha256.Sum256([]byte("development blind index key"))
+		keys, current, indexKey = map[int][]byte{1: developmentKey[:]}, 1, developmentIndexKey[:]
+	}
+
+	if err := utils.InitFieldEncryption(keys, current, indexKey); err != nil {
+		utils.Log.Fatalf("Failed to initialize field encryption: %v", err)
+	}
+}
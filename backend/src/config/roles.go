@@ -1,8 +1,19 @@
 package config
 
+// RoleCustomer is the role that uses the customer self-service API
+const RoleCustomer = "customer"
+
+// Roles of salon staff and admins, used for operations not allowed to customers such as reading audit logs
+const (
+	RoleStaff = "staff"
+	RoleAdmin = "admin"
+)
+
 var allRoles = map[string][]string{
-	"user":  {},
-	"admin": {"getUsers", "manageUsers"},
+	"user":       {},
+	RoleAdmin:    {"getUsers", "manageUsers"},
+	RoleStaff:    {},
+	RoleCustomer: {},
 }
 
 var Roles = getKeys(allRoles)
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AnalyticsController struct {
+	analyticsService *service.AnalyticsService
+}
+
+func NewAnalyticsController(analyticsService *service.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetUtilization godoc
+// @Summary スタッフの稼働率分析
+// @Description スタッフごと・日または週ごとの稼働率（予約の所要時間 ÷ シフトから休憩などを除いた時間）、シフト中の空き時間の分布、曜日と時間帯ごとの混雑状況（ヒートマップ）を返します
+// @Tags レポート
+// @Produce json
+// @Param date_from query string false "開始日 (YYYY-MM-DD)"
+// @Param date_to query string false "終了日 (YYYY-MM-DD)"
+// @Param staff_id query string false "スタッフIDで絞り込み"
+// @Param granularity query string false "集計単位 (day, week)" default(day)
+// @Success 200 {object} service.UtilizationReport "稼働率"
+// @Router /analytics/utilization [get]
+func (c *AnalyticsController) GetUtilization(ctx *fiber.Ctx) error {
+	filter := service.UtilizationFilter{
+		DateFrom:    ctx.Query("date_from"),
+		DateTo:      ctx.Query("date_to"),
+		Granularity: ctx.Query("granularity"),
+	}
+	if staffID := ctx.Query("staff_id"); staffID != "" {
+		parsed, err := uuid.Parse(staffID)
+		if err != nil {
+			return errInvalidStaffID
+		}
+		filter.StaffID = &parsed
+	}
+
+	report, err := c.analyticsService.GetUtilization(filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"utilization": report,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AuditLogController struct {
+	auditLogService *service.AuditLogService
+}
+
+func NewAuditLogController(auditLogService *service.AuditLogService) *AuditLogController {
+	return &AuditLogController{
+		auditLogService: auditLogService,
+	}
+}
+
+// GetAuditLogs godoc
+// @Summary 監査ログ一覧取得
+// @Description 顧客データの変更・統合・エクスポート・消去などの監査ログを取得します。cursor を指定すると前のページの pagination.next_cursor の続きから取得し、page は無視されます
+// @Tags 監査ログ
+// @Accept json
+// @Produce json
+// @Param page query int false "ページ番号（オフセット方式）" default(1)
+// @Param limit query int false "ページサイズ" default(20)
+// @Param cursor query string false "次のページのカーソル（カーソル方式）"
+// @Param sort query string false "並び順 (created_at。先頭に - で降順)" default(-created_at)
+// @Param include_total query bool false "総件数を返すか。省略時はオフセット方式のみ返す"
+// @Param table_name query string false "テーブル名絞り込み"
+// @Param record_id query string false "レコードID絞り込み"
+// @Param action query string false "操作種別絞り込み (CREATE, UPDATE, DELETE, MERGE, EXPORT, ERASE)"
+// @Param user_id query string false "操作者ID絞り込み"
+// @Success 200 {object} map[string]interface{} "監査ログ一覧"
+// @Router /audit-logs [get]
+func (c *AuditLogController) GetAuditLogs(ctx *fiber.Ctx) error {
+	filter := service.AuditLogFilter{
+		Table:  ctx.Query("table_name"),
+		Action: ctx.Query("action"),
+	}
+	if recordID := ctx.Query("record_id"); recordID != "" {
+		id, err := uuid.Parse(recordID)
+		if err != nil {
+			return errInvalidID
+		}
+		filter.RecordID = &id
+	}
+	if userID := ctx.Query("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return errInvalidID
+		}
+		filter.UserID = &id
+	}
+
+	auditLogs, page, err := c.auditLogService.GetAuditLogs(listOptions(ctx, 20), filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"audit_logs": auditLogs,
+			"pagination": paginationResponse(page),
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
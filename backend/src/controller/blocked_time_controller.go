@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BlockedTimeController struct {
+	blockedTimeService *service.BlockedTimeService
+}
+
+func NewBlockedTimeController(blockedTimeService *service.BlockedTimeService) *BlockedTimeController {
+	return &BlockedTimeController{
+		blockedTimeService: blockedTimeService,
+	}
+}
+
+// GetBlockedTimes godoc
+// @Summary ブロック時間一覧取得
+// @Description スタッフの休憩・研修・私用などのブロック時間を取得します
+// @Tags シフト管理
+// @Accept json
+// @Produce json
+// @Param staffId path string true "スタッフID"
+// @Param date query string false "適用日で絞り込み (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "ブロック時間一覧"
+// @Router /staff/{staffId}/blocked-times [get]
+func (c *BlockedTimeController) GetBlockedTimes(ctx *fiber.Ctx) error {
+	staffID, err := uuid.Parse(ctx.Params("staffId"))
+	if err != nil {
+		return errInvalidStaffID
+	}
+
+	blockedTimes, err := c.blockedTimeService.GetBlockedTimes(staffID, ctx.Query("date"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"blocked_times": blockedTimes,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CreateBlockedTime godoc
+// @Summary ブロック時間登録
+// @Description 単発（date）または毎週（weekday）のブロック時間を登録します。空き時間検索から除外されます
+// @Tags シフト管理
+// @Accept json
+// @Produce json
+// @Param staffId path string true "スタッフID"
+// @Param blockedTime body model.BlockedTime true "ブロック時間データ"
+// @Success 201 {object} model.BlockedTime "登録されたブロック時間"
+// @Router /staff/{staffId}/blocked-times [post]
+func (c *BlockedTimeController) CreateBlockedTime(ctx *fiber.Ctx) error {
+	staffID, err := uuid.Parse(ctx.Params("staffId"))
+	if err != nil {
+		return errInvalidStaffID
+	}
+
+	var blockedTime model.BlockedTime
+	if err := ctx.BodyParser(&blockedTime); err != nil {
+		return errInvalidRequest
+	}
+
+	blockedTime.ID = uuid.Nil
+	blockedTime.StaffID = staffID
+	createdBlockedTime, err := c.blockedTimeService.CreateBlockedTime(&blockedTime)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"blocked_time": createdBlockedTime,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// DeleteBlockedTime godoc
+// @Summary ブロック時間削除
+// @Description IDで指定したブロック時間を削除します
+// @Tags シフト管理
+// @Accept json
+// @Produce json
+// @Param staffId path string true "スタッフID"
+// @Param id path string true "ブロック時間ID"
+// @Success 204 "削除成功"
+// @Router /staff/{staffId}/blocked-times/{id} [delete]
+func (c *BlockedTimeController) DeleteBlockedTime(ctx *fiber.Ctx) error {
+	staffID, errStaff := uuid.Parse(ctx.Params("staffId"))
+	id, errID := uuid.Parse(ctx.Params("id"))
+	if errStaff != nil || errID != nil {
+		return errInvalidID
+	}
+
+	if err := c.blockedTimeService.DeleteBlockedTime(staffID, id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
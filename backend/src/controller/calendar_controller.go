@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"app/src/middleware"
+	"app/src/model"
+	"app/src/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CalendarController struct {
+	calendarService *service.CalendarService
+}
+
+func NewCalendarController(calendarService *service.CalendarService) *CalendarController {
+	return &CalendarController{
+		calendarService: calendarService,
+	}
+}
+
+// GetFeed godoc
+// @Summary カレンダー購読フィード
+// @Description 購読URLの秘密トークンに対応する予約（スタッフの場合はシフトも含む）を iCalendar 形式で返します。キャンセルされた予約は STATUS:CANCELLED で返します
+// @Tags カレンダー連携
+// @Produce text/calendar
+// @Param token path string true "購読トークン"
+// @Success 200 {string} string "iCalendar"
+// @Router /calendar/{token}.ics [get]
+func (c *CalendarController) GetFeed(ctx *fiber.Ctx) error {
+	calendar, err := c.calendarService.FeedCalendar(ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	ctx.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return ctx.SendString(calendar)
+}
+
+// IssueStaffFeed godoc
+// @Summary スタッフのカレンダー購読URL発行
+// @Description スタッフの予約とシフトを購読するURLを発行します。再発行すると以前のURLは使えなくなります。スタッフ・管理者のみ実行できます
+// @Tags カレンダー連携
+// @Produce json
+// @Security BearerAuth
+// @Param staffId path string true "スタッフID"
+// @Success 201 {object} map[string]interface{} "購読URL"
+// @Router /staff/{staffId}/calendar-feed [post]
+func (c *CalendarController) IssueStaffFeed(ctx *fiber.Ctx) error {
+	staffID, err := uuid.Parse(ctx.Params("staffId"))
+	if err != nil {
+		return errInvalidID
+	}
+	return c.issueFeed(ctx, model.CalendarFeedOwnerStaff, staffID)
+}
+
+// RevokeStaffFeed godoc
+// @Summary スタッフのカレンダー購読URL無効化
+// @Tags カレンダー連携
+// @Security BearerAuth
+// @Param staffId path string true "スタッフID"
+// @Success 204 "無効化成功"
+// @Router /staff/{staffId}/calendar-feed [delete]
+func (c *CalendarController) RevokeStaffFeed(ctx *fiber.Ctx) error {
+	staffID, err := uuid.Parse(ctx.Params("staffId"))
+	if err != nil {
+		return errInvalidID
+	}
+	return c.revokeFeed(ctx, model.CalendarFeedOwnerStaff, staffID)
+}
+
+// IssueCustomerFeed godoc
+// @Summary 顧客のカレンダー購読URL発行
+// @Description 顧客の予約を購読するURLを発行します。再発行すると以前のURLは使えなくなります。スタッフ・管理者のみ実行できます
+// @Tags カレンダー連携
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "顧客ID"
+// @Success 201 {object} map[string]interface{} "購読URL"
+// @Router /customers/{id}/calendar-feed [post]
+func (c *CalendarController) IssueCustomerFeed(ctx *fiber.Ctx) error {
+	customerID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+	return c.issueFeed(ctx, model.CalendarFeedOwnerCustomer, customerID)
+}
+
+// RevokeCustomerFeed godoc
+// @Summary 顧客のカレンダー購読URL無効化
+// @Tags カレンダー連携
+// @Security BearerAuth
+// @Param id path string true "顧客ID"
+// @Success 204 "無効化成功"
+// @Router /customers/{id}/calendar-feed [delete]
+func (c *CalendarController) RevokeCustomerFeed(ctx *fiber.Ctx) error {
+	customerID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+	return c.revokeFeed(ctx, model.CalendarFeedOwnerCustomer, customerID)
+}
+
+// IssueMyFeed godoc
+// @Summary 自分のカレンダー購読URL発行
+// @Description ログイン中の顧客の予約を購読するURLを発行します。再発行すると以前のURLは使えなくなります
+// @Tags マイページ
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "購読URL"
+// @Router /me/calendar-feed [post]
+func (c *CalendarController) IssueMyFeed(ctx *fiber.Ctx) error {
+	return c.issueFeed(ctx, model.CalendarFeedOwnerCustomer, middleware.CustomerID(ctx))
+}
+
+func (c *CalendarController) issueFeed(ctx *fiber.Ctx, ownerType model.CalendarFeedOwner, ownerID uuid.UUID) error {
+	token, err := c.calendarService.IssueFeed(ownerType, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"url": service.CalendarFeedURL(token),
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+func (c *CalendarController) revokeFeed(ctx *fiber.Ctx, ownerType model.CalendarFeedOwner, ownerID uuid.UUID) error {
+	if err := c.calendarService.RevokeFeed(ownerType, ownerID); err != nil {
+		return err
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
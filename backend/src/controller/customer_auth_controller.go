@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CustomerAuthController struct {
+	authService *service.CustomerAuthService
+}
+
+func NewCustomerAuthController(authService *service.CustomerAuthService) *CustomerAuthController {
+	return &CustomerAuthController{
+		authService: authService,
+	}
+}
+
+// RequestLogin godoc
+// @Summary ログインコードの送信
+// @Description メール（マジックリンク付き）またはSMSでワンタイムコードを送信します。未登録の宛先は初回ログイン時に顧客として登録されます
+// @Tags 顧客認証
+// @Accept json
+// @Produce json
+// @Param login body map[string]string true "channel (email, sms) と destination"
+// @Success 202 {object} map[string]interface{} "送信結果"
+// @Failure 429 {object} map[string]interface{} "送信回数の上限"
+// @Router /auth/customer/login [post]
+func (c *CustomerAuthController) RequestLogin(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		Channel     string `json:"channel" validate:"required,oneof=email sms"`
+		Destination string `json:"destination" validate:"required"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	expiresAt, err := c.authService.RequestLogin(requestBody.Channel, requestBody.Destination, i18n.FromCtx(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"message":    i18n.T(i18n.FromCtx(ctx), "message.login_code_sent", nil),
+			"expires_at": expiresAt,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// VerifyLoginCode godoc
+// @Summary ワンタイムコードでログイン
+// @Description 送信されたワンタイムコードを確認し、顧客のアクセストークンを発行します
+// @Tags 顧客認証
+// @Accept json
+// @Produce json
+// @Param verify body map[string]string true "channel, destination, code, name（初回のみ任意）"
+// @Success 200 {object} map[string]interface{} "トークンと顧客情報"
+// @Router /auth/customer/verify [post]
+func (c *CustomerAuthController) VerifyLoginCode(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		Channel     string `json:"channel" validate:"required,oneof=email sms"`
+		Destination string `json:"destination" validate:"required"`
+		Code        string `json:"code" validate:"required,len=6"`
+		Name        string `json:"name,omitempty" validate:"max=100"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	customer, tokens, isNew, err := c.authService.VerifyCode(requestBody.Channel, requestBody.Destination, requestBody.Code, requestBody.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.loggedIn(ctx, customer, tokens, isNew)
+}
+
+// GetMagicLink godoc
+// @Summary マジックリンクの確認
+// @Description メールのマジックリンクから開く確認ページです。リンクのプレビューやメールのスキャンでログインが完了しないよう、ここではトークンを使用済みにせず、ログインボタンから POST でログインします
+// @Tags 顧客認証
+// @Produce html
+// @Produce json
+// @Param token path string true "リンクのトークン"
+// @Success 200 {object} map[string]interface{} "リンクの有効期限"
+// @Router /auth/customer/magic/{token} [get]
+func (c *CustomerAuthController) GetMagicLink(ctx *fiber.Ctx) error {
+	token := ctx.Params("token")
+	expiresAt, err := c.authService.CheckMagicLink(token)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML {
+		locale := i18n.FromCtx(ctx)
+		return renderActionPage(ctx, actionPageData{
+			Locale:  locale,
+			Title:   i18n.T(locale, "page.magic_link.title", nil),
+			Message: i18n.T(locale, "page.magic_link.body", nil),
+			Action:  "/v1/auth/customer/magic/" + token,
+			Button:  i18n.T(locale, "page.magic_link.login", nil),
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"expires_at": expiresAt,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// VerifyMagicLink godoc
+// @Summary マジックリンクでログイン
+// @Description 確認ページのフォームからマジックリンクのトークンを確認し、顧客のアクセストークンを発行します
+// @Tags 顧客認証
+// @Produce json
+// @Param token path string true "リンクのトークン"
+// @Success 200 {object} map[string]interface{} "トークンと顧客情報"
+// @Router /auth/customer/magic/{token} [post]
+func (c *CustomerAuthController) VerifyMagicLink(ctx *fiber.Ctx) error {
+	customer, tokens, isNew, err := c.authService.VerifyMagicLink(ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.loggedIn(ctx, customer, tokens, isNew)
+}
+
+// RefreshToken godoc
+// @Summary トークンの更新
+// @Description リフレッシュトークンから新しいアクセストークンを発行します
+// @Tags 顧客認証
+// @Accept json
+// @Produce json
+// @Param refresh body map[string]string true "refresh_token"
+// @Success 200 {object} service.CustomerTokens "新しいトークン"
+// @Router /auth/customer/refresh [post]
+func (c *CustomerAuthController) RefreshToken(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	tokens, err := c.authService.RefreshTokens(requestBody.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"tokens": tokens,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+func (c *CustomerAuthController) loggedIn(ctx *fiber.Ctx, customer *model.Customer, tokens *service.CustomerTokens, isNew bool) error {
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"customer": customer,
+			"tokens":   tokens,
+			"is_new":   isNew,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
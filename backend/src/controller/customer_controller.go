@@ -1,9 +1,17 @@
 package controller
 
 import (
+	"app/src/i18n"
+	"app/src/middleware"
 	"app/src/model"
 	"app/src/service"
+	"app/src/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -21,35 +29,196 @@ func NewCustomerController(customerService *service.CustomerService) *CustomerCo
 
 // GetCustomers godoc
 // @Summary 顧客一覧取得
-// @Description ページング付きで全顧客を取得します
+// @Description ページング付きで全顧客を取得します。cursor を指定すると前のページの pagination.next_cursor の続きから取得し、page は無視されます
 // @Tags 顧客管理
 // @Accept json
 // @Produce json
-// @Param page query int false "ページ番号" default(1)
+// @Param page query int false "ページ番号（オフセット方式）" default(1)
 // @Param limit query int false "ページサイズ" default(10)
+// @Param cursor query string false "次のページのカーソル（カーソル方式）"
+// @Param sort query string false "並び順 (created_at, name。先頭に - で降順)" default(-created_at)
+// @Param include_total query bool false "総件数を返すか。省略時はオフセット方式のみ返す"
 // @Success 200 {object} map[string]interface{} "顧客一覧"
 // @Router /customers [get]
 func (c *CustomerController) GetCustomers(ctx *fiber.Ctx) error {
-	page := ctx.QueryInt("page", 1)
-	limit := ctx.QueryInt("limit", 10)
+	customers, page, err := c.customerService.GetCustomers(listOptions(ctx, 10))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data":       customers,
+		"pagination": paginationResponse(page),
+	})
+}
 
-	customers, total, err := c.customerService.GetCustomers(page, limit)
+// SearchCustomers godoc
+// @Summary 顧客検索
+// @Description 氏名・フリガナ・電話番号・メールアドレスで顧客を検索します。全角・半角、ひらがな・カタカナ、電話番号のハイフンは区別しません
+// @Tags 顧客管理
+// @Accept json
+// @Produce json
+// @Param q query string true "検索キーワード（空白区切りですべてに一致）"
+// @Param match query string false "一致方法 (prefix, fuzzy)" default(prefix)
+// @Param limit query int false "最大件数" default(20)
+// @Success 200 {object} map[string]interface{} "検索結果"
+// @Router /customers/search [get]
+func (c *CustomerController) SearchCustomers(ctx *fiber.Ctx) error {
+	customers, err := c.customerService.SearchCustomers(ctx.Query("q"), ctx.Query("match"), ctx.QueryInt("limit", 0))
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get customers",
-		})
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
 		"data": customers,
-		"pagination": fiber.Map{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
 	})
 }
 
+// ImportCustomers godoc
+// @Summary 顧客CSVの取り込み
+// @Description 紙の台帳や他システムから移行する顧客を CSV で一括登録します。UTF-8・Shift_JIS に対応し、正規化した電話番号が既存の顧客と一致した行は duplicate の方法で扱います。dry_run では保存せずに検証結果だけを返します
+// @Tags 顧客管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSVファイル（1行目は見出し）"
+// @Param encoding formData string false "文字コード (auto, utf-8, shift_jis)" default(auto)
+// @Param mapping formData string false "項目名から見出しへの対応付け（JSON、例: {\"name\":\"お客様名\",\"phone\":\"TEL\"}）"
+// @Param duplicate formData string false "重複時の扱い (skip, update, merge)" default(skip)
+// @Param dry_run formData bool false "検証のみ行う" default(false)
+// @Success 200 {object} service.CustomerImportReport "取り込み結果"
+// @Router /customers/import [post]
+func (c *CustomerController) ImportCustomers(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return errCSVFileRequired
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return errCSVFileUnreadable
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return errCSVFileUnreadable
+	}
+
+	options := service.CustomerImportOptions{
+		Encoding:  ctx.FormValue("encoding"),
+		Duplicate: ctx.FormValue("duplicate"),
+		DryRun:    ctx.FormValue("dry_run") == "true",
+		Locale:    i18n.FromCtx(ctx),
+	}
+	if mapping := ctx.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			return errInvalidColumnMapping
+		}
+	}
+
+	report, err := c.customerService.ImportCustomersCSV(data, options)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": report,
+	})
+}
+
+// ExportCustomers godoc
+// @Summary 顧客CSVの出力
+// @Description 条件に合う顧客を CSV で出力します。件数が多くても少しずつ読み込みながら送ります。出力した CSV はそのまま取り込めます
+// @Tags 顧客管理
+// @Produce text/csv
+// @Security BearerAuth
+// @Param status query string false "状態 (active, inactive, all)" default(active)
+// @Param gender query string false "性別 (male, female, other)"
+// @Param created_from query string false "登録日の開始 (YYYY-MM-DD)"
+// @Param created_to query string false "登録日の終了 (YYYY-MM-DD)"
+// @Param encoding query string false "文字コード (utf-8, shift_jis)" default(utf-8)
+// @Success 200 {string} string "顧客CSV"
+// @Router /customers/export [get]
+func (c *CustomerController) ExportCustomers(ctx *fiber.Ctx) error {
+	filter := service.CustomerExportFilter{
+		Status:      ctx.Query("status"),
+		Gender:      ctx.Query("gender"),
+		CreatedFrom: ctx.Query("created_from"),
+		CreatedTo:   ctx.Query("created_to"),
+		Encoding:    ctx.Query("encoding"),
+	}
+	write, err := c.customerService.ExportCustomersCSV(filter)
+	if err != nil {
+		return err
+	}
+
+	charset := "utf-8"
+	if filter.Encoding == service.CSVEncodingShiftJIS {
+		charset = "shift_jis"
+	}
+	ctx.Attachment(fmt.Sprintf("customers-%s.csv", time.Now().Format("20060102")))
+	ctx.Set(fiber.HeaderContentType, "text/csv; charset="+charset)
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The status line has already been sent, a failure can only cut the file short
+		if err := write(w); err != nil {
+			utils.Log.Errorf("Failed to stream customer export: %v", err)
+		}
+	})
+	return nil
+}
+
+// GetDuplicateCandidates godoc
+// @Summary 重複顧客の候補一覧
+// @Description 正規化した電話番号・メールアドレスの一致と名前の類似度から、同一人物の可能性がある顧客の組を返します
+// @Tags 顧客管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "最大件数" default(100)
+// @Success 200 {object} map[string]interface{} "重複候補"
+// @Router /customers/duplicates [get]
+func (c *CustomerController) GetDuplicateCandidates(ctx *fiber.Ctx) error {
+	candidates, err := c.customerService.FindDuplicateCandidates(ctx.QueryInt("limit", 100))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": candidates,
+	})
+}
+
+// MergeCustomer godoc
+// @Summary 重複顧客の統合
+// @Description 重複側の顧客の予約・キャンセル待ち・通知履歴・通知設定を指定した顧客に付け替え、重複側を無効化します
+// @Tags 顧客管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "残す顧客のID"
+// @Param merge body map[string]string true "duplicate_id（統合して無効化する顧客のID）"
+// @Success 200 {object} service.CustomerMergeResult "統合結果"
+// @Router /customers/{id}/merge [post]
+func (c *CustomerController) MergeCustomer(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidCustomerID
+	}
+
+	var requestBody struct {
+		DuplicateID uuid.UUID `json:"duplicate_id"`
+	}
+	if err := ctx.BodyParser(&requestBody); err != nil || requestBody.DuplicateID == uuid.Nil {
+		return errInvalidDuplicateCustomerID
+	}
+
+	result, err := c.customerService.MergeCustomers(id, requestBody.DuplicateID, auditActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(result)
+}
+
 // GetCustomer godoc
 // @Summary 顧客詳細取得
 // @Description IDで指定した顧客の詳細情報を取得します
@@ -62,21 +231,52 @@ func (c *CustomerController) GetCustomers(ctx *fiber.Ctx) error {
 func (c *CustomerController) GetCustomer(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid customer ID",
-		})
+		return errInvalidCustomerID
 	}
 
 	customer, err := c.customerService.GetCustomerByID(id)
 	if err != nil {
-		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Customer not found",
-		})
+		return err
 	}
 
 	return ctx.JSON(customer)
 }
 
+// GetCustomerProfile godoc
+// @Summary 顧客プロフィール取得
+// @Description 顧客情報に加え、来店回数・初回と最終来店日・平均来店間隔・累計利用金額・よく利用するメニューとスタッフ・キャンセルと無断キャンセルの件数、新しい順の予約履歴を返します
+// @Tags 顧客管理
+// @Accept json
+// @Produce json
+// @Param id path string true "顧客ID"
+// @Param page query int false "予約履歴のページ番号" default(1)
+// @Param limit query int false "予約履歴のページサイズ" default(10)
+// @Success 200 {object} map[string]interface{} "顧客プロフィール"
+// @Router /customers/{id}/profile [get]
+func (c *CustomerController) GetCustomerProfile(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidCustomerID
+	}
+
+	page := ctx.QueryInt("page", 1)
+	limit := ctx.QueryInt("limit", 10)
+
+	profile, total, err := c.customerService.GetCustomerProfile(id, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": profile,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
 // CreateCustomer godoc
 // @Summary 顧客新規登録
 // @Description 新しい顧客を登録します
@@ -89,16 +289,12 @@ func (c *CustomerController) GetCustomer(ctx *fiber.Ctx) error {
 func (c *CustomerController) CreateCustomer(ctx *fiber.Ctx) error {
 	var customer model.Customer
 	if err := ctx.BodyParser(&customer); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return errInvalidRequest
 	}
 
 	createdCustomer, err := c.customerService.CreateCustomer(&customer)
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return ctx.Status(http.StatusCreated).JSON(createdCustomer)
@@ -117,24 +313,18 @@ func (c *CustomerController) CreateCustomer(ctx *fiber.Ctx) error {
 func (c *CustomerController) UpdateCustomer(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid customer ID",
-		})
+		return errInvalidCustomerID
 	}
 
 	var customer model.Customer
 	if err := ctx.BodyParser(&customer); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return errInvalidRequest
 	}
 
 	customer.ID = id
 	updatedCustomer, err := c.customerService.UpdateCustomer(&customer)
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return ctx.JSON(updatedCustomer)
@@ -152,17 +342,73 @@ func (c *CustomerController) UpdateCustomer(ctx *fiber.Ctx) error {
 func (c *CustomerController) DeleteCustomer(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid customer ID",
-		})
+		return errInvalidCustomerID
 	}
 
 	err = c.customerService.DeleteCustomer(id)
 	if err != nil {
-		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
-			"error": "Customer not found",
-		})
+		return err
 	}
 
 	return ctx.SendStatus(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// ExportCustomerData godoc
+// @Summary 顧客データの開示
+// @Description 個人情報保護法に基づく開示請求向けに、顧客について保有するプロフィール・予約・通知・ログイン履歴・監査ログをJSONで出力します。出力したことは監査ログに記録されます。管理者のみ実行できます
+// @Tags 顧客管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "顧客ID"
+// @Success 200 {object} service.CustomerDataExport "顧客データ"
+// @Router /customers/{id}/export [get]
+func (c *CustomerController) ExportCustomerData(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidCustomerID
+	}
+
+	export, err := c.customerService.ExportCustomerData(id, auditActor(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx.Attachment(fmt.Sprintf("customer-%s.json", id))
+	return ctx.JSON(export)
+}
+
+// EraseCustomer godoc
+// @Summary 顧客の個人情報の消去
+// @Description 個人情報保護法に基づく削除請求向けに、顧客の氏名・連絡先・メモなどを匿名化します。売上集計のため予約と金額は残ります。消去したことは監査ログに記録されます。管理者のみ実行できます
+// @Tags 顧客管理
+// @Security BearerAuth
+// @Param id path string true "顧客ID"
+// @Success 204 "消去成功"
+// @Router /customers/{id}/erase [post]
+func (c *CustomerController) EraseCustomer(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidCustomerID
+	}
+
+	if err := c.customerService.EraseCustomer(id, auditActor(ctx)); err != nil {
+		switch err.Error() {
+		case "customer not found":
+			return err
+		case "customer already erased":
+			return err
+		}
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// auditActor takes the actor recorded in audit logs from the request. The actor is staff or an admin verified by StaffAuth
+func auditActor(ctx *fiber.Ctx) service.AuditActor {
+	return service.AuditActor{
+		UserID:    middleware.StaffID(ctx),
+		IPAddress: ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+}
@@ -0,0 +1,23 @@
+package controller
+
+import "app/src/apperror"
+
+// Malformed requests. Like the errors returned by services, ErrorHandler turns them into the common error response
+var (
+	errInvalidRequest             = apperror.Validation("error.invalid_request")
+	errInvalidID                  = apperror.Validation("error.invalid_id")
+	errInvalidReservationID       = apperror.Validation("error.invalid_reservation_id")
+	errInvalidWaitlistEntryID     = apperror.Validation("error.invalid_waitlist_entry_id")
+	errInvalidStaffID             = apperror.Validation("error.invalid_staff_id")
+	errInvalidFormat              = apperror.Validation("error.invalid_format")
+	errAvailabilityQueryRequired  = apperror.Validation("error.availability_query_required")
+	errMatchWaitlistRequired      = apperror.Validation("error.match_waitlist_required")
+	errInvalidCustomerID          = apperror.Validation("error.invalid_customer_id")
+	errInvalidDuplicateCustomerID = apperror.Validation("error.invalid_duplicate_customer_id")
+	errCSVFileRequired            = apperror.Validation("error.csv_file_required")
+	errCSVFileUnreadable          = apperror.Validation("error.csv_file_unreadable")
+	errInvalidColumnMapping       = apperror.Validation("error.invalid_column_mapping")
+)
+
+// A request that is not from an admin set an option only admins may set
+var errOverrideBlockedTimeForbidden = apperror.Forbidden("error.override_blocked_time_forbidden")
@@ -34,6 +34,60 @@ func (h *HealthCheckController) addServiceStatus(
 	})
 }
 
+// runCheck runs check, adds its result to serviceList and reports whether it passed
+func (h *HealthCheckController) runCheck(serviceList *[]response.HealthCheck, name string, check func() error) bool {
+	if err := check(); err != nil {
+		errMsg := err.Error()
+		h.addServiceStatus(serviceList, name, false, &errMsg)
+		return false
+	}
+
+	h.addServiceStatus(serviceList, name, true, nil)
+	return true
+}
+
+// probeResponse writes the result of a probe. Failures return 503 so load balancers and orchestrators can act on them
+func (h *HealthCheckController) probeResponse(c *fiber.Ctx, message string, isHealthy bool, serviceList []response.HealthCheck) error {
+	statusCode := fiber.StatusOK
+	status := "success"
+
+	if !isHealthy {
+		statusCode = fiber.StatusServiceUnavailable
+		status = "error"
+	}
+
+	return c.Status(statusCode).JSON(response.HealthCheckResponse{
+		Status:    status,
+		Message:   message,
+		Code:      statusCode,
+		IsHealthy: isHealthy,
+		Result:    serviceList,
+	})
+}
+
+// Live is the liveness probe. It only checks that the process responds and the heap size, and never fails because of a dependency.
+// It is registered outside /v1, so it is not part of the API spec
+func (h *HealthCheckController) Live(c *fiber.Ctx) error {
+	var serviceList []response.HealthCheck
+
+	isHealthy := h.runCheck(&serviceList, "Memory", h.HealthCheckService.MemoryHeapCheck)
+
+	return h.probeResponse(c, "Liveness check completed", isHealthy, serviceList)
+}
+
+// Ready is the readiness probe. It checks the database connection, the migration version, that SMTP is reachable and that the background workers run.
+// It returns 503 when any of them fails, so no requests are routed here meanwhile
+func (h *HealthCheckController) Ready(c *fiber.Ctx) error {
+	var serviceList []response.HealthCheck
+
+	isHealthy := h.runCheck(&serviceList, "Postgre", h.HealthCheckService.GormCheck)
+	isHealthy = h.runCheck(&serviceList, "Migration", h.HealthCheckService.MigrationCheck) && isHealthy
+	isHealthy = h.runCheck(&serviceList, "SMTP", h.HealthCheckService.SMTPCheck) && isHealthy
+	isHealthy = h.runCheck(&serviceList, "Workers", h.HealthCheckService.WorkerCheck) && isHealthy
+
+	return h.probeResponse(c, "Readiness check completed", isHealthy, serviceList)
+}
+
 // @Tags ヘルスチェック
 // @Summary ヘルスチェック
 // @Description サービスとデータベース接続の状態を確認します
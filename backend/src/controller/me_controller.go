@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"app/src/i18n"
+	"app/src/middleware"
+	"app/src/service"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MeController is the API for the signed-in customer. The customer ID always comes from the token
+type MeController struct {
+	portalService *service.CustomerPortalService
+}
+
+func NewMeController(portalService *service.CustomerPortalService) *MeController {
+	return &MeController{
+		portalService: portalService,
+	}
+}
+
+// GetProfile godoc
+// @Summary 自分のプロフィール取得
+// @Description ログイン中の顧客のプロフィールを取得します
+// @Tags マイページ
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.Customer "プロフィール"
+// @Router /me [get]
+func (c *MeController) GetProfile(ctx *fiber.Ctx) error {
+	customer, err := c.portalService.GetProfile(middleware.CustomerID(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"customer": customer,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// UpdateProfile godoc
+// @Summary 自分のプロフィール更新
+// @Description 氏名・フリガナ・誕生日・性別・通知の言語（ja, en）を更新します。電話番号とメールアドレスはログインに使うため変更できません
+// @Tags マイページ
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body map[string]interface{} true "プロフィール"
+// @Success 200 {object} model.Customer "更新後のプロフィール"
+// @Router /me [put]
+func (c *MeController) UpdateProfile(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		Name     string `json:"name,omitempty"`
+		NameKana string `json:"name_kana,omitempty"`
+		Email    string `json:"email,omitempty"`
+		Birthday string `json:"birthday,omitempty"`
+		Gender   string `json:"gender,omitempty"`
+		Locale   string `json:"locale,omitempty"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	var birthday *time.Time
+	if requestBody.Birthday != "" {
+		parsed, err := time.Parse("2006-01-02", requestBody.Birthday)
+		if err != nil {
+			return errInvalidRequest
+		}
+		birthday = &parsed
+	}
+
+	customer, err := c.portalService.UpdateProfile(middleware.CustomerID(ctx), requestBody.Name, requestBody.NameKana, requestBody.Email, birthday, requestBody.Gender, requestBody.Locale)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"customer": customer,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// GetMyReservations godoc
+// @Summary 自分の予約一覧
+// @Description これからの予約（upcoming）または過去の予約（past）を取得します
+// @Tags マイページ
+// @Produce json
+// @Security BearerAuth
+// @Param scope query string false "upcoming または past" default(upcoming)
+// @Param page query int false "ページ番号" default(1)
+// @Param limit query int false "ページサイズ" default(20)
+// @Success 200 {object} map[string]interface{} "予約一覧"
+// @Router /me/reservations [get]
+func (c *MeController) GetMyReservations(ctx *fiber.Ctx) error {
+	page := ctx.QueryInt("page", 1)
+	limit := ctx.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	reservations, total, err := c.portalService.GetReservations(middleware.CustomerID(ctx), ctx.Query("scope", service.CustomerReservationsUpcoming), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservations": reservations,
+			"pagination": fiber.Map{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// GetMyReservation godoc
+// @Summary 自分の予約詳細
+// @Description ログイン中の顧客の予約を取得します
+// @Tags マイページ
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "予約ID"
+// @Success 200 {object} model.Reservation "予約"
+// @Router /me/reservations/{id} [get]
+func (c *MeController) GetMyReservation(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	reservation, err := c.portalService.GetReservation(middleware.CustomerID(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservation": reservation,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CreateMyReservation godoc
+// @Summary 予約する
+// @Description ログイン中の顧客として予約を作成します。staff_id を省略するとスタッフを自動で割り当てます
+// @Tags マイページ
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reservation body map[string]interface{} true "予約データ"
+// @Success 201 {object} model.Reservation "作成された予約"
+// @Router /me/reservations [post]
+func (c *MeController) CreateMyReservation(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		StaffID         uuid.UUID   `json:"staff_id,omitempty"`
+		StaffAssignment string      `json:"staff_assignment,omitempty"`
+		ReservationDate string      `json:"reservation_date" validate:"required"`
+		StartTime       string      `json:"start_time" validate:"required"`
+		MenuIDs         []uuid.UUID `json:"menu_ids" validate:"required,min=1"`
+		OptionIDs       []uuid.UUID `json:"option_ids,omitempty"`
+		Notes           string      `json:"notes,omitempty" validate:"max=500"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+	if len(requestBody.MenuIDs) == 0 {
+		return errInvalidRequest
+	}
+
+	reservation, err := c.portalService.CreateReservation(middleware.CustomerID(ctx), requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime, requestBody.MenuIDs, requestBody.OptionIDs, requestBody.Notes, requestBody.StaffAssignment)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservation": reservation,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// RescheduleMyReservation godoc
+// @Summary 予約の日時変更
+// @Description ログイン中の顧客の予約の日時・スタッフを変更します。変更は開始時刻の締切（既定24時間前）まで可能です
+// @Tags マイページ
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "予約ID"
+// @Param reservation body map[string]interface{} true "変更データ"
+// @Success 200 {object} model.Reservation "変更後の予約"
+// @Router /me/reservations/{id} [put]
+func (c *MeController) RescheduleMyReservation(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	var requestBody struct {
+		StaffID         uuid.UUID `json:"staff_id,omitempty"`
+		ReservationDate string    `json:"reservation_date,omitempty"`
+		StartTime       string    `json:"start_time,omitempty"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	reservation, err := c.portalService.RescheduleReservation(middleware.CustomerID(ctx), id, requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservation": reservation,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CancelMyReservation godoc
+// @Summary 予約のキャンセル
+// @Description ログイン中の顧客の予約をキャンセルします。キャンセルは開始時刻の締切（既定24時間前）まで可能です
+// @Tags マイページ
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "予約ID"
+// @Success 200 {object} map[string]interface{} "キャンセル結果"
+// @Router /me/reservations/{id} [delete]
+func (c *MeController) CancelMyReservation(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	if err := c.portalService.CancelReservation(middleware.CustomerID(ctx), id); err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"message":        i18n.T(i18n.FromCtx(ctx), "message.reservation_cancelled", nil),
+			"reservation_id": id,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// GetNotificationPreferences godoc
+// @Summary 通知設定の取得
+// @Description ログイン中の顧客の通知設定を取得します
+// @Tags マイページ
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.NotificationPreference "通知設定"
+// @Router /me/notification-preferences [get]
+func (c *MeController) GetNotificationPreferences(ctx *fiber.Ctx) error {
+	preference, err := c.portalService.GetNotificationPreferences(middleware.CustomerID(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"notification_preferences": preference,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary 通知設定の更新
+// @Description 指定した項目だけ通知設定を更新します
+// @Tags マイページ
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param preferences body map[string]bool true "通知設定"
+// @Success 200 {object} model.NotificationPreference "更新後の通知設定"
+// @Router /me/notification-preferences [put]
+func (c *MeController) UpdateNotificationPreferences(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		EmailEnabled     *bool `json:"email_enabled,omitempty"`
+		SMSEnabled       *bool `json:"sms_enabled,omitempty"`
+		ReminderEnabled  *bool `json:"reminder_enabled,omitempty"`
+		MarketingEnabled *bool `json:"marketing_enabled,omitempty"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	preference, err := c.portalService.UpdateNotificationPreferences(middleware.CustomerID(ctx), requestBody.EmailEnabled, requestBody.SMSEnabled, requestBody.ReminderEnabled, requestBody.MarketingEnabled)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"notification_preferences": preference,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
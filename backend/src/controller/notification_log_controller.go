@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type NotificationLogController struct {
+	notificationLogService *service.NotificationLogService
+}
+
+func NewNotificationLogController(notificationLogService *service.NotificationLogService) *NotificationLogController {
+	return &NotificationLogController{
+		notificationLogService: notificationLogService,
+	}
+}
+
+// GetNotificationLogs godoc
+// @Summary 通知履歴一覧取得
+// @Description 顧客に送信した（送信予定の）メール・SMSなどの通知履歴を取得します。cursor を指定すると前のページの pagination.next_cursor の続きから取得し、page は無視されます
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Param page query int false "ページ番号（オフセット方式）" default(1)
+// @Param limit query int false "ページサイズ" default(20)
+// @Param cursor query string false "次のページのカーソル（カーソル方式）"
+// @Param sort query string false "並び順 (created_at。先頭に - で降順)" default(-created_at)
+// @Param include_total query bool false "総件数を返すか。省略時はオフセット方式のみ返す"
+// @Param customer_id query string false "顧客ID絞り込み"
+// @Param type query string false "種類絞り込み (email, sms, push)"
+// @Param status query string false "ステータス絞り込み (pending, sent, failed)"
+// @Success 200 {object} map[string]interface{} "通知履歴一覧"
+// @Router /notifications [get]
+func (c *NotificationLogController) GetNotificationLogs(ctx *fiber.Ctx) error {
+	filter := service.NotificationLogFilter{
+		Type:   ctx.Query("type"),
+		Status: ctx.Query("status"),
+	}
+	if customerID := ctx.Query("customer_id"); customerID != "" {
+		id, err := uuid.Parse(customerID)
+		if err != nil {
+			return errInvalidCustomerID
+		}
+		filter.CustomerID = &id
+	}
+
+	notifications, page, err := c.notificationLogService.GetNotificationLogs(listOptions(ctx, 20), filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"notifications": notifications,
+			"pagination":    paginationResponse(page),
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listOptions reads the list options from the query parameters (page, limit, cursor, sort, include_total).
+// When include_total is omitted, offset pagination returns the total as before and cursor pagination does not count it
+func listOptions(ctx *fiber.Ctx, defaultLimit int) service.ListOptions {
+	options := service.ListOptions{
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", defaultLimit),
+		Cursor: ctx.Query("cursor"),
+		Sort:   ctx.Query("sort"),
+	}
+	options.WithTotal = ctx.QueryBool("include_total", options.Cursor == "")
+	return options
+}
+
+// paginationResponse builds the pagination of a response.
+// Offset pagination includes the page number, and total and total_pages are included when the total was counted
+func paginationResponse(page service.PageInfo) fiber.Map {
+	pagination := fiber.Map{
+		"limit":       page.Limit,
+		"sort":        page.Sort,
+		"has_next":    page.HasNext,
+		"next_cursor": page.NextCursor,
+	}
+	if page.Page > 0 {
+		pagination["page"] = page.Page
+		pagination["has_prev"] = page.Page > 1
+	}
+	if page.Total != nil {
+		pagination["total"] = *page.Total
+		pagination["total_pages"] = (*page.Total + int64(page.Limit) - 1) / int64(page.Limit)
+	}
+	return pagination
+}
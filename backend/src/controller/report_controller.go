@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"app/src/service"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReportController struct {
+	reportService *service.ReportService
+}
+
+func NewReportController(reportService *service.ReportService) *ReportController {
+	return &ReportController{
+		reportService: reportService,
+	}
+}
+
+// GetSalesReport godoc
+// @Summary 売上・予約レポート
+// @Description 日次・週次（月曜始まり）・月次の売上（完了した予約の合計金額）、状態別の予約件数、平均客単価と、メニュー・オプション・カテゴリ別の売上を集計します。format=csv で section に指定した内訳を CSV で出力します
+// @Tags レポート
+// @Produce json
+// @Produce text/csv
+// @Param period query string false "集計単位 (daily, weekly, monthly)" default(daily)
+// @Param date_from query string false "予約日の開始 (YYYY-MM-DD)"
+// @Param date_to query string false "予約日の終了 (YYYY-MM-DD)"
+// @Param staff_id query string false "スタッフIDで絞り込み"
+// @Param format query string false "出力形式 (json, csv)" default(json)
+// @Param section query string false "CSVに出力する内訳 (periods, menus, options, categories)" default(periods)
+// @Success 200 {object} service.SalesReport "レポート"
+// @Router /reports/sales [get]
+func (c *ReportController) GetSalesReport(ctx *fiber.Ctx) error {
+	filter := service.ReportFilter{
+		Period:   ctx.Query("period"),
+		DateFrom: ctx.Query("date_from"),
+		DateTo:   ctx.Query("date_to"),
+	}
+	if staffID := ctx.Query("staff_id"); staffID != "" {
+		parsed, err := uuid.Parse(staffID)
+		if err != nil {
+			return errInvalidStaffID
+		}
+		filter.StaffID = &parsed
+	}
+
+	format := ctx.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return errInvalidFormat
+	}
+
+	report, err := c.reportService.GetSalesReport(filter)
+	if err != nil {
+		return err
+	}
+
+	if format == "csv" {
+		section := ctx.Query("section", service.ReportSectionPeriods)
+		var body strings.Builder
+		if err := service.WriteSalesReportCSV(&body, report, section); err != nil {
+			return err
+		}
+		ctx.Attachment(fmt.Sprintf("sales-%s-%s-%s_%s.csv", report.Period, section, report.DateFrom, report.DateTo))
+		ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		return ctx.SendString(body.String())
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"report": report,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
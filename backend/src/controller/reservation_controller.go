@@ -1,9 +1,10 @@
 package controller
 
 import (
+	"app/src/i18n"
+	"app/src/middleware"
 	"app/src/service"
 	"net/http"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -21,45 +22,71 @@ func NewReservationController(reservationService service.ReservationServiceInter
 
 // GetReservations godoc
 // @Summary 予約一覧取得
-// @Description ページング・フィルター付きで全予約を取得します
+// @Description ページング・フィルター付きで全予約を取得します。cursor を指定すると前のページの pagination.next_cursor の続きから取得し、page は無視されます
 // @Tags 予約管理
 // @Accept json
 // @Produce json
-// @Param page query int false "ページ番号" default(1)
-// @Param limit query int false "ページサイズ" default(10)
+// @Param page query int false "ページ番号（オフセット方式）" default(1)
+// @Param limit query int false "ページサイズ" default(20)
+// @Param cursor query string false "次のページのカーソル（カーソル方式）"
+// @Param sort query string false "並び順 (reservation_date, created_at。先頭に - で降順)" default(-reservation_date)
+// @Param include_total query bool false "総件数を返すか。省略時はオフセット方式のみ返す"
 // @Param status query string false "ステータス絞り込み"
-// @Param date query string false "日付絞り込み (YYYY-MM-DD)"
+// @Param staff_id query string false "スタッフID絞り込み"
+// @Param customer_id query string false "顧客ID絞り込み"
+// @Param date_from query string false "開始日 (YYYY-MM-DD)"
+// @Param date_to query string false "終了日 (YYYY-MM-DD)"
 // @Success 200 {object} map[string]interface{} "予約一覧"
 // @Router /reservations [get]
 func (c *ReservationController) GetReservations(ctx *fiber.Ctx) error {
+	filter := service.ReservationFilter{
+		Status:     ctx.Query("status"),
+		StaffID:    ctx.Query("staff_id"),
+		CustomerID: ctx.Query("customer_id"),
+		DateFrom:   ctx.Query("date_from"),
+		DateTo:     ctx.Query("date_to"),
+	}
+
+	reservations, page, err := c.reservationService.WithContext(ctx.UserContext()).GetReservations(listOptions(ctx, 20), filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservations": reservations,
+			"pagination":   paginationResponse(page),
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// GetNoShowReview godoc
+// @Summary 無断キャンセル確認待ちの予約一覧
+// @Description 開始から一定時間（既定30分）を過ぎても確定済みのままの予約を返します。来店済み（completed）か無断キャンセル（no_show）にステータスを更新すると一覧から外れます
+// @Tags 予約管理
+// @Accept json
+// @Produce json
+// @Param page query int false "ページ番号" default(1)
+// @Param limit query int false "ページサイズ" default(20)
+// @Success 200 {object} map[string]interface{} "確認待ちの予約一覧"
+// @Router /reservations/no-show-review [get]
+func (c *ReservationController) GetNoShowReview(ctx *fiber.Ctx) error {
 	page := ctx.QueryInt("page", 1)
 	limit := ctx.QueryInt("limit", 20)
 	if limit > 100 {
 		limit = 100
 	}
-	status := ctx.Query("status")
-	staffID := ctx.Query("staff_id")
-	customerID := ctx.Query("customer_id")
-	dateFrom := ctx.Query("date_from")
-	dateTo := ctx.Query("date_to")
 
-	reservations, total, err := c.reservationService.GetReservations(page, limit, status, staffID, customerID, dateFrom, dateTo)
+	reservations, total, err := c.reservationService.WithContext(ctx.UserContext()).GetNoShowReview(page, limit)
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INTERNAL_ERROR",
-				"message": "予約一覧の取得に失敗しました",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
 	}
 
 	totalPages := (total + int64(limit) - 1) / int64(limit)
-	hasNext := int64(page) < totalPages
-	hasPrev := page > 1
 
 	return ctx.JSON(fiber.Map{
 		"success": true,
@@ -70,8 +97,8 @@ func (c *ReservationController) GetReservations(ctx *fiber.Ctx) error {
 				"limit":       limit,
 				"total":       total,
 				"total_pages": totalPages,
-				"has_next":    hasNext,
-				"has_prev":    hasPrev,
+				"has_next":    int64(page) < totalPages,
+				"has_prev":    page > 1,
 			},
 		},
 		"meta": fiber.Map{
@@ -92,30 +119,12 @@ func (c *ReservationController) GetReservations(ctx *fiber.Ctx) error {
 func (c *ReservationController) GetReservation(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効な予約IDです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidReservationID
 	}
 
-	reservation, err := c.reservationService.GetReservationByID(id)
+	reservation, err := c.reservationService.WithContext(ctx.UserContext()).GetReservationByID(id)
 	if err != nil {
-		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "予約が見つかりません",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
@@ -131,7 +140,8 @@ func (c *ReservationController) GetReservation(ctx *fiber.Ctx) error {
 
 // CreateReservation godoc
 // @Summary 新規予約作成
-// @Description メニューとオプションを含む新しい予約を作成します
+// @Description メニューとオプションを含む新しい予約を作成します。staff_id を省略すると staff_assignment（least_booked, round_robin, previous_stylist）で自動割当します。
+// @Description 無断キャンセル・直前キャンセルの多い顧客は acknowledge_risk を指定しないと予約できず、注意が必要な顧客の場合はレスポンスに customer_risk が含まれます
 // @Tags 予約管理
 // @Accept json
 // @Produce json
@@ -140,54 +150,44 @@ func (c *ReservationController) GetReservation(ctx *fiber.Ctx) error {
 // @Router /reservations [post]
 func (c *ReservationController) CreateReservation(ctx *fiber.Ctx) error {
 	var requestBody struct {
-		CustomerID      uuid.UUID   `json:"customer_id" validate:"required"`
-		StaffID         uuid.UUID   `json:"staff_id" validate:"required"`
+		CustomerID uuid.UUID `json:"customer_id" validate:"required"`
+		// Omitting StaffID means "no preference", and a staff member is assigned with StaffAssignment
+		StaffID         uuid.UUID   `json:"staff_id,omitempty"`
+		StaffAssignment string      `json:"staff_assignment,omitempty" validate:"omitempty,oneof=least_booked round_robin previous_stylist"`
 		ReservationDate string      `json:"reservation_date" validate:"required"`
 		StartTime       string      `json:"start_time" validate:"required"`
 		MenuIDs         []uuid.UUID `json:"menu_ids" validate:"required,min=1"`
 		OptionIDs       []uuid.UUID `json:"option_ids,omitempty"`
 		Notes           string      `json:"notes,omitempty" validate:"max=500"`
+		// OverrideBlockedTime is set when an admin books over a staff member's blocked time (requires an admin token)
+		OverrideBlockedTime bool `json:"override_blocked_time,omitempty"`
+		// AcknowledgeRisk is set when staff book a high-risk customer after confirming the risk
+		AcknowledgeRisk bool `json:"acknowledge_risk,omitempty"`
 	}
 
 	if err := ctx.BodyParser(&requestBody); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効なリクエストです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidRequest
+	}
+	if requestBody.OverrideBlockedTime && !middleware.IsAdmin(ctx) {
+		return errOverrideBlockedTimeForbidden
 	}
 
-	createdReservation, err := c.reservationService.CreateReservationFromRequest(requestBody.CustomerID, requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime, requestBody.MenuIDs, requestBody.OptionIDs, requestBody.Notes)
+	createdReservation, err := c.reservationService.WithContext(ctx.UserContext()).CreateReservationFromRequest(requestBody.CustomerID, requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime, requestBody.MenuIDs, requestBody.OptionIDs, requestBody.Notes, requestBody.OverrideBlockedTime, requestBody.StaffAssignment, requestBody.AcknowledgeRisk)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		errorCode := "VALIDATION_ERROR"
-		errorMsg := err.Error()
-		if errorMsg == "time slot is already booked" || strings.Contains(errorMsg, "already booked") || strings.Contains(errorMsg, "重複") {
-			statusCode = http.StatusConflict
-			errorCode = "CONFLICT"
-		}
-		return ctx.Status(statusCode).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    errorCode,
-				"message": err.Error(),
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
+	}
+
+	data := fiber.Map{
+		"reservation": createdReservation,
+	}
+	// Let the staff know about customers who often miss or cancel late
+	if risk, err := c.reservationService.WithContext(ctx.UserContext()).GetCustomerRisk(createdReservation.CustomerID); err == nil && risk.Level != service.CustomerRiskLevelNone {
+		data["customer_risk"] = risk
 	}
 
 	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
 		"success": true,
-		"data": fiber.Map{
-			"reservation": createdReservation,
-		},
+		"data":    data,
 		"meta": fiber.Map{
 			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
 		},
@@ -207,16 +207,7 @@ func (c *ReservationController) CreateReservation(ctx *fiber.Ctx) error {
 func (c *ReservationController) UpdateReservation(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効な予約IDです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidReservationID
 	}
 
 	var requestBody struct {
@@ -227,42 +218,20 @@ func (c *ReservationController) UpdateReservation(ctx *fiber.Ctx) error {
 		MenuIDs         []uuid.UUID `json:"menu_ids"`
 		OptionIDs       []uuid.UUID `json:"option_ids,omitempty"`
 		Notes           string      `json:"notes,omitempty" validate:"max=500"`
+		// OverrideBlockedTime is set when an admin books over a staff member's blocked time (requires an admin token)
+		OverrideBlockedTime bool `json:"override_blocked_time,omitempty"`
 	}
 
 	if err := ctx.BodyParser(&requestBody); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効なリクエストです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidRequest
+	}
+	if requestBody.OverrideBlockedTime && !middleware.IsAdmin(ctx) {
+		return errOverrideBlockedTimeForbidden
 	}
 
-	updatedReservation, err := c.reservationService.UpdateReservationFromRequest(id, requestBody.CustomerID, requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime, requestBody.MenuIDs, requestBody.OptionIDs, requestBody.Notes)
+	updatedReservation, err := c.reservationService.WithContext(ctx.UserContext()).UpdateReservationFromRequest(id, requestBody.CustomerID, requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime, requestBody.MenuIDs, requestBody.OptionIDs, requestBody.Notes, requestBody.OverrideBlockedTime)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		errorCode := "VALIDATION_ERROR"
-		if err.Error() == "reservation not found" {
-			statusCode = http.StatusNotFound
-			errorCode = "NOT_FOUND"
-		} else if err.Error() == "cannot update cancelled or completed reservations" {
-			statusCode = http.StatusUnprocessableEntity
-			errorCode = "BUSINESS_RULE_ERROR"
-		}
-		return ctx.Status(statusCode).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    errorCode,
-				"message": err.Error(),
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
@@ -289,45 +258,18 @@ func (c *ReservationController) UpdateReservation(ctx *fiber.Ctx) error {
 func (c *ReservationController) CancelReservation(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効な予約IDです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidReservationID
 	}
 
-	err = c.reservationService.CancelReservation(id)
+	err = c.reservationService.WithContext(ctx.UserContext()).CancelReservation(id)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		errorCode := "VALIDATION_ERROR"
-		if err.Error() == "reservation not found" {
-			statusCode = http.StatusNotFound
-			errorCode = "NOT_FOUND"
-		} else if err.Error() == "cannot cancel completed reservation" || err.Error() == "reservation is already cancelled" {
-			statusCode = http.StatusUnprocessableEntity
-			errorCode = "BUSINESS_RULE_ERROR"
-		}
-		return ctx.Status(statusCode).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    errorCode,
-				"message": err.Error(),
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
-			"message":        "予約をキャンセルしました",
+			"message":        i18n.T(i18n.FromCtx(ctx), "message.reservation_cancelled", nil),
 			"reservation_id": id,
 		},
 		"meta": fiber.Map{
@@ -338,7 +280,7 @@ func (c *ReservationController) CancelReservation(ctx *fiber.Ctx) error {
 
 // UpdateReservationStatus godoc
 // @Summary 予約ステータス更新
-// @Description 予約ステータスを更新します（管理者・スタッフのみ）
+// @Description 予約ステータスを更新します（管理者・スタッフのみ）。確定済みの予約は来店がなかった場合に no_show にできます
 // @Tags 予約管理
 // @Accept json
 // @Produce json
@@ -349,56 +291,20 @@ func (c *ReservationController) CancelReservation(ctx *fiber.Ctx) error {
 func (c *ReservationController) UpdateReservationStatus(ctx *fiber.Ctx) error {
 	id, err := uuid.Parse(ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効な予約IDです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidReservationID
 	}
 
 	var requestBody struct {
-		Status string `json:"status" validate:"required,oneof=pending confirmed in_progress completed cancelled"`
+		Status string `json:"status" validate:"required,oneof=pending confirmed in_progress completed cancelled no_show"`
 	}
 
 	if err := ctx.BodyParser(&requestBody); err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "無効なリクエストです",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errInvalidRequest
 	}
 
-	updatedReservation, err := c.reservationService.UpdateReservationStatus(id, requestBody.Status)
+	updatedReservation, err := c.reservationService.WithContext(ctx.UserContext()).UpdateReservationStatus(id, requestBody.Status)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		errorCode := "VALIDATION_ERROR"
-		if err.Error() == "reservation not found" {
-			statusCode = http.StatusNotFound
-			errorCode = "NOT_FOUND"
-		} else if err.Error() == "invalid status transition" {
-			statusCode = http.StatusUnprocessableEntity
-			errorCode = "BUSINESS_RULE_ERROR"
-		}
-		return ctx.Status(statusCode).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    errorCode,
-				"message": err.Error(),
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
@@ -412,6 +318,51 @@ func (c *ReservationController) UpdateReservationStatus(ctx *fiber.Ctx) error {
 	})
 }
 
+// RebookReservation godoc
+// @Summary 前回と同じ内容で再予約
+// @Description 過去の予約のメニュー・オプション・スタッフをコピーし、現在のカタログと予約ポリシーで予約可能な直近の空き枠を返します。提供終了・価格改定されたメニューにはフラグが付きます
+// @Tags 予約管理
+// @Accept json
+// @Produce json
+// @Param id path string true "予約ID"
+// @Param rebook body map[string]interface{} false "検索条件（date, window_start, window_end, limit）"
+// @Success 200 {object} service.RebookSuggestion "再予約候補"
+// @Router /reservations/{id}/rebook [post]
+func (c *ReservationController) RebookReservation(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidReservationID
+	}
+
+	var requestBody struct {
+		Date        string `json:"date,omitempty"`
+		WindowStart string `json:"window_start,omitempty"`
+		WindowEnd   string `json:"window_end,omitempty"`
+		Limit       int    `json:"limit,omitempty"`
+	}
+
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&requestBody); err != nil {
+			return errInvalidRequest
+		}
+	}
+
+	suggestion, err := c.reservationService.WithContext(ctx.UserContext()).GetRebookSuggestions(id, requestBody.Date, requestBody.WindowStart, requestBody.WindowEnd, requestBody.Limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"rebook": suggestion,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
 // GetAvailability godoc
 // @Summary 空き時間取得
 // @Description 予約可能な時間枠を取得します
@@ -431,30 +382,12 @@ func (c *ReservationController) GetAvailability(ctx *fiber.Ctx) error {
 	menuIDsStr := ctx.Query("menu_ids")
 
 	if date == "" || durationStr == "" {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "日付と必要時間は必須です",
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return errAvailabilityQueryRequired
 	}
 
-	availableSlots, err := c.reservationService.GetAvailability(date, durationStr, staffIDStr, menuIDsStr)
+	availableSlots, err := c.reservationService.WithContext(ctx.UserContext()).GetAvailability(date, durationStr, staffIDStr, menuIDsStr)
 	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-			"meta": fiber.Map{
-				"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
-			},
-		})
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{
@@ -466,4 +399,4 @@ func (c *ReservationController) GetAvailability(ctx *fiber.Ctx) error {
 			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
 		},
 	})
-}
\ No newline at end of file
+}
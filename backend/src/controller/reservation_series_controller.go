@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"app/src/i18n"
+	"app/src/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReservationSeriesController struct {
+	seriesService *service.ReservationSeriesService
+}
+
+func NewReservationSeriesController(seriesService *service.ReservationSeriesService) *ReservationSeriesController {
+	return &ReservationSeriesController{
+		seriesService: seriesService,
+	}
+}
+
+// GetReservationSeries godoc
+// @Summary 予約シリーズ詳細取得
+// @Description 繰り返し予約のシリーズと各回の予約を取得します
+// @Tags 繰り返し予約
+// @Accept json
+// @Produce json
+// @Param id path string true "シリーズID"
+// @Success 200 {object} model.ReservationSeries "予約シリーズ"
+// @Router /reservation-series/{id} [get]
+func (c *ReservationSeriesController) GetReservationSeries(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	series, err := c.seriesService.GetSeriesByID(id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"series": series,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CreateReservationSeries godoc
+// @Summary 繰り返し予約作成
+// @Description 繰り返しルール（RRULE: FREQ/INTERVAL/COUNT/UNTIL）から予約シリーズを作成します。空きのない日付は conflicts に返されます。確認メールはシリーズ全体で1通送ります
+// @Description 無断キャンセル・直前キャンセルの多い顧客は acknowledge_risk を指定しないと作成できず、注意が必要な顧客の場合はレスポンスに customer_risk が含まれます
+// @Tags 繰り返し予約
+// @Accept json
+// @Produce json
+// @Param series body map[string]interface{} true "シリーズデータ"
+// @Success 201 {object} model.ReservationSeries "作成された予約シリーズ"
+// @Failure 409 {object} map[string]interface{} "重複する日付"
+// @Router /reservation-series [post]
+func (c *ReservationSeriesController) CreateReservationSeries(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		CustomerID    uuid.UUID   `json:"customer_id" validate:"required"`
+		StaffID       uuid.UUID   `json:"staff_id" validate:"required"`
+		StartDate     string      `json:"start_date" validate:"required"`
+		StartTime     string      `json:"start_time" validate:"required"`
+		RRule         string      `json:"rrule" validate:"required"`
+		MenuIDs       []uuid.UUID `json:"menu_ids" validate:"required,min=1"`
+		OptionIDs     []uuid.UUID `json:"option_ids,omitempty"`
+		Notes         string      `json:"notes,omitempty" validate:"max=500"`
+		SkipConflicts bool        `json:"skip_conflicts,omitempty"`
+		// AcknowledgeRisk is set when staff book a series for a high-risk customer after confirming the risk
+		AcknowledgeRisk bool `json:"acknowledge_risk,omitempty"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	series, conflicts, err := c.seriesService.CreateSeries(requestBody.CustomerID, requestBody.StaffID, requestBody.StartDate, requestBody.StartTime, requestBody.RRule, requestBody.MenuIDs, requestBody.OptionIDs, requestBody.Notes, requestBody.SkipConflicts, requestBody.AcknowledgeRisk)
+	if err != nil {
+		return err
+	}
+
+	data := fiber.Map{
+		"series":    series,
+		"conflicts": conflicts.Localize(i18n.FromCtx(ctx)),
+	}
+	if risk, err := c.seriesService.GetCustomerRisk(series.CustomerID); err == nil && risk.Level != service.CustomerRiskLevelNone {
+		data["customer_risk"] = risk
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// UpdateSeriesOccurrence godoc
+// @Summary 繰り返し予約の変更
+// @Description 1回分・以降すべて・シリーズ全体のいずれかの範囲で予約を変更します
+// @Tags 繰り返し予約
+// @Accept json
+// @Produce json
+// @Param id path string true "シリーズID"
+// @Param reservationId path string true "予約ID"
+// @Param scope query string false "変更範囲 (this, following, all)" default(this)
+// @Param occurrence body map[string]interface{} true "変更データ"
+// @Success 200 {object} map[string]interface{} "変更後の予約一覧"
+// @Router /reservation-series/{id}/occurrences/{reservationId} [put]
+func (c *ReservationSeriesController) UpdateSeriesOccurrence(ctx *fiber.Ctx) error {
+	seriesID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+	reservationID, err := uuid.Parse(ctx.Params("reservationId"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	var requestBody struct {
+		StaffID         uuid.UUID `json:"staff_id"`
+		ReservationDate string    `json:"reservation_date"`
+		StartTime       string    `json:"start_time"`
+		Notes           string    `json:"notes,omitempty" validate:"max=500"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	scope := ctx.Query("scope", service.SeriesScopeThis)
+	reservations, err := c.seriesService.UpdateOccurrences(seriesID, reservationID, scope, requestBody.StaffID, requestBody.ReservationDate, requestBody.StartTime, requestBody.Notes)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservations": reservations,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CancelSeriesOccurrence godoc
+// @Summary 繰り返し予約のキャンセル
+// @Description 1回分・以降すべて・シリーズ全体のいずれかの範囲で予約をキャンセルします
+// @Tags 繰り返し予約
+// @Accept json
+// @Produce json
+// @Param id path string true "シリーズID"
+// @Param reservationId path string true "予約ID"
+// @Param scope query string false "キャンセル範囲 (this, following, all)" default(this)
+// @Success 200 {object} map[string]interface{} "キャンセル件数"
+// @Router /reservation-series/{id}/occurrences/{reservationId} [delete]
+func (c *ReservationSeriesController) CancelSeriesOccurrence(ctx *fiber.Ctx) error {
+	seriesID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+	reservationID, err := uuid.Parse(ctx.Params("reservationId"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	return c.cancel(ctx, seriesID, reservationID, ctx.Query("scope", service.SeriesScopeThis))
+}
+
+// CancelReservationSeries godoc
+// @Summary 予約シリーズ全体のキャンセル
+// @Description シリーズに含まれる未完了の予約をすべてキャンセルします
+// @Tags 繰り返し予約
+// @Accept json
+// @Produce json
+// @Param id path string true "シリーズID"
+// @Success 200 {object} map[string]interface{} "キャンセル件数"
+// @Router /reservation-series/{id} [delete]
+func (c *ReservationSeriesController) CancelReservationSeries(ctx *fiber.Ctx) error {
+	seriesID, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidID
+	}
+
+	return c.cancel(ctx, seriesID, uuid.Nil, service.SeriesScopeAll)
+}
+
+func (c *ReservationSeriesController) cancel(ctx *fiber.Ctx, seriesID, reservationID uuid.UUID, scope string) error {
+	cancelled, err := c.seriesService.CancelOccurrences(seriesID, reservationID, scope)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"message":         i18n.T(i18n.FromCtx(ctx), "message.reservation_cancelled", nil),
+			"series_id":       seriesID,
+			"cancelled_count": cancelled,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
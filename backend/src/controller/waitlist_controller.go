@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/service"
+	"bytes"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WaitlistController struct {
+	waitlistService *service.WaitlistService
+}
+
+func NewWaitlistController(waitlistService *service.WaitlistService) *WaitlistController {
+	return &WaitlistController{
+		waitlistService: waitlistService,
+	}
+}
+
+// GetWaitlistEntries godoc
+// @Summary キャンセル待ち一覧取得
+// @Description ページング・フィルター付きでキャンセル待ちを取得します
+// @Tags キャンセル待ち
+// @Accept json
+// @Produce json
+// @Param page query int false "ページ番号" default(1)
+// @Param limit query int false "ページサイズ" default(20)
+// @Param status query string false "ステータス絞り込み"
+// @Param customer_id query string false "顧客ID絞り込み"
+// @Param date query string false "希望日絞り込み (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "キャンセル待ち一覧"
+// @Router /waitlist [get]
+func (c *WaitlistController) GetWaitlistEntries(ctx *fiber.Ctx) error {
+	page := ctx.QueryInt("page", 1)
+	limit := ctx.QueryInt("limit", 20)
+	if limit > 100 {
+		limit = 100
+	}
+
+	entries, total, err := c.waitlistService.GetEntries(page, limit, ctx.Query("status"), ctx.Query("customer_id"), ctx.Query("date"))
+	if err != nil {
+		return err
+	}
+
+	totalPages := (total + int64(limit) - 1) / int64(limit)
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"entries": entries,
+			"pagination": fiber.Map{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": totalPages,
+				"has_next":    int64(page) < totalPages,
+				"has_prev":    page > 1,
+			},
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// GetWaitlistEntry godoc
+// @Summary キャンセル待ち詳細取得
+// @Description IDで指定したキャンセル待ちを取得します
+// @Tags キャンセル待ち
+// @Accept json
+// @Produce json
+// @Param id path string true "キャンセル待ちID"
+// @Success 200 {object} model.WaitlistEntry "キャンセル待ち詳細"
+// @Router /waitlist/{id} [get]
+func (c *WaitlistController) GetWaitlistEntry(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidWaitlistEntryID
+	}
+
+	entry, err := c.waitlistService.GetEntryByID(id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"entry": entry,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CreateWaitlistEntry godoc
+// @Summary キャンセル待ち登録
+// @Description 満席の日に希望メニュー・スタッフ・時間帯でキャンセル待ちを登録します
+// @Tags キャンセル待ち
+// @Accept json
+// @Produce json
+// @Param entry body map[string]interface{} true "キャンセル待ちデータ"
+// @Success 201 {object} model.WaitlistEntry "登録されたキャンセル待ち"
+// @Router /waitlist [post]
+func (c *WaitlistController) CreateWaitlistEntry(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		CustomerID  uuid.UUID   `json:"customer_id" validate:"required"`
+		StaffID     *uuid.UUID  `json:"staff_id,omitempty"`
+		DesiredDate string      `json:"desired_date" validate:"required"`
+		WindowStart string      `json:"window_start,omitempty"`
+		WindowEnd   string      `json:"window_end,omitempty"`
+		MenuIDs     []uuid.UUID `json:"menu_ids" validate:"required,min=1"`
+		Notes       string      `json:"notes,omitempty" validate:"max=500"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	entry, err := c.waitlistService.CreateEntry(requestBody.CustomerID, requestBody.StaffID, requestBody.DesiredDate, requestBody.WindowStart, requestBody.WindowEnd, requestBody.MenuIDs, requestBody.Notes)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"entry": entry,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// CancelWaitlistEntry godoc
+// @Summary キャンセル待ち取消
+// @Description キャンセル待ちを取り消します。案内中の枠は次の順番へ回されます
+// @Tags キャンセル待ち
+// @Accept json
+// @Produce json
+// @Param id path string true "キャンセル待ちID"
+// @Success 200 {object} map[string]interface{} "取消結果"
+// @Router /waitlist/{id} [delete]
+func (c *WaitlistController) CancelWaitlistEntry(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errInvalidWaitlistEntryID
+	}
+
+	if err := c.waitlistService.CancelEntry(id); err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"message":  i18n.T(i18n.FromCtx(ctx), "message.waitlist_entry_cancelled", nil),
+			"entry_id": id,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// actionPage is the confirmation page opened from an email link. Accepting an offer or logging in only happens on the form's POST
+var actionPage = template.Must(template.New("action").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Action}}<form method="post" action="{{.Action}}"><button type="submit">{{.Button}}</button></form>{{end}}
+</body>
+</html>`))
+
+type actionPageData struct {
+	Locale  string
+	Title   string
+	Message string
+	Action  string
+	Button  string
+}
+
+func renderActionPage(ctx *fiber.Ctx, data actionPageData) error {
+	var body bytes.Buffer
+	if err := actionPage.Execute(&body, data); err != nil {
+		return err
+	}
+	ctx.Type("html", "utf-8")
+	return ctx.Send(body.Bytes())
+}
+
+// GetWaitlistOffer godoc
+// @Summary キャンセル待ち案内の確認
+// @Description 案内のリンクから開く確認ページです。状態は変更せず、承諾ボタンから POST で承諾します
+// @Tags キャンセル待ち
+// @Produce html
+// @Produce json
+// @Param token path string true "案内トークン"
+// @Success 200 {object} model.WaitlistEntry "案内中のキャンセル待ち"
+// @Router /waitlist/offers/{token} [get]
+func (c *WaitlistController) GetWaitlistOffer(ctx *fiber.Ctx) error {
+	entry, err := c.waitlistService.GetOffer(ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML {
+		locale := i18n.FromCtx(ctx)
+		return renderActionPage(ctx, actionPageData{
+			Locale: locale,
+			Title:  i18n.T(locale, "page.waitlist_offer.title", nil),
+			Message: i18n.T(locale, "page.waitlist_offer.body", i18n.Params{
+				"date":       entry.Reservation.ReservationDate.Format("2006-01-02"),
+				"time":       entry.Reservation.StartTime.Format("15:04"),
+				"expires_at": entry.OfferExpiresAt.Format("2006-01-02 15:04"),
+			}),
+			Action: "/v1/waitlist/offers/" + entry.OfferToken + "/accept",
+			Button: i18n.T(locale, "page.waitlist_offer.accept", nil),
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"entry": entry,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+// AcceptWaitlistOffer godoc
+// @Summary キャンセル待ち案内の承諾
+// @Description 確認ページのフォームから空き枠を承諾し、予約を確定します
+// @Tags キャンセル待ち
+// @Accept json
+// @Produce json
+// @Param token path string true "案内トークン"
+// @Success 200 {object} model.Reservation "確定した予約"
+// @Router /waitlist/offers/{token}/accept [post]
+func (c *WaitlistController) AcceptWaitlistOffer(ctx *fiber.Ctx) error {
+	reservation, err := c.waitlistService.AcceptOffer(ctx.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML {
+		return renderAcceptedPage(ctx, reservation)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"reservation": reservation,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
+
+func renderAcceptedPage(ctx *fiber.Ctx, reservation *model.Reservation) error {
+	locale := i18n.FromCtx(ctx)
+	return renderActionPage(ctx, actionPageData{
+		Locale: locale,
+		Title:  i18n.T(locale, "page.waitlist_offer.title", nil),
+		Message: i18n.T(locale, "page.waitlist_offer.accepted", i18n.Params{
+			"date": reservation.ReservationDate.Format("2006-01-02"),
+			"time": reservation.StartTime.Format("15:04"),
+		}),
+	})
+}
+
+// MatchWaitlist godoc
+// @Summary キャンセル待ちの再照合
+// @Description シフト延長などで空いた枠をキャンセル待ちへ案内します（管理者・スタッフのみ）
+// @Tags キャンセル待ち
+// @Accept json
+// @Produce json
+// @Param request body map[string]string true "スタッフIDと日付"
+// @Success 200 {object} map[string]interface{} "案内したキャンセル待ち"
+// @Router /waitlist/match [post]
+func (c *WaitlistController) MatchWaitlist(ctx *fiber.Ctx) error {
+	var requestBody struct {
+		StaffID uuid.UUID `json:"staff_id" validate:"required"`
+		Date    string    `json:"date" validate:"required"`
+	}
+
+	if err := ctx.BodyParser(&requestBody); err != nil {
+		return errInvalidRequest
+	}
+
+	date, err := time.Parse("2006-01-02", requestBody.Date)
+	if err != nil || requestBody.StaffID == uuid.Nil {
+		return errMatchWaitlistRequired
+	}
+
+	entry, err := c.waitlistService.MatchFreedSlot(requestBody.StaffID, date)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"offered_entry": entry,
+		},
+		"meta": fiber.Map{
+			"timestamp": ctx.Context().Time().Format("2006-01-02T15:04:05-07:00"),
+		},
+	})
+}
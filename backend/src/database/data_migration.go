@@ -0,0 +1,153 @@
+package database
+
+import (
+	"app/src/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// dataMigrationLockKey is the Postgres advisory lock held while a data migration runs,
+// so that replicas starting at the same time do not run the same backfill twice
+const dataMigrationLockKey = 7263401
+
+// DataMigration is a one-time data backfill that has to run in Go, such as re-encrypting
+// personal data. Once Run succeeds its ID is recorded and it is skipped on later starts
+type DataMigration struct {
+	ID  string
+	Run func(tx *gorm.DB) error
+}
+
+type dataMigrationRecord struct {
+	ID        string `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time
+}
+
+func (dataMigrationRecord) TableName() string {
+	return "data_migrations"
+}
+
+// DataMigrations returns the data migrations of this version of the app in the order they run.
+// The first one records the schema of the AutoMigrate models, so that readiness can tell whether
+// the schema this binary expects has been applied. The encryption backfills include the key version,
+// so they run again once after a key rotation
+func DataMigrations() ([]DataMigration, error) {
+	fingerprint, err := schemaFingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	keyVersion := utils.CurrentFieldKeyVersion()
+	return []DataMigration{
+		// Only called after AutoMigrate succeeded, so recording it is all that is left to do
+		{ID: "schema_" + fingerprint, Run: func(tx *gorm.DB) error { return nil }},
+		{ID: fmt.Sprintf("customer_encryption_v%d", keyVersion), Run: migrateCustomerEncryption},
+		{ID: fmt.Sprintf("notification_log_encryption_v%d", keyVersion), Run: migrateNotificationLogEncryption},
+		{ID: "merge_audit_logs_redaction", Run: migrateMergeAuditLogs},
+		{ID: "phone_numbers_e164", Run: migratePhoneNumbers},
+		{ID: "customer_search", Run: migrateCustomerSearch},
+	}, nil
+}
+
+// schemaFingerprint hashes the tables, columns and gorm tags of the AutoMigrate models,
+// so any change to them results in a new schema migration ID
+func schemaFingerprint() (string, error) {
+	var columns []string
+	cache := &sync.Map{}
+	for _, m := range models {
+		parsed, err := schema.Parse(m, cache, schema.NamingStrategy{})
+		if err != nil {
+			return "", fmt.Errorf("failed to parse model %T: %w", m, err)
+		}
+		for _, field := range parsed.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			columns = append(columns, fmt.Sprintf("%s.%s %s %s", parsed.Table, field.DBName, field.DataType, field.Tag.Get("gorm")))
+		}
+	}
+	sort.Strings(columns)
+
+	sum := sha256.Sum256([]byte(strings.Join(columns, "\n")))
+	return hex.EncodeToString(sum[:6]), nil
+}
+
+// ApplyDataMigrations runs the migrations that have not been recorded yet, each in its own transaction.
+// It stops at the first failure, since later migrations may depend on earlier ones
+func ApplyDataMigrations(db *gorm.DB, migrations []DataMigration) error {
+	if err := db.AutoMigrate(&dataMigrationRecord{}); err != nil {
+		return fmt.Errorf("failed to create the data migrations table: %w", err)
+	}
+
+	for _, migration := range migrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if tx.Dialector.Name() == "postgres" {
+				if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", dataMigrationLockKey).Error; err != nil {
+					return err
+				}
+			}
+
+			// Another replica may have finished it while this one waited for the lock
+			var applied int64
+			if err := tx.Model(&dataMigrationRecord{}).Where("id = ?", migration.ID).Count(&applied).Error; err != nil {
+				return err
+			}
+			if applied > 0 {
+				return nil
+			}
+
+			started := time.Now()
+			if err := migration.Run(tx); err != nil {
+				return err
+			}
+			if err := tx.Create(&dataMigrationRecord{ID: migration.ID, AppliedAt: time.Now()}).Error; err != nil {
+				return err
+			}
+			utils.Log.Infof("Applied data migration %s in %s", migration.ID, time.Since(started).Round(time.Millisecond))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("data migration %s failed: %w", migration.ID, err)
+		}
+	}
+	return nil
+}
+
+// PendingDataMigrations returns the IDs of the migrations that have not been recorded as applied
+func PendingDataMigrations(db *gorm.DB, migrations []DataMigration) ([]string, error) {
+	if !db.Migrator().HasTable(&dataMigrationRecord{}) {
+		pending := make([]string, 0, len(migrations))
+		for _, migration := range migrations {
+			pending = append(pending, migration.ID)
+		}
+		return pending, nil
+	}
+
+	ids := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		ids = append(ids, migration.ID)
+	}
+	var applied []string
+	if err := db.Model(&dataMigrationRecord{}).Where("id IN ?", ids).Pluck("id", &applied).Error; err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, id := range applied {
+		done[id] = true
+	}
+	var pending []string
+	for _, id := range ids {
+		if !done[id] {
+			pending = append(pending, id)
+		}
+	}
+	return pending, nil
+}
@@ -4,36 +4,79 @@ import (
 	"app/src/config"
 	"app/src/model"
 	"app/src/utils"
+	"app/src/validation"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
 )
 
-func Connect(dbHost, dbName string) *gorm.DB {
+// maxConnectBackoff is the longest wait between connection attempts
+const maxConnectBackoff = 30 * time.Second
+
+// models are the beauty salon models created and updated through AutoMigrate
+var models = []interface{}{
+	&model.Customer{},
+	&model.Staff{},
+	&model.Menu{},
+	&model.Option{},
+	&model.Label{},
+	&model.Shift{},
+	&model.BlockedTime{},
+	&model.Reservation{},
+	&model.ReservationMenu{},
+	&model.ReservationOption{},
+	&model.AuditLog{},
+	&model.NotificationLog{},
+	&model.NotificationPreference{},
+	&model.LoginChallenge{},
+	&model.WaitlistEntry{},
+	&model.WaitlistMenu{},
+	&model.ReservationSeries{},
+	&model.ReservationSeriesMenu{},
+	&model.ReservationSeriesOption{},
+	&model.CalendarFeed{},
+}
+
+// Connect connects to the database and runs the migrations.
+// The database may not accept connections right after startup, so it retries up to config.DBConnectAttempts times, doubling the wait each time
+func Connect(dbHost, dbName string) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=Asia/Shanghai",
 		dbHost, config.DBUser, config.DBPassword, dbName, config.DBPort,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger:                 logger.Default.LogMode(logger.Info),
-		SkipDefaultTransaction: true,
-		PrepareStmt:            true,
-		TranslateError:         true,
-	})
-	if err != nil {
-		utils.Log.Errorf("Failed to connect to database: %+v", err)
-		// Return nil database connection but don't panic
-		return nil
+	var db *gorm.DB
+	var err error
+	backoff := time.Duration(config.DBConnectBackoff) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger:                 newQueryLogger(time.Duration(config.DBSlowQuery) * time.Millisecond),
+			SkipDefaultTransaction: true,
+			PrepareStmt:            true,
+			TranslateError:         true,
+		})
+		if err == nil {
+			break
+		}
+		if attempt >= config.DBConnectAttempts {
+			return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, err)
+		}
+
+		utils.Log.Warnf("Failed to connect to database (attempt %d/%d), retrying in %s: %v",
+			attempt, config.DBConnectAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
 	}
 
 	sqlDB, errDB := db.DB()
 	if errDB != nil {
-		utils.Log.Errorf("Failed to get database instance: %+v", errDB)
-		return nil
+		return nil, fmt.Errorf("failed to get database instance: %w", errDB)
 	}
 
 	// Config connection pooling
@@ -41,25 +84,237 @@ func Connect(dbHost, dbName string) *gorm.DB {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(60 * time.Minute)
 
+	// Customers may sign in with only a phone number or only an email address,
+	// so the old unique indexes are replaced by ones that ignore empty values
+	// Phone and email are now encrypted, so uniqueness and lookups move to their blind indexes
+	for _, index := range []string{
+		"idx_customers_phone", "idx_customers_email",
+		"idx_customers_phone_present", "idx_customers_email_present",
+		"idx_customers_phone_digits_trgm", "idx_customers_email_trgm",
+	} {
+		if db.Migrator().HasIndex(&model.Customer{}, index) {
+			if err := db.Migrator().DropIndex(&model.Customer{}, index); err != nil {
+				utils.Log.Errorf("Failed to drop customer index %s: %+v", index, err)
+			}
+		}
+	}
+
 	// Auto-migrate beauty salon models
-	err = db.AutoMigrate(
-		&model.Customer{},
-		&model.Staff{},
-		&model.Menu{},
-		&model.Option{},
-		&model.Label{},
-		&model.Shift{},
-		&model.Reservation{},
-		&model.ReservationMenu{},
-		&model.ReservationOption{},
-		&model.AuditLog{},
-		&model.NotificationLog{},
-	)
+	err = db.AutoMigrate(models...)
 	if err != nil {
 		utils.Log.Errorf("Failed to auto-migrate models: %+v", err)
 	} else {
 		utils.Log.Info("Database models auto-migrated successfully")
+		migrations, err := DataMigrations()
+		if err == nil {
+			err = ApplyDataMigrations(db, migrations)
+		}
+		if err != nil {
+			utils.Log.Errorf("Failed to apply data migrations: %+v", err)
+		}
+	}
+
+	// SQL spans are added after the migrations so that startup queries do not produce traces.
+	// Bound values are left out because they include customer personal data
+	if err := db.Use(otelgorm.NewPlugin(otelgorm.WithDBName(dbName), otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+		utils.Log.Errorf("Failed to enable database tracing: %+v", err)
+	}
+
+	return db, nil
+}
+
+// migrateCustomerSearch creates the trigram indexes for customer search and fills the normalized
+// search columns of customers created before they existed
+func migrateCustomerSearch(db *gorm.DB) error {
+	// Partial and fuzzy matches use LIKE '%...%', which only trigram indexes can serve
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+			return fmt.Errorf("failed to enable pg_trgm: %w", err)
+		}
+		indexes := map[string]string{
+			"idx_customers_name_search_trgm": "name_search gin_trgm_ops",
+			"idx_customers_kana_search_trgm": "kana_search gin_trgm_ops",
+		}
+		for name, expression := range indexes {
+			if err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON customers USING gin (%s)", name, expression)).Error; err != nil {
+				return fmt.Errorf("failed to create customer search index %s: %w", name, err)
+			}
+		}
+	}
+
+	var customers []model.Customer
+	return db.Where("name_search = '' AND name <> ''").FindInBatches(&customers, 500, func(tx *gorm.DB, batch int) error {
+		for i := range customers {
+			if err := db.Save(&customers[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// migrateCustomerEncryption re-encrypts customer personal data that is plain text or encrypted with
+// an older key with the current key, and fills the blind indexes
+func migrateCustomerEncryption(db *gorm.DB) error {
+	// The plain digits copy is replaced by the blind indexes
+	if db.Migrator().HasColumn(&model.Customer{}, "phone_digits") {
+		if err := db.Migrator().DropColumn(&model.Customer{}, "phone_digits"); err != nil {
+			return fmt.Errorf("failed to drop customers.phone_digits: %w", err)
+		}
+	}
+
+	current := utils.EncryptedFieldPrefix(utils.CurrentFieldKeyVersion()) + "%"
+	stale := db.Where("phone <> '' AND phone NOT LIKE ?", current).
+		Or("COALESCE(email, '') <> '' AND email NOT LIKE ?", current).
+		Or("COALESCE(notes, '') <> '' AND notes NOT LIKE ?", current).
+		Or("COALESCE(birthday, '') <> '' AND birthday NOT LIKE ?", current).
+		Or("phone <> '' AND phone_index = ''").
+		Or("COALESCE(email, '') <> '' AND email_index = ''")
+
+	var customers []model.Customer
+	migrated := 0
+	err := stale.FindInBatches(&customers, 500, func(tx *gorm.DB, batch int) error {
+		for i := range customers {
+			if err := db.Save(&customers[i]).Error; err != nil {
+				return err
+			}
+		}
+		migrated += len(customers)
+		return nil
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to encrypt customer personal data: %w", err)
 	}
+	if migrated > 0 {
+		utils.Log.Infof("Encrypted personal data of %d customers with key version %d", migrated, utils.CurrentFieldKeyVersion())
+	}
+	return nil
+}
+
+// migrateNotificationLogEncryption re-encrypts the recipient, message and error of notification logs
+// with the current key and fills the recipient blind index
+func migrateNotificationLogEncryption(db *gorm.DB) error {
+	current := utils.EncryptedFieldPrefix(utils.CurrentFieldKeyVersion()) + "%"
+	stale := db.Where("recipient <> '' AND recipient NOT LIKE ?", current).
+		Or("message <> '' AND message NOT LIKE ?", current).
+		Or("COALESCE(error_message, '') <> '' AND error_message NOT LIKE ?", current).
+		Or("recipient <> '' AND recipient_index = ''")
+
+	var notifications []model.NotificationLog
+	migrated := 0
+	err := stale.FindInBatches(&notifications, 500, func(tx *gorm.DB, batch int) error {
+		for i := range notifications {
+			if err := db.Save(&notifications[i]).Error; err != nil {
+				return err
+			}
+		}
+		migrated += len(notifications)
+		return nil
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to encrypt notification logs: %w", err)
+	}
+	if migrated > 0 {
+		utils.Log.Infof("Encrypted %d notification logs with key version %d", migrated, utils.CurrentFieldKeyVersion())
+	}
+	return nil
+}
+
+// migrateMergeAuditLogs removes the customer data left in customer merge audit logs,
+// keeping only the customer IDs and the moved record counts
+func migrateMergeAuditLogs(db *gorm.DB) error {
+	var audits []model.AuditLog
+	if err := db.Where("action = ? AND table_name = ?", "MERGE", "customers").Find(&audits).Error; err != nil {
+		return fmt.Errorf("failed to get merge audit logs: %w", err)
+	}
+
+	redacted := 0
+	for _, audit := range audits {
+		var oldValues struct {
+			Customer  *struct{ ID uuid.UUID } `json:"customer"`
+			Duplicate *struct{ ID uuid.UUID } `json:"duplicate"`
+		}
+		var newValues struct {
+			Customer         *struct{ ID uuid.UUID } `json:"customer"`
+			MergedCustomerID uuid.UUID               `json:"merged_customer_id"`
+			Moved            map[string]int64        `json:"moved"`
+		}
+		if json.Unmarshal([]byte(audit.OldValues), &oldValues) != nil || json.Unmarshal([]byte(audit.NewValues), &newValues) != nil {
+			continue
+		}
+		if oldValues.Customer == nil && oldValues.Duplicate == nil && newValues.Customer == nil {
+			continue
+		}
+
+		encodedOld, err := json.Marshal(map[string]interface{}{"customer_id": audit.RecordID, "duplicate_id": newValues.MergedCustomerID})
+		if err != nil {
+			continue
+		}
+		encodedNew, err := json.Marshal(map[string]interface{}{"customer_id": audit.RecordID, "merged_customer_id": newValues.MergedCustomerID, "moved": newValues.Moved})
+		if err != nil {
+			continue
+		}
+		if err := db.Model(&model.AuditLog{}).Where("id = ?", audit.ID).Updates(map[string]interface{}{
+			"old_values": string(encodedOld),
+			"new_values": string(encodedNew),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to redact merge audit log %s: %w", audit.ID, err)
+		}
+		redacted++
+	}
+	if redacted > 0 {
+		utils.Log.Infof("Removed customer data from %d merge audit logs", redacted)
+	}
+	return nil
+}
+
+// migratePhoneNumbers converts customer and staff phone numbers to E.164. Customer phone numbers are
+// encrypted, so they are loaded and checked in Go. Numbers that cannot be parsed are left as is and only counted
+func migratePhoneNumbers(db *gorm.DB) error {
+	invalid := 0
 
-	return db
+	var customers []model.Customer
+	err := db.Where("phone <> ''").FindInBatches(&customers, 500, func(tx *gorm.DB, batch int) error {
+		for i := range customers {
+			normalized := validation.NormalizePhone(customers[i].Phone)
+			if normalized == customers[i].Phone {
+				if !strings.HasPrefix(normalized, "+") {
+					invalid++
+				}
+				continue
+			}
+			if err := db.Save(&customers[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to normalize customer phone numbers: %w", err)
+	}
+
+	var staff []model.Staff
+	err = db.Where("phone <> ''").FindInBatches(&staff, 500, func(tx *gorm.DB, batch int) error {
+		for i := range staff {
+			normalized := validation.NormalizePhone(staff[i].Phone)
+			if normalized == staff[i].Phone {
+				if !strings.HasPrefix(normalized, "+") {
+					invalid++
+				}
+				continue
+			}
+			if err := db.Model(&staff[i]).Update("phone", normalized).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to normalize staff phone numbers: %w", err)
+	}
+
+	if invalid > 0 {
+		utils.Log.Warnf("%d customer or staff phone numbers could not be parsed as Japanese numbers and were left as is", invalid)
+	}
+	return nil
 }
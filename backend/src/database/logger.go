@@ -0,0 +1,79 @@
+package database
+
+import (
+	"app/src/utils"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// queryLogger is a GORM logger that writes only slow queries and errors to the structured log.
+// Bind values include customers' personal data, so SQL is logged with its placeholders
+type queryLogger struct {
+	slowThreshold time.Duration
+	level         logger.LogLevel
+}
+
+func newQueryLogger(slowThreshold time.Duration) *queryLogger {
+	return &queryLogger{slowThreshold: slowThreshold, level: logger.Warn}
+}
+
+func (l *queryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		utils.Log.WithContext(ctx).Infof(msg, args...)
+	}
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		utils.Log.WithContext(ctx).Warnf(msg, args...)
+	}
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		utils.Log.WithContext(ctx).Errorf(msg, args...)
+	}
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.entry(ctx, elapsed, sql, rows).WithError(err).Error("query failed")
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		l.entry(ctx, elapsed, sql, rows).Warn("slow query")
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		l.entry(ctx, elapsed, sql, rows).Info("query")
+	}
+}
+
+// ParamsFilter makes GORM log SQL without the bind values
+func (l *queryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *queryLogger) entry(ctx context.Context, elapsed time.Duration, sql string, rows int64) *logrus.Entry {
+	return utils.Log.WithContext(ctx).WithFields(logrus.Fields{
+		"sql":        sql,
+		"rows":       rows,
+		"elapsed_ms": float64(elapsed.Microseconds()) / 1000,
+	})
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles are the migrations embedded in the binary, used to know the schema version the running app expects
+//
+//go:embed migrations/*.up.sql
+var migrationFiles embed.FS
+
+var ErrMigrationsNotApplied = errors.New("migrations have not been applied")
+
+// LatestMigrationVersion returns the newest version of the embedded migrations
+func LatestMigrationVersion() (uint64, error) {
+	files, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint64
+	for _, file := range files {
+		prefix, _, found := strings.Cut(file.Name(), "_")
+		if !found {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration file name %s: %w", file.Name(), err)
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+// AppliedMigrationVersion returns the version golang-migrate recorded in schema_migrations and
+// whether it failed halfway (dirty). It returns ErrMigrationsNotApplied when no migration has run yet
+func AppliedMigrationVersion(db *gorm.DB) (uint64, bool, error) {
+	if !db.Migrator().HasTable("schema_migrations") {
+		return 0, false, ErrMigrationsNotApplied
+	}
+
+	var applied struct {
+		Version uint64
+		Dirty   bool
+	}
+	result := db.Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&applied)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, false, ErrMigrationsNotApplied
+	}
+	return applied.Version, applied.Dirty, nil
+}
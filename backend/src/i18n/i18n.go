@@ -0,0 +1,139 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	Japanese = "ja"
+	English  = "en"
+)
+
+// LocalsKey is the fiber.Ctx Locals key holding the language of the request
+const LocalsKey = "locale"
+
+// Params are the values embedded in a message. {name} in the message is replaced by the value of name
+type Params map[string]interface{}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs holds the message keys and texts of each language
+var catalogs = map[string]map[string]string{}
+
+var defaultLocale = Japanese
+
+func init() {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("invalid message catalog %s: %v", file.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(file.Name(), ".json")] = messages
+	}
+}
+
+// SetDefault sets the language used when the language of the request or customer is unknown. Unsupported languages are ignored
+func SetDefault(locale string) {
+	if Supported(locale) {
+		defaultLocale = locale
+	}
+}
+
+// Default returns the language used when the language of the request or customer is unknown
+func Default() string {
+	return defaultLocale
+}
+
+// Supported reports whether there is a message catalog for the language
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Keys returns the sorted message keys of the language
+func Keys(locale string) []string {
+	keys := make([]string, 0, len(catalogs[locale]))
+	for key := range catalogs[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// T renders a message key in the given language.
+// It falls back to the default language and then English, and returns the key itself when no catalog has it
+func T(locale, key string, params Params) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message, ok = catalogs[defaultLocale][key]
+	}
+	if !ok {
+		message, ok = catalogs[English][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(params) == 0 {
+		return message
+	}
+
+	replacements := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+// Match picks the supported language with the highest priority from an Accept-Language header.
+// Tags with a region such as en-US match on the language, and the default language is returned when none is supported
+func Match(acceptLanguage string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, options, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(options), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if language == "*" {
+			language = defaultLocale
+		}
+		if quality > bestQuality && Supported(language) {
+			best, bestQuality = language, quality
+		}
+	}
+	if best == "" {
+		return defaultLocale
+	}
+	return best
+}
+
+// FromCtx returns the language of the request. Without the Locale middleware it is taken from Accept-Language
+func FromCtx(c *fiber.Ctx) string {
+	if locale, ok := c.Locals(LocalsKey).(string); ok && locale != "" {
+		return locale
+	}
+	return Match(c.Get(fiber.HeaderAcceptLanguage))
+}
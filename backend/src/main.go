@@ -5,6 +5,7 @@ import (
 	"app/src/database"
 	"app/src/middleware"
 	"app/src/router"
+	"app/src/tracing"
 	"app/src/utils"
 	"context"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/gofiber/contrib/otelfiber/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -33,6 +35,16 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		utils.Log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			utils.Log.Errorf("Error flushing traces: %v", err)
+		}
+	}()
+
 	app := setupFiberApp()
 	db := setupDatabase()
 	defer closeDatabase(db)
@@ -50,8 +62,19 @@ func setupFiberApp() *fiber.App {
 	app := fiber.New(config.FiberConfig())
 
 	// Middleware setup
+	// Tracing comes first so that the access log and every handler see the request span.
+	// Scrapes and probes run every few seconds and are not traced
+	app.Use(otelfiber.Middleware(otelfiber.WithNext(func(c *fiber.Ctx) bool {
+		switch c.Path() {
+		case "/metrics", "/livez", "/readyz":
+			return true
+		}
+		return false
+	})))
+	app.Use(middleware.Locale())
 	app.Use("/v1/auth", middleware.LimiterConfig())
 	app.Use(middleware.LoggerConfig())
+	app.Use(middleware.Metrics())
 	app.Use(helmet.New())
 	app.Use(compress.New())
 	app.Use(cors.New())
@@ -61,7 +84,10 @@ func setupFiberApp() *fiber.App {
 }
 
 func setupDatabase() *gorm.DB {
-	db := database.Connect(config.DBHost, config.DBName)
+	db, err := database.Connect(config.DBHost, config.DBName)
+	if err != nil {
+		utils.Log.Fatalf("Failed to set up database: %v", err)
+	}
 	// Add any additional database setup if needed
 	return db
 }
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const customerIDKey = "customerID"
+
+// CustomerAuth verifies a customer access token and attaches the customer ID in the token to the request
+func CustomerAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get(fiber.HeaderAuthorization)
+		tokenStr := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		if tokenStr == "" || tokenStr == authHeader {
+			return errAuthenticationRequired
+		}
+
+		sub, _, err := utils.VerifyTokenRole(tokenStr, config.JWTSecret, config.TokenTypeAccess, config.RoleCustomer)
+		if err != nil {
+			return errAuthenticationRequired
+		}
+
+		customerID, err := uuid.Parse(sub)
+		if err != nil {
+			return errAuthenticationRequired
+		}
+
+		c.Locals(customerIDKey, customerID)
+		return c.Next()
+	}
+}
+
+// CustomerID returns the customer ID verified by CustomerAuth
+func CustomerID(c *fiber.Ctx) uuid.UUID {
+	customerID, _ := c.Locals(customerIDKey).(uuid.UUID)
+	return customerID
+}
+
+var errAuthenticationRequired = apperror.Unauthorized("error.authentication_required")
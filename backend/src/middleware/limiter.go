@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"app/src/response"
+	"app/src/apperror"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,12 +13,7 @@ func LimiterConfig() fiber.Handler {
 		Max:        20,
 		Expiration: 15 * time.Minute,
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).
-				JSON(response.Common{
-					Code:    fiber.StatusTooManyRequests,
-					Status:  "error",
-					Message: "Too many requests, please try again later",
-				})
+			return apperror.TooManyRequests("error.too_many_requests")
 		},
 		SkipSuccessfulRequests: true,
 	})
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"app/src/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Locale picks the response language from Accept-Language and stores it in Locals.
+// Accept-Language is added to Vary so that caches keep one copy per language
+func Locale() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := i18n.Match(c.Get(fiber.HeaderAcceptLanguage))
+		c.Locals(i18n.LocalsKey, locale)
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -1,13 +1,92 @@
 package middleware
 
 import (
+	"app/src/i18n"
+	"app/src/response"
+	"app/src/utils"
+	"regexp"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
+// requestIDPattern is the format of an incoming X-Request-ID that can be used as is
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,128}$`)
+
+// LoggerConfig writes the request ID, user ID, route, status and duration of each request to the structured log.
+// The request ID is taken from X-Request-ID or newly issued, and is set on the response and on the logs written during the request.
+// The route definition (e.g. /v1/calendar/:token.ics) is logged instead of the URL, so tokens and search terms in URLs stay out of the logs.
+// Errors returned by handlers become error responses here, and the cause of internal errors is only logged
 func LoggerConfig() fiber.Handler {
-	return logger.New(logger.Config{
-		Format:     "${time} ${method} ${status} ${path} in ${latency}\n",
-		TimeFormat: "15:04:05.00",
-	})
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, requestID)
+		c.SetUserContext(utils.ContextWithRequestID(c.UserContext(), requestID))
+
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		if err != nil {
+			// The error response is written here so that the middleware registered before this one,
+			// such as the rate limiter that only counts failed requests, sees the final status
+			if renderErr := c.App().ErrorHandler(c, err); renderErr != nil {
+				return renderErr
+			}
+		}
+		fields := logrus.Fields{
+			"method":     c.Method(),
+			"route":      c.Route().Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if userID := requestUserID(c); userID != "" {
+			fields["user_id"] = userID
+		}
+
+		entry := utils.Log.WithContext(c.UserContext()).WithFields(fields)
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			// Internal errors are not shown to the client, so their cause is only kept here
+			if err != nil {
+				entry = entry.WithError(err)
+			}
+			entry.Error("request failed")
+		case status >= fiber.StatusBadRequest:
+			entry.Warn("request rejected")
+		default:
+			entry.Info("request completed")
+		}
+
+		return nil
+	}
+}
+
+// requestUserID returns the authenticated customer ID or the subject of a staff access token
+func requestUserID(c *fiber.Ctx) string {
+	if customerID := CustomerID(c); customerID != uuid.Nil {
+		return customerID.String()
+	}
+	if token, ok := c.Locals("user").(*jwt.Token); ok {
+		if subject, err := token.Claims.GetSubject(); err == nil {
+			return subject
+		}
+	}
+	return ""
+}
+
+// responseStatus works out the status code sent to the client, including when the handler returned an error
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	statusCode, _ := response.FromError(err, i18n.English)
+	return statusCode
 }
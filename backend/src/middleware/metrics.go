@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"app/src/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the request count and response time of each route.
+// Routes are counted by their definition without parameters (e.g. /v1/reservations/:id), so IDs do not add series
+func Metrics() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+
+		labels := []string{ctx.Method(), ctx.Route().Path, strconv.Itoa(responseStatus(ctx, err))}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
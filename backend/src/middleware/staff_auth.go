@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/utils"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	staffRoleKey = "staffRole"
+	staffIDKey   = "staffID"
+)
+
+// StaffAuth verifies a staff or admin access token.
+// When roles are given only those roles are allowed, and other staff get 403
+func StaffAuth(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		staffID, role, ok := staffToken(c)
+		if !ok {
+			return errAuthenticationRequired
+		}
+		if len(roles) > 0 && !slices.Contains(roles, role) {
+			return errForbidden
+		}
+
+		c.Locals(staffRoleKey, role)
+		c.Locals(staffIDKey, staffID)
+		return c.Next()
+	}
+}
+
+// StaffID returns the ID of the staff member or admin verified by StaffAuth, or nil without StaffAuth
+func StaffID(c *fiber.Ctx) *uuid.UUID {
+	staffID, ok := c.Locals(staffIDKey).(uuid.UUID)
+	if !ok {
+		return nil
+	}
+	return &staffID
+}
+
+// IsAdmin reports whether the request carries a valid admin access token. It is used on routes
+// that do not require authentication, to allow admin-only options such as booking over blocked time
+func IsAdmin(c *fiber.Ctx) bool {
+	if role, ok := c.Locals(staffRoleKey).(string); ok && role != "" {
+		return role == config.RoleAdmin
+	}
+	_, role, ok := staffToken(c)
+	return ok && role == config.RoleAdmin
+}
+
+func staffToken(c *fiber.Ctx) (uuid.UUID, string, bool) {
+	authHeader := c.Get(fiber.HeaderAuthorization)
+	tokenStr := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	if tokenStr == "" || tokenStr == authHeader {
+		return uuid.Nil, "", false
+	}
+
+	sub, role, err := utils.VerifyTokenRole(tokenStr, config.JWTSecret, config.TokenTypeAccess, config.RoleStaff, config.RoleAdmin)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	staffID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return staffID, role, true
+}
+
+var errForbidden = apperror.Forbidden("error.forbidden")
@@ -11,7 +11,7 @@ type AuditLog struct {
 	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Table      string    `gorm:"size:50;not null;index;column:table_name" json:"table_name" validate:"required,max=50"`
 	RecordID   uuid.UUID `gorm:"type:uuid;not null;index" json:"record_id" validate:"required"`
-	Action     string    `gorm:"size:20;not null;index" json:"action" validate:"required,oneof=CREATE UPDATE DELETE"`
+	Action     string    `gorm:"size:20;not null;index" json:"action" validate:"required,oneof=CREATE UPDATE DELETE MERGE EXPORT ERASE"`
 	OldValues  string    `gorm:"type:jsonb" json:"old_values"`
 	NewValues  string    `gorm:"type:jsonb" json:"new_values"`
 	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BlockedTimeReason string
+
+const (
+	BlockedTimeReasonBreak    BlockedTimeReason = "break"
+	BlockedTimeReasonTraining BlockedTimeReason = "training"
+	BlockedTimeReasonMeeting  BlockedTimeReason = "meeting"
+	BlockedTimeReasonPersonal BlockedTimeReason = "personal"
+	BlockedTimeReasonOther    BlockedTimeReason = "other"
+)
+
+// BlockedTime is a period within a shift that takes no reservations (breaks, training, personal time and so on).
+// With Date it happens once, and with Weekday it repeats every week between ValidFrom and ValidUntil
+type BlockedTime struct {
+	ID          uuid.UUID         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	StaffID     uuid.UUID         `gorm:"type:uuid;not null;index" json:"staff_id" validate:"required"`
+	Reason      BlockedTimeReason `gorm:"size:20;not null" json:"reason" validate:"required,oneof=break training meeting personal other"`
+	Description string            `gorm:"type:text" json:"description"`
+	Date        *time.Time        `gorm:"type:date;index" json:"date"`                           // one-off
+	Weekday     *int              `json:"weekday" validate:"omitempty,min=0,max=6"`              // recurring, 0 = Sunday
+	StartTime   string            `gorm:"size:8;not null" json:"start_time" validate:"required"` // HH:MM:SS
+	EndTime     string            `gorm:"size:8;not null" json:"end_time" validate:"required"`   // HH:MM:SS
+	ValidFrom   *time.Time        `gorm:"type:date" json:"valid_from"`
+	ValidUntil  *time.Time        `gorm:"type:date" json:"valid_until"`
+	IsActive    bool              `gorm:"default:true;not null" json:"is_active"`
+	CreatedAt   time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Staff Staff `gorm:"foreignKey:StaffID" json:"staff,omitempty" validate:"-"`
+}
+
+func (b *BlockedTime) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+func (b *BlockedTime) TableName() string {
+	return "staff_blocked_times"
+}
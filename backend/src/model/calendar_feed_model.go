@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CalendarFeedOwner string
+
+const (
+	CalendarFeedOwnerStaff    CalendarFeedOwner = "staff"
+	CalendarFeedOwnerCustomer CalendarFeedOwner = "customer"
+)
+
+// CalendarFeed is the secret token of the iCalendar feed URL of a staff member or customer.
+// Each owner has at most one valid feed, and issuing a new one disables the old URL. Only the token hash is stored
+type CalendarFeed struct {
+	ID             uuid.UUID         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	OwnerType      CalendarFeedOwner `gorm:"size:20;not null;uniqueIndex:idx_calendar_feeds_owner" json:"owner_type" validate:"required,oneof=staff customer"`
+	OwnerID        uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex:idx_calendar_feeds_owner" json:"owner_id" validate:"required"`
+	TokenHash      string            `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	LastAccessedAt *time.Time        `json:"last_accessed_at"`
+	CreatedAt      time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+func (f *CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"app/src/utils"
+	"app/src/validation"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,19 +13,67 @@ import (
 type Customer struct {
 	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Name        string    `gorm:"size:100;not null" json:"name" validate:"required,min=1,max=100"`
-	Phone       string    `gorm:"size:20;uniqueIndex;not null" json:"phone" validate:"required,min=10,max=20"`
-	Email       string    `gorm:"size:255;uniqueIndex" json:"email" validate:"omitempty,email,max=255"`
-	Birthday    *time.Time `gorm:"type:date" json:"birthday"`
+	NameKana    string    `gorm:"size:100" json:"name_kana" validate:"omitempty,max=100"` // reading of the name in kana
+	Phone       string    `gorm:"type:text;not null;default:'';serializer:encrypted" json:"phone" validate:"omitempty,phone"` // E.164, empty until a customer who signed in by email registers one
+	PhoneDisplay string    `gorm:"-" json:"phone_display"` // national format, e.g. 090-1234-5678
+	Email       string    `gorm:"type:text;serializer:encrypted" json:"email" validate:"omitempty,email,max=255"`
+	Birthday    *time.Time `gorm:"type:text;serializer:encrypted" json:"birthday"`
 	Gender      string    `gorm:"size:10" json:"gender" validate:"omitempty,oneof=male female other"`
-	Notes       string    `gorm:"type:text" json:"notes"`
+	Notes       string    `gorm:"type:text;serializer:encrypted" json:"notes"`
+	Locale      string    `gorm:"size:10;not null;default:''" json:"locale" validate:"omitempty,oneof=ja en"` // language of notifications, empty for the salon default
 	IsActive    bool      `gorm:"default:true;not null" json:"is_active"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ErasedAt    *time.Time `gorm:"index" json:"erased_at,omitempty"` // personal data was anonymized on request
+	CreatedAt   time.Time `gorm:"autoCreateTime;index:idx_customers_created_at" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Normalized copies for search, maintained in BeforeSave
+	NameSearch  string `gorm:"size:100;index" json:"-"`
+	KanaSearch  string `gorm:"size:100;index" json:"-"`
+
+	// Blind indexes for exact lookups on the encrypted phone and email, maintained in BeforeSave
+	PhoneIndex         string `gorm:"size:64;not null;default:'';uniqueIndex:idx_customers_phone_index,where:phone_index <> ''" json:"-"`
+	PhoneLastFourIndex string `gorm:"size:64;not null;default:'';index" json:"-"`
+	EmailIndex         string `gorm:"size:64;not null;default:'';uniqueIndex:idx_customers_email_index,where:email_index <> ''" json:"-"`
 	
 	// Relations
 	Reservations []Reservation `gorm:"foreignKey:CustomerID" json:"reservations,omitempty"`
 }
 
+func (c *Customer) BeforeSave(tx *gorm.DB) error {
+	c.Phone = validation.NormalizePhone(c.Phone)
+	c.PhoneDisplay = validation.DisplayPhone(c.Phone)
+	c.NameSearch = utils.NormalizeSearchText(c.Name)
+	c.KanaSearch = utils.NormalizeSearchText(c.NameKana)
+	c.PhoneIndex = CustomerPhoneIndex(c.Phone)
+	c.PhoneLastFourIndex = CustomerPhoneLastFourIndex(c.Phone)
+	c.EmailIndex = CustomerEmailIndex(c.Email)
+	return nil
+}
+
+func (c *Customer) AfterFind(tx *gorm.DB) error {
+	c.PhoneDisplay = validation.DisplayPhone(c.Phone)
+	return nil
+}
+
+// CustomerPhoneIndex returns the blind index of a phone number. The number is normalized to domestic digits first so formatting does not matter
+func CustomerPhoneIndex(phone string) string {
+	return utils.BlindIndex(utils.NationalPhoneDigits(phone))
+}
+
+// CustomerPhoneLastFourIndex returns the blind index of the last four digits of a phone number, used to find customers at the front desk
+func CustomerPhoneLastFourIndex(phone string) string {
+	digits := utils.NationalPhoneDigits(phone)
+	if len(digits) < 4 {
+		return ""
+	}
+	return utils.BlindIndex("last4:" + digits[len(digits)-4:])
+}
+
+// CustomerEmailIndex returns the blind index of an email address, ignoring case
+func CustomerEmailIndex(email string) string {
+	return utils.BlindIndex(strings.ToLower(strings.TrimSpace(email)))
+}
+
 func (c *Customer) BeforeCreate(tx *gorm.DB) error {
 	if c.ID == uuid.Nil {
 		c.ID = uuid.New()
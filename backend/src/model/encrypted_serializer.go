@@ -0,0 +1,80 @@
+package model
+
+import (
+	"app/src/utils"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+// EncryptedSerializer encrypts fields tagged `gorm:"serializer:encrypted"` when saving and decrypts them when loading.
+// It supports string and *time.Time (dates), and stores empty strings and nil as is without encrypting them
+type EncryptedSerializer struct{}
+
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	var stored string
+	switch value := dbValue.(type) {
+	case nil:
+	case string:
+		stored = value
+	case []byte:
+		stored = string(value)
+	case time.Time:
+		// A date column that has not been migrated to text yet
+		stored = value.Format("2006-01-02")
+	default:
+		return fmt.Errorf("unsupported encrypted value type %T for %s", dbValue, field.Name)
+	}
+
+	if stored != "" {
+		plaintext, err := utils.DecryptField(stored)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+
+		switch target := fieldValue.Interface().(type) {
+		case *string:
+			*target = plaintext
+		case **time.Time:
+			date, err := time.Parse("2006-01-02", plaintext[:min(len(plaintext), 10)])
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", field.Name, err)
+			}
+			*target = &date
+		default:
+			return fmt.Errorf("unsupported encrypted field type %s for %s", field.FieldType, field.Name)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext string
+	switch value := fieldValue.(type) {
+	case string:
+		plaintext = value
+	case *time.Time:
+		if value == nil {
+			return nil, nil
+		}
+		plaintext = value.Format("2006-01-02")
+	default:
+		return nil, fmt.Errorf("unsupported encrypted field type %T for %s", fieldValue, field.Name)
+	}
+
+	if plaintext == "" {
+		return "", nil
+	}
+	return utils.EncryptField(plaintext)
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LoginChannel string
+
+const (
+	LoginChannelEmail LoginChannel = "email"
+	LoginChannelSMS   LoginChannel = "sms"
+)
+
+// LoginChallenge is a one-time code or magic link sent for a customer's passwordless login.
+// Only hashes of the code and the link token are stored
+type LoginChallenge struct {
+	ID          uuid.UUID    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Channel     LoginChannel `gorm:"size:10;not null" json:"channel" validate:"required,oneof=email sms"`
+	Destination string       `gorm:"size:255;not null;index" json:"destination" validate:"required,max=255"`
+	CodeHash    string       `gorm:"size:64;not null" json:"-"`
+	LinkHash    string       `gorm:"size:64;index" json:"-"`
+	Attempts    int          `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt   time.Time    `gorm:"not null" json:"expires_at"`
+	ConsumedAt  *time.Time   `json:"consumed_at"`
+	CustomerID  *uuid.UUID   `gorm:"type:uuid" json:"customer_id"`
+	Locale      string       `gorm:"size:10;not null;default:''" json:"locale"` // language of the request, given to a customer who signs up
+	CreatedAt   time.Time    `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (l *LoginChallenge) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *LoginChallenge) TableName() string {
+	return "customer_login_challenges"
+}
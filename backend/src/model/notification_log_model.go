@@ -17,16 +17,36 @@ const (
 
 type NotificationLog struct {
 	ID            uuid.UUID          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CustomerID    *uuid.UUID         `gorm:"type:uuid;index" json:"customer_id"`
 	Type          string             `gorm:"size:20;not null;index" json:"type" validate:"required,oneof=email sms push"`
-	Recipient     string             `gorm:"size:255;not null" json:"recipient" validate:"required,max=255"`
+	Recipient     string             `gorm:"type:text;not null;serializer:encrypted" json:"recipient" validate:"required,max=255"`
 	Subject       string             `gorm:"size:255" json:"subject" validate:"omitempty,max=255"`
-	Message       string             `gorm:"type:text;not null" json:"message" validate:"required"`
+	Message       string             `gorm:"type:text;not null;serializer:encrypted" json:"message" validate:"required"` // message key of the template, older rows hold the sent body
 	Status        NotificationStatus `gorm:"size:20;not null;default:pending" json:"status" validate:"required,oneof=pending sent failed"`
-	ErrorMessage  string             `gorm:"type:text" json:"error_message"`
+	ErrorMessage  string             `gorm:"type:text;serializer:encrypted" json:"error_message"` // provider errors may quote the recipient
 	ScheduledAt   *time.Time         `gorm:"index" json:"scheduled_at"`
 	SentAt        *time.Time         `gorm:"index" json:"sent_at"`
 	CreatedAt     time.Time          `gorm:"autoCreateTime;index:idx_notification_logs_created_at" json:"created_at"`
 	UpdatedAt     time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Blind index of the recipient, the same value as the customer's phone or email index, maintained in BeforeSave
+	RecipientIndex string `gorm:"size:64;not null;default:'';index" json:"-"`
+}
+
+func (n *NotificationLog) BeforeSave(tx *gorm.DB) error {
+	n.RecipientIndex = NotificationRecipientIndex(n.Type, n.Recipient)
+	return nil
+}
+
+// NotificationRecipientIndex returns the blind index of a notification recipient, the same value as the customer phone and email indexes
+func NotificationRecipientIndex(notificationType, recipient string) string {
+	if recipient == "" {
+		return ""
+	}
+	if notificationType == "email" {
+		return CustomerEmailIndex(recipient)
+	}
+	return CustomerPhoneIndex(recipient)
 }
 
 func (n *NotificationLog) BeforeCreate(tx *gorm.DB) error {
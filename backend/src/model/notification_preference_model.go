@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationPreference is a customer's notification settings
+type NotificationPreference struct {
+	ID               uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CustomerID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"customer_id" validate:"required"`
+	EmailEnabled     bool      `gorm:"not null;default:true" json:"email_enabled"`
+	SMSEnabled       bool      `gorm:"not null;default:true" json:"sms_enabled"`
+	ReminderEnabled  bool      `gorm:"not null;default:true" json:"reminder_enabled"`
+	MarketingEnabled bool      `gorm:"not null;default:false" json:"marketing_enabled"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (n *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+func (n *NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
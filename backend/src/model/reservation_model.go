@@ -18,25 +18,31 @@ const (
 )
 
 type Reservation struct {
-	ID                  uuid.UUID         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	CustomerID          uuid.UUID         `gorm:"type:uuid;not null;index" json:"customer_id" validate:"required"`
-	StaffID             uuid.UUID         `gorm:"type:uuid;not null;index" json:"staff_id" validate:"required"`
-	ReservationDate     time.Time         `gorm:"not null;index" json:"reservation_date" validate:"required"`
-	StartTime           time.Time         `gorm:"not null" json:"start_time" validate:"required"`
-	EndTime             time.Time         `gorm:"not null" json:"end_time" validate:"required"`
-	Status              ReservationStatus `gorm:"size:20;not null;default:pending" json:"status" validate:"required,oneof=pending confirmed completed cancelled no_show"`
-	TotalDuration       int               `gorm:"not null" json:"total_duration"`        // minutes
-	TotalPrice          int               `gorm:"not null" json:"total_price"`           // yen
-	Notes               string            `gorm:"type:text" json:"notes"`
-	CancellationReason  string            `gorm:"type:text" json:"cancellation_reason"`
-	CreatedAt           time.Time         `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt           time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
-	
+	ID                      uuid.UUID         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CustomerID              uuid.UUID         `gorm:"type:uuid;not null;index" json:"customer_id" validate:"required"`
+	StaffID                 uuid.UUID         `gorm:"type:uuid;not null;index" json:"staff_id" validate:"required"`
+	ReservationDate         time.Time         `gorm:"not null;index" json:"reservation_date" validate:"required"`
+	StartTime               time.Time         `gorm:"not null" json:"start_time" validate:"required"`
+	EndTime                 time.Time         `gorm:"not null" json:"end_time" validate:"required"`
+	Status                  ReservationStatus `gorm:"size:20;not null;default:pending" json:"status" validate:"required,oneof=pending confirmed completed cancelled no_show"`
+	TotalDuration           int               `gorm:"not null" json:"total_duration"` // minutes
+	TotalPrice              int               `gorm:"not null" json:"total_price"`    // yen
+	Notes                   string            `gorm:"type:text" json:"notes"`
+	CancellationReason      string            `gorm:"type:text" json:"cancellation_reason"`
+	CancelledAt             *time.Time        `json:"cancelled_at,omitempty"`
+	LateCancellation        bool              `gorm:"not null;default:false" json:"late_cancellation"` // cancelled within config.LateCancelWindow hours of the start
+	NoShowFlaggedAt         *time.Time        `gorm:"index" json:"no_show_flagged_at,omitempty"`       // still unattended after the start, waiting for staff review
+	SeriesID                *uuid.UUID        `gorm:"type:uuid;index" json:"series_id,omitempty"`
+	StaffAutoAssigned       bool              `gorm:"not null;default:false" json:"staff_auto_assigned"`
+	StaffAssignmentStrategy string            `gorm:"size:20" json:"staff_assignment_strategy,omitempty"`
+	CreatedAt               time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt               time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+
 	// Relations
-	Customer            Customer            `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
-	Staff               Staff               `gorm:"foreignKey:StaffID" json:"staff,omitempty"`
-	ReservationMenus    []ReservationMenu   `gorm:"foreignKey:ReservationID" json:"reservation_menus,omitempty"`
-	ReservationOptions  []ReservationOption `gorm:"foreignKey:ReservationID" json:"reservation_options,omitempty"`
+	Customer           Customer            `gorm:"foreignKey:CustomerID" json:"customer,omitempty" validate:"-"`
+	Staff              Staff               `gorm:"foreignKey:StaffID" json:"staff,omitempty" validate:"-"`
+	ReservationMenus   []ReservationMenu   `gorm:"foreignKey:ReservationID" json:"reservation_menus,omitempty"`
+	ReservationOptions []ReservationOption `gorm:"foreignKey:ReservationID" json:"reservation_options,omitempty"`
 }
 
 type ReservationMenu struct {
@@ -46,10 +52,10 @@ type ReservationMenu struct {
 	Quantity      int       `gorm:"not null;default:1" json:"quantity" validate:"required,min=1"`
 	UnitPrice     int       `gorm:"not null" json:"unit_price"`
 	TotalPrice    int       `gorm:"not null" json:"total_price"`
-	
+
 	// Relations
-	Reservation   Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
-	Menu          Menu        `gorm:"foreignKey:MenuID" json:"menu,omitempty"`
+	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty" validate:"-"`
+	Menu        Menu        `gorm:"foreignKey:MenuID" json:"menu,omitempty" validate:"-"`
 }
 
 type ReservationOption struct {
@@ -59,10 +65,10 @@ type ReservationOption struct {
 	Quantity      int       `gorm:"not null;default:1" json:"quantity" validate:"required,min=1"`
 	UnitPrice     int       `gorm:"not null" json:"unit_price"`
 	TotalPrice    int       `gorm:"not null" json:"total_price"`
-	
+
 	// Relations
-	Reservation   Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
-	Option        Option      `gorm:"foreignKey:OptionID" json:"option,omitempty"`
+	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty" validate:"-"`
+	Option      Option      `gorm:"foreignKey:OptionID" json:"option,omitempty" validate:"-"`
 }
 
 func (r *Reservation) BeforeCreate(tx *gorm.DB) error {
@@ -96,4 +102,4 @@ func (rm *ReservationMenu) TableName() string {
 
 func (ro *ReservationOption) TableName() string {
 	return "reservation_options"
-}
\ No newline at end of file
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReservationSeriesStatus string
+
+const (
+	ReservationSeriesStatusActive    ReservationSeriesStatus = "active"
+	ReservationSeriesStatusCancelled ReservationSeriesStatus = "cancelled"
+)
+
+type ReservationSeries struct {
+	ID         uuid.UUID               `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CustomerID uuid.UUID               `gorm:"type:uuid;not null;index" json:"customer_id" validate:"required"`
+	StaffID    uuid.UUID               `gorm:"type:uuid;not null;index" json:"staff_id" validate:"required"`
+	RRule      string                  `gorm:"size:255;not null" json:"rrule" validate:"required,max=255"`
+	StartDate  time.Time               `gorm:"type:date;not null" json:"start_date" validate:"required"`
+	StartTime  string                  `gorm:"size:8;not null" json:"start_time" validate:"required"` // HH:MM:SS
+	Status     ReservationSeriesStatus `gorm:"size:20;not null;default:active" json:"status" validate:"required,oneof=active cancelled"`
+	Notes      string                  `gorm:"type:text" json:"notes"`
+	CreatedAt  time.Time               `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time               `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Customer      Customer                  `gorm:"foreignKey:CustomerID" json:"customer,omitempty" validate:"-"`
+	Staff         Staff                     `gorm:"foreignKey:StaffID" json:"staff,omitempty" validate:"-"`
+	SeriesMenus   []ReservationSeriesMenu   `gorm:"foreignKey:SeriesID" json:"series_menus,omitempty"`
+	SeriesOptions []ReservationSeriesOption `gorm:"foreignKey:SeriesID" json:"series_options,omitempty"`
+	Reservations  []Reservation             `gorm:"foreignKey:SeriesID" json:"reservations,omitempty"`
+}
+
+type ReservationSeriesMenu struct {
+	ID       uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	SeriesID uuid.UUID `gorm:"type:uuid;not null;index" json:"series_id"`
+	MenuID   uuid.UUID `gorm:"type:uuid;not null;index" json:"menu_id"`
+
+	// Relations
+	Menu Menu `gorm:"foreignKey:MenuID" json:"menu,omitempty" validate:"-"`
+}
+
+type ReservationSeriesOption struct {
+	ID       uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	SeriesID uuid.UUID `gorm:"type:uuid;not null;index" json:"series_id"`
+	OptionID uuid.UUID `gorm:"type:uuid;not null;index" json:"option_id"`
+
+	// Relations
+	Option Option `gorm:"foreignKey:OptionID" json:"option,omitempty" validate:"-"`
+}
+
+func (rs *ReservationSeries) BeforeCreate(tx *gorm.DB) error {
+	if rs.ID == uuid.Nil {
+		rs.ID = uuid.New()
+	}
+	return nil
+}
+
+func (rsm *ReservationSeriesMenu) BeforeCreate(tx *gorm.DB) error {
+	if rsm.ID == uuid.Nil {
+		rsm.ID = uuid.New()
+	}
+	return nil
+}
+
+func (rso *ReservationSeriesOption) BeforeCreate(tx *gorm.DB) error {
+	if rso.ID == uuid.Nil {
+		rso.ID = uuid.New()
+	}
+	return nil
+}
+
+func (rs *ReservationSeries) TableName() string {
+	return "reservation_series"
+}
+
+func (rsm *ReservationSeriesMenu) TableName() string {
+	return "reservation_series_menus"
+}
+
+func (rso *ReservationSeriesOption) TableName() string {
+	return "reservation_series_options"
+}
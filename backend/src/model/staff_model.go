@@ -1,6 +1,7 @@
 package model
 
 import (
+	"app/src/validation"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,7 +12,8 @@ type Staff struct {
 	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Name        string    `gorm:"size:100;not null" json:"name" validate:"required,min=1,max=100"`
 	Email       string    `gorm:"size:255;uniqueIndex;not null" json:"email" validate:"required,email,max=255"`
-	Phone       string    `gorm:"size:20" json:"phone" validate:"omitempty,min=10,max=20"`
+	Phone       string    `gorm:"size:20" json:"phone" validate:"omitempty,phone"` // E.164
+	PhoneDisplay string    `gorm:"-" json:"phone_display"` // national format, e.g. 03-1234-5678
 	Position    string    `gorm:"size:50" json:"position" validate:"omitempty,max=50"`
 	Specialties string    `gorm:"type:text" json:"specialties"`
 	IsActive    bool      `gorm:"default:true;not null" json:"is_active"`
@@ -30,6 +32,17 @@ func (s *Staff) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (s *Staff) BeforeSave(tx *gorm.DB) error {
+	s.Phone = validation.NormalizePhone(s.Phone)
+	s.PhoneDisplay = validation.DisplayPhone(s.Phone)
+	return nil
+}
+
+func (s *Staff) AfterFind(tx *gorm.DB) error {
+	s.PhoneDisplay = validation.DisplayPhone(s.Phone)
+	return nil
+}
+
 func (s *Staff) TableName() string {
 	return "staff"
 }
\ No newline at end of file
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"
+	WaitlistStatusOffered   WaitlistStatus = "offered"
+	WaitlistStatusAccepted  WaitlistStatus = "accepted"
+	WaitlistStatusExpired   WaitlistStatus = "expired"
+	WaitlistStatusCancelled WaitlistStatus = "cancelled"
+)
+
+type WaitlistEntry struct {
+	ID             uuid.UUID      `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CustomerID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"customer_id" validate:"required"`
+	StaffID        *uuid.UUID     `gorm:"type:uuid;index" json:"staff_id"` // nil means no preference
+	DesiredDate    time.Time      `gorm:"type:date;not null;index" json:"desired_date" validate:"required"`
+	WindowStart    string         `gorm:"size:8" json:"window_start"` // HH:MM:SS, empty means shift start
+	WindowEnd      string         `gorm:"size:8" json:"window_end"`   // HH:MM:SS, empty means shift end
+	Status         WaitlistStatus `gorm:"size:20;not null;default:waiting;index" json:"status" validate:"required,oneof=waiting offered accepted expired cancelled"`
+	Notes          string         `gorm:"type:text" json:"notes"`
+	OfferToken     string         `gorm:"size:64;index" json:"-"`
+	OfferExpiresAt *time.Time     `json:"offer_expires_at"`
+	ReservationID  *uuid.UUID     `gorm:"type:uuid" json:"reservation_id"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Customer      Customer       `gorm:"foreignKey:CustomerID" json:"customer,omitempty" validate:"-"`
+	Staff         *Staff         `gorm:"foreignKey:StaffID" json:"staff,omitempty"`
+	WaitlistMenus []WaitlistMenu `gorm:"foreignKey:WaitlistEntryID" json:"waitlist_menus,omitempty"`
+	Reservation   *Reservation   `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+}
+
+type WaitlistMenu struct {
+	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	WaitlistEntryID uuid.UUID `gorm:"type:uuid;not null;index" json:"waitlist_entry_id"`
+	MenuID          uuid.UUID `gorm:"type:uuid;not null;index" json:"menu_id"`
+
+	// Relations
+	Menu Menu `gorm:"foreignKey:MenuID" json:"menu,omitempty" validate:"-"`
+}
+
+func (w *WaitlistEntry) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+func (wm *WaitlistMenu) BeforeCreate(tx *gorm.DB) error {
+	if wm.ID == uuid.Nil {
+		wm.ID = uuid.New()
+	}
+	return nil
+}
+
+func (w *WaitlistEntry) TableName() string {
+	return "waitlist_entries"
+}
+
+func (wm *WaitlistMenu) TableName() string {
+	return "waitlist_menus"
+}
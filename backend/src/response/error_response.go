@@ -1,30 +1,126 @@
 package response
 
 import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/validation"
+	"errors"
+	"sort"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
-func Error(c *fiber.Ctx, statusCode int, message string, details interface{}) error {
-	var errRes error
-	if details != nil {
-		errRes = c.Status(statusCode).JSON(ErrorDetails{
-			Code:    statusCode,
-			Status:  "error",
-			Message: message,
-			Errors:  details,
-		})
-	} else {
-		errRes = c.Status(statusCode).JSON(Common{
-			Code:    statusCode,
-			Status:  "error",
-			Message: message,
-		})
+// ErrorBody is the error part of an error response
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ErrorEnvelope is the error response shared by all endpoints
+type ErrorEnvelope struct {
+	Success bool      `json:"success"`
+	Error   ErrorBody `json:"error"`
+	Meta    Meta      `json:"meta"`
+}
+
+type Meta struct {
+	Timestamp string `json:"timestamp"`
+}
+
+type errorMapping struct {
+	status int
+	code   string
+}
+
+var kindMappings = map[apperror.Kind]errorMapping{
+	apperror.KindValidation:      {fiber.StatusBadRequest, "VALIDATION_ERROR"},
+	apperror.KindUnauthorized:    {fiber.StatusUnauthorized, "UNAUTHORIZED"},
+	apperror.KindForbidden:       {fiber.StatusForbidden, "FORBIDDEN"},
+	apperror.KindNotFound:        {fiber.StatusNotFound, "NOT_FOUND"},
+	apperror.KindGone:            {fiber.StatusGone, "GONE"},
+	apperror.KindConflict:        {fiber.StatusConflict, "CONFLICT"},
+	apperror.KindPolicyViolation: {fiber.StatusUnprocessableEntity, "BUSINESS_RULE_ERROR"},
+	apperror.KindTooManyRequests: {fiber.StatusTooManyRequests, "TOO_MANY_REQUESTS"},
+}
+
+type statusMapping struct {
+	code string
+	key  string
+}
+
+// statusMappings are the error codes and message keys of errors returned by Fiber (no route, wrong method and so on)
+var statusMappings = map[int]statusMapping{
+	fiber.StatusBadRequest:            {"VALIDATION_ERROR", "error.bad_request"},
+	fiber.StatusUnauthorized:          {"UNAUTHORIZED", "error.unauthorized"},
+	fiber.StatusForbidden:             {"FORBIDDEN", "error.forbidden"},
+	fiber.StatusNotFound:              {"NOT_FOUND", "error.not_found"},
+	fiber.StatusMethodNotAllowed:      {"METHOD_NOT_ALLOWED", "error.method_not_allowed"},
+	fiber.StatusRequestEntityTooLarge: {"PAYLOAD_TOO_LARGE", "error.payload_too_large"},
+	fiber.StatusTooManyRequests:       {"TOO_MANY_REQUESTS", "error.too_many_requests"},
+}
+
+// FromError turns an error into a status code and the body of an error response in the given language.
+// This is the only place mapping errors to HTTP, and errors without a kind become a 500 that hides their content
+func FromError(err error, locale string) (int, ErrorBody) {
+	internalError := ErrorBody{Code: "INTERNAL_ERROR", Message: i18n.T(locale, "error.internal", nil)}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		mapping, ok := kindMappings[appErr.Kind]
+		if !ok {
+			return fiber.StatusInternalServerError, internalError
+		}
+		code := mapping.code
+		if appErr.Code != "" {
+			code = appErr.Code
+		}
+		details := appErr.Details
+		if localizer, ok := details.(apperror.Localizer); ok {
+			details = localizer.Localize(locale)
+		}
+		return mapping.status, ErrorBody{Code: code, Message: appErr.Message(locale), Details: details}
 	}
 
-	if errRes != nil {
-		logrus.Errorf("Failed to send error response : %+v", errRes)
+	if fields := validation.CustomErrorMessages(err, locale); len(fields) > 0 {
+		details := make([]apperror.FieldError, 0, len(fields))
+		for field, message := range fields {
+			details = append(details, apperror.FieldError{Field: field, Message: message})
+		}
+		sort.Slice(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+		return fiber.StatusBadRequest, ErrorBody{Code: "VALIDATION_ERROR", Message: i18n.T(locale, "error.invalid_input", nil), Details: details}
 	}
 
-	return errRes
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		if mapping, ok := statusMappings[fiberErr.Code]; ok {
+			return fiberErr.Code, ErrorBody{Code: mapping.code, Message: i18n.T(locale, mapping.key, nil)}
+		}
+		code := "INTERNAL_ERROR"
+		if fiberErr.Code < fiber.StatusInternalServerError {
+			code = "BAD_REQUEST"
+		}
+		return fiberErr.Code, ErrorBody{Code: code, Message: fiberErr.Message}
+	}
+
+	// Translated by GORM, for lookups and unique indexes the services do not check themselves
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.StatusNotFound, ErrorBody{Code: "NOT_FOUND", Message: i18n.T(locale, "error.resource_not_found", nil)}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fiber.StatusConflict, ErrorBody{Code: "CONFLICT", Message: i18n.T(locale, "error.already_registered", nil)}
+	}
+
+	return fiber.StatusInternalServerError, internalError
+}
+
+// Error writes the error as the common error response in the request's language
+func Error(c *fiber.Ctx, err error) error {
+	statusCode, body := FromError(err, i18n.FromCtx(c))
+	return c.Status(statusCode).JSON(ErrorEnvelope{
+		Success: false,
+		Error:   body,
+		Meta:    Meta{Timestamp: c.Context().Time().Format("2006-01-02T15:04:05-07:00")},
+	})
 }
@@ -33,10 +33,3 @@ type SuccessWithPaginate[T any] struct {
 	TotalPages   int64  `json:"total_pages"`
 	TotalResults int64  `json:"total_results"`
 }
-
-type ErrorDetails struct {
-	Code    int         `json:"code"`
-	Status  string      `json:"status"`
-	Message string      `json:"message"`
-	Errors  interface{} `json:"errors"`
-}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func AnalyticsRoutes(api fiber.Router, db *gorm.DB) {
+	analyticsService := service.NewAnalyticsService(db)
+	analyticsController := controller.NewAnalyticsController(analyticsService)
+
+	analytics := api.Group("/analytics")
+	analytics.Get("/utilization", analyticsController.GetUtilization)
+}
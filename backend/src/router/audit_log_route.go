@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/src/config"
+	"app/src/controller"
+	"app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func AuditLogRoutes(api fiber.Router, db *gorm.DB) {
+	auditLogService := service.NewAuditLogService(db)
+	auditLogController := controller.NewAuditLogController(auditLogService)
+
+	// Audit entries describe changes to customer data, only admins can read them
+	auditLog := api.Group("/audit-logs", middleware.StaffAuth(config.RoleAdmin))
+	auditLog.Get("/", auditLogController.GetAuditLogs)
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func BlockedTimeRoutes(api fiber.Router, db *gorm.DB) {
+	blockedTimeService := service.NewBlockedTimeService(db)
+	blockedTimeController := controller.NewBlockedTimeController(blockedTimeService)
+
+	blockedTime := api.Group("/staff/:staffId/blocked-times")
+	blockedTime.Get("/", blockedTimeController.GetBlockedTimes)
+	blockedTime.Post("/", blockedTimeController.CreateBlockedTime)
+	blockedTime.Delete("/:id", blockedTimeController.DeleteBlockedTime)
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CalendarRoutes(api fiber.Router, calendarService *service.CalendarService) {
+	calendarController := controller.NewCalendarController(calendarService)
+
+	// Calendar apps cannot send credentials, the secret token in the URL is the only authorization
+	api.Get("/calendar/:token.ics", calendarController.GetFeed)
+
+	// A feed token grants read access to the schedule, so only staff may issue one for someone else
+	api.Post("/staff/:staffId/calendar-feed", middleware.StaffAuth(), calendarController.IssueStaffFeed)
+	api.Delete("/staff/:staffId/calendar-feed", middleware.StaffAuth(), calendarController.RevokeStaffFeed)
+	api.Post("/customers/:id/calendar-feed", middleware.StaffAuth(), calendarController.IssueCustomerFeed)
+	api.Delete("/customers/:id/calendar-feed", middleware.StaffAuth(), calendarController.RevokeCustomerFeed)
+	api.Post("/me/calendar-feed", middleware.CustomerAuth(), calendarController.IssueMyFeed)
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func CustomerAuthRoutes(api fiber.Router, authService *service.CustomerAuthService) {
+	authController := controller.NewCustomerAuthController(authService)
+
+	// Passwordless login for customers
+	auth := api.Group("/auth/customer")
+	auth.Post("/login", authController.RequestLogin)
+	auth.Post("/verify", authController.VerifyLoginCode)
+	auth.Post("/refresh", authController.RefreshToken)
+
+	// The magic link opens a confirm page; only its form POST uses up the link and logs in,
+	// so mail scanners that follow links cannot log in or spend the link
+	auth.Get("/magic/:token", authController.GetMagicLink)
+	auth.Post("/magic/:token", authController.VerifyMagicLink)
+}
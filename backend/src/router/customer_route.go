@@ -1,7 +1,9 @@
 package router
 
 import (
+	"app/src/config"
 	"app/src/controller"
+	"app/src/middleware"
 	"app/src/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,8 +16,16 @@ func CustomerRoutes(api fiber.Router, db *gorm.DB) {
 
 	customer := api.Group("/customers")
 	customer.Get("/", customerController.GetCustomers)
+	customer.Get("/search", customerController.SearchCustomers)
+	customer.Get("/duplicates", middleware.StaffAuth(), customerController.GetDuplicateCandidates)
+	customer.Get("/export", middleware.StaffAuth(), customerController.ExportCustomers)
+	customer.Post("/import", middleware.StaffAuth(), customerController.ImportCustomers)
 	customer.Get("/:id", customerController.GetCustomer)
+	customer.Get("/:id/profile", customerController.GetCustomerProfile)
 	customer.Post("/", customerController.CreateCustomer)
 	customer.Put("/:id", customerController.UpdateCustomer)
 	customer.Delete("/:id", customerController.DeleteCustomer)
+	customer.Post("/:id/merge", middleware.StaffAuth(), customerController.MergeCustomer)
+	customer.Get("/:id/export", middleware.StaffAuth(config.RoleAdmin), customerController.ExportCustomerData)
+	customer.Post("/:id/erase", middleware.StaffAuth(config.RoleAdmin), customerController.EraseCustomer)
 }
\ No newline at end of file
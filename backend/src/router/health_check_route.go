@@ -13,3 +13,11 @@ func HealthCheckRoutes(v1 fiber.Router, h service.HealthCheckService) {
 	healthCheck := v1.Group("/health-check")
 	healthCheck.Get("/", healthCheckController.Check)
 }
+
+// ProbeRoutes registers /livez and /readyz, used by orchestrators, outside /v1
+func ProbeRoutes(app *fiber.App, h service.HealthCheckService) {
+	healthCheckController := controller.NewHealthCheckController(h)
+
+	app.Get("/livez", healthCheckController.Live)
+	app.Get("/readyz", healthCheckController.Ready)
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func MeRoutes(api fiber.Router, portalService *service.CustomerPortalService) {
+	meController := controller.NewMeController(portalService)
+
+	// Customer self-service routes, the customer is always taken from the token
+	me := api.Group("/me", middleware.CustomerAuth())
+	me.Get("/", meController.GetProfile)
+	me.Put("/", meController.UpdateProfile)
+	me.Get("/reservations", meController.GetMyReservations)
+	me.Get("/reservations/:id", meController.GetMyReservation)
+	me.Post("/reservations", meController.CreateMyReservation)
+	me.Put("/reservations/:id", meController.RescheduleMyReservation)
+	me.Delete("/reservations/:id", meController.CancelMyReservation)
+	me.Get("/notification-preferences", meController.GetNotificationPreferences)
+	me.Put("/notification-preferences", meController.UpdateNotificationPreferences)
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"app/src/config"
+	"app/src/metrics"
+	"app/src/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+func MetricsRoutes(app *fiber.App, db *gorm.DB) {
+	if db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			if err := metrics.RegisterDB(sqlDB, config.DBName); err != nil {
+				utils.Log.Errorf("Failed to register database metrics: %v", err)
+			}
+		}
+	}
+
+	// Prometheus scrapes the app root, outside the versioned API
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+}
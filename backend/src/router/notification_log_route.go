@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func NotificationLogRoutes(api fiber.Router, db *gorm.DB) {
+	notificationLogService := service.NewNotificationLogService(db)
+	notificationLogController := controller.NewNotificationLogController(notificationLogService)
+
+	notification := api.Group("/notifications", middleware.StaffAuth())
+	notification.Get("/", notificationLogController.GetNotificationLogs)
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func ReportRoutes(api fiber.Router, db *gorm.DB) {
+	reportService := service.NewReportService(db)
+	reportController := controller.NewReportController(reportService)
+
+	report := api.Group("/reports")
+	report.Get("/sales", reportController.GetSalesReport)
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ReservationSeriesRoutes(api fiber.Router, seriesService *service.ReservationSeriesService) {
+	seriesController := controller.NewReservationSeriesController(seriesService)
+
+	series := api.Group("/reservation-series")
+	series.Post("/", seriesController.CreateReservationSeries)
+	series.Get("/:id", seriesController.GetReservationSeries)
+	series.Delete("/:id", seriesController.CancelReservationSeries)
+	series.Put("/:id/occurrences/:reservationId", seriesController.UpdateSeriesOccurrence)
+	series.Delete("/:id/occurrences/:reservationId", seriesController.CancelSeriesOccurrence)
+}
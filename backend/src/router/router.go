@@ -3,6 +3,8 @@ package router
 import (
 	"app/src/config"
 	"app/src/service"
+	"context"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -12,11 +14,15 @@ func Routes(app *fiber.App, db *gorm.DB) {
 	// validate := validation.Validator()
 
 	healthCheckService := service.NewHealthCheckService(db)
-	// emailService := service.NewEmailService()
+	emailService := service.NewEmailService()
+	smsService := service.NewSMSService()
 	// userService := service.NewUserService(db, validate)
 	// tokenService := service.NewTokenService(db, validate, userService)
 	// authService := service.NewAuthService(db, validate, userService, tokenService)
 
+	MetricsRoutes(app, db)
+	ProbeRoutes(app, healthCheckService)
+
 	v1 := app.Group("/v1")
 
 	HealthCheckRoutes(v1, healthCheckService)
@@ -25,7 +31,31 @@ func Routes(app *fiber.App, db *gorm.DB) {
 	
 	// Beauty salon specific routes
 	CustomerRoutes(v1, db)
-	ReservationRoutes(v1, db)
+
+	reservationService := service.NewReservationService(db)
+	waitlistService := service.NewWaitlistService(db, reservationService, emailService, smsService)
+	seriesService := service.NewReservationSeriesService(db, reservationService)
+	portalService := service.NewCustomerPortalService(db, reservationService)
+	customerAuthService := service.NewCustomerAuthService(db, service.NewLoginSender(db, emailService, smsService))
+	calendarService := service.NewCalendarService(db, emailService)
+	reservationService.AddSlotReleaseHandler(waitlistService)
+	reservationService.AddConfirmationHandler(calendarService)
+	if db != nil {
+		go waitlistService.RunOfferLoop(context.Background(), time.Minute)
+		go reservationService.RunNoShowReviewLoop(context.Background(), 5*time.Minute)
+	}
+
+	ReservationRoutes(v1, reservationService)
+	WaitlistRoutes(v1, waitlistService)
+	ReservationSeriesRoutes(v1, seriesService)
+	BlockedTimeRoutes(v1, db)
+	MeRoutes(v1, portalService)
+	CustomerAuthRoutes(v1, customerAuthService)
+	CalendarRoutes(v1, calendarService)
+	ReportRoutes(v1, db)
+	AnalyticsRoutes(v1, db)
+	AuditLogRoutes(v1, db)
+	NotificationLogRoutes(v1, db)
 
 	if !config.IsProd {
 		DocsRoutes(v1)
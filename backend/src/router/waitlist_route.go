@@ -0,0 +1,24 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WaitlistRoutes(api fiber.Router, waitlistService *service.WaitlistService) {
+	waitlistController := controller.NewWaitlistController(waitlistService)
+
+	waitlist := api.Group("/waitlist")
+	waitlist.Get("/", waitlistController.GetWaitlistEntries)
+	waitlist.Get("/:id", waitlistController.GetWaitlistEntry)
+	waitlist.Post("/", waitlistController.CreateWaitlistEntry)
+	waitlist.Delete("/:id", waitlistController.CancelWaitlistEntry)
+	waitlist.Post("/match", middleware.StaffAuth(), waitlistController.MatchWaitlist)
+
+	// The offer link opens a confirm page; only its form POST accepts the slot
+	waitlist.Get("/offers/:token", waitlistController.GetWaitlistOffer)
+	waitlist.Post("/offers/:token/accept", waitlistController.AcceptWaitlistOffer)
+}
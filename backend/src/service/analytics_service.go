@@ -0,0 +1,490 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AnalyticsGranularityDay  = "day"
+	AnalyticsGranularityWeek = "week"
+
+	// maxAnalyticsDays is the longest period a single analysis may cover
+	maxAnalyticsDays = 366
+)
+
+// idleGapBuckets are the idle gap lengths in minutes that split the histogram, each the lower bound of a bucket
+var idleGapBuckets = []int{0, 15, 30, 60, 120}
+
+// AnalyticsService analyses staff utilization, idle time and busy hours
+type AnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsService(db *gorm.DB) *AnalyticsService {
+	return &AnalyticsService{
+		db: db,
+	}
+}
+
+// UtilizationFilter is the utilization analysis query. Dates are YYYY-MM-DD and DateTo is inclusive
+type UtilizationFilter struct {
+	DateFrom    string
+	DateTo      string
+	StaffID     *uuid.UUID
+	Granularity string // day (default) or week (starting Monday)
+}
+
+// UtilizationStat is the available time and the time filled by reservations.
+// Available time is the shift time minus blocked time such as breaks, and booked time is the sum of reservation durations
+type UtilizationStat struct {
+	AvailableMinutes int     `json:"available_minutes"`
+	BookedMinutes    int     `json:"booked_minutes"`
+	Utilization      float64 `json:"utilization"` // booked / available, above 1 when overbooked
+}
+
+// UtilizationPeriod is the utilization of a day or week
+type UtilizationPeriod struct {
+	PeriodStart string `json:"period_start"`
+	UtilizationStat
+}
+
+// IdleGapBucket is the number of idle gaps of a given length
+type IdleGapBucket struct {
+	MinMinutes int  `json:"min_minutes"`
+	MaxMinutes *int `json:"max_minutes"` // exclusive, nil for the last bucket
+	Count      int  `json:"count"`
+	Minutes    int  `json:"minutes"`
+}
+
+// IdleGapSummary summarizes the idle time in shifts with neither reservations nor blocked time.
+// Gaps shorter than the shortest menu cannot take a reservation, so they are counted separately as unsellable time
+type IdleGapSummary struct {
+	Count             int             `json:"count"`
+	TotalMinutes      int             `json:"total_minutes"`
+	LongestMinutes    int             `json:"longest_minutes"`
+	UnsellableCount   int             `json:"unsellable_count"`
+	UnsellableMinutes int             `json:"unsellable_minutes"`
+	Histogram         []IdleGapBucket `json:"histogram"`
+}
+
+// StaffUtilization is the utilization and idle time of a staff member
+type StaffUtilization struct {
+	StaffID   uuid.UUID `json:"staff_id"`
+	StaffName string    `json:"staff_name"`
+	UtilizationStat
+	Periods  []UtilizationPeriod `json:"periods"`
+	IdleGaps IdleGapSummary      `json:"idle_gaps"`
+}
+
+// HeatmapCell is the available and booked time of a weekday (0 = Sunday) and hour, and the number of reservations starting in it
+type HeatmapCell struct {
+	Weekday      int `json:"weekday"`
+	Hour         int `json:"hour"`
+	Reservations int `json:"reservations"`
+	UtilizationStat
+}
+
+// UtilizationReport is the result of the staff utilization analysis
+type UtilizationReport struct {
+	DateFrom        string              `json:"date_from"`
+	DateTo          string              `json:"date_to"`
+	Granularity     string              `json:"granularity"`
+	MinMenuDuration int                 `json:"min_menu_duration"`
+	Overall         UtilizationStat     `json:"overall"`
+	Periods         []UtilizationPeriod `json:"periods"`
+	Staff           []StaffUtilization  `json:"staff"`
+	Heatmap         []HeatmapCell       `json:"heatmap"`
+}
+
+// timeRange is a time range within one day
+type timeRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func (r timeRange) minutes() int {
+	return int(r.end.Sub(r.start) / time.Minute)
+}
+
+// staffDay is the available time ranges and reservations of one staff member on one day
+type staffDay struct {
+	staffID      uuid.UUID
+	date         time.Time
+	shift        *timeRange
+	blocked      []timeRange
+	reservations []model.Reservation
+}
+
+// GetUtilization analyses utilization per staff member and day or week, idle time, and how busy each weekday and hour is.
+// All reservations except cancelled ones (including no-shows) count as using the slot. Without dates it covers the four weeks up to today
+func (s *AnalyticsService) GetUtilization(filter UtilizationFilter) (*UtilizationReport, error) {
+	if filter.Granularity == "" {
+		filter.Granularity = AnalyticsGranularityDay
+	}
+	if filter.Granularity != AnalyticsGranularityDay && filter.Granularity != AnalyticsGranularityWeek {
+		return nil, apperror.Validation("error.invalid_granularity")
+	}
+	from, to, err := analyticsDateRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.loadStaffDays(filter.StaffID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var minMenuDuration int
+	if err := s.db.Model(&model.Menu{}).Where("is_active = ?", true).
+		Select("COALESCE(MIN(duration), 0)").Scan(&minMenuDuration).Error; err != nil {
+		utils.Log.Errorf("Failed to get shortest menu duration: %v", err)
+		return nil, err
+	}
+
+	staffNames := map[uuid.UUID]string{}
+	if len(days) > 0 {
+		var staff []model.Staff
+		ids := make([]uuid.UUID, 0, len(days))
+		for _, day := range days {
+			ids = append(ids, day.staffID)
+		}
+		if err := s.db.Where("id IN ?", ids).Find(&staff).Error; err != nil {
+			utils.Log.Errorf("Failed to get staff for utilization: %v", err)
+			return nil, err
+		}
+		for _, member := range staff {
+			staffNames[member.ID] = member.Name
+		}
+	}
+
+	report := &UtilizationReport{
+		DateFrom:        from.Format("2006-01-02"),
+		DateTo:          to.Format("2006-01-02"),
+		Granularity:     filter.Granularity,
+		MinMenuDuration: minMenuDuration,
+		Periods:         []UtilizationPeriod{},
+		Staff:           []StaffUtilization{},
+		Heatmap:         []HeatmapCell{},
+	}
+
+	overallPeriods := map[string]*UtilizationStat{}
+	staffIndex := map[uuid.UUID]int{}
+	staffPeriods := map[uuid.UUID]map[string]*UtilizationStat{}
+	heatmap := map[[2]int]*HeatmapCell{}
+	heatmapCell := func(t time.Time) *HeatmapCell {
+		key := [2]int{int(t.Weekday()), t.Hour()}
+		if heatmap[key] == nil {
+			heatmap[key] = &HeatmapCell{Weekday: key[0], Hour: key[1]}
+		}
+		return heatmap[key]
+	}
+
+	for _, day := range days {
+		index, ok := staffIndex[day.staffID]
+		if !ok {
+			index = len(report.Staff)
+			staffIndex[day.staffID] = index
+			staffPeriods[day.staffID] = map[string]*UtilizationStat{}
+			report.Staff = append(report.Staff, StaffUtilization{
+				StaffID:   day.staffID,
+				StaffName: staffNames[day.staffID],
+				Periods:   []UtilizationPeriod{},
+				IdleGaps:  newIdleGapSummary(),
+			})
+		}
+		member := &report.Staff[index]
+
+		var available []timeRange
+		if day.shift != nil {
+			available = subtractRanges(*day.shift, day.blocked)
+		}
+		var availableMinutes, bookedMinutes int
+		for _, r := range available {
+			availableMinutes += r.minutes()
+			splitByHour(r, func(hour time.Time, minutes int) {
+				heatmapCell(hour).AvailableMinutes += minutes
+			})
+		}
+
+		busy := append([]timeRange{}, day.blocked...)
+		for _, reservation := range day.reservations {
+			bookedMinutes += reservation.TotalDuration
+			booked := timeRange{reservation.StartTime.In(time.Local), reservation.EndTime.In(time.Local)}
+			busy = append(busy, booked)
+			heatmapCell(booked.start).Reservations++
+			splitByHour(booked, func(hour time.Time, minutes int) {
+				heatmapCell(hour).BookedMinutes += minutes
+			})
+		}
+
+		if day.shift != nil {
+			for _, gap := range subtractRanges(*day.shift, busy) {
+				member.IdleGaps.add(gap.minutes(), minMenuDuration)
+			}
+		}
+
+		period := analyticsPeriodStart(day.date, filter.Granularity)
+		for _, stat := range []*UtilizationStat{
+			&report.Overall,
+			&member.UtilizationStat,
+			periodStat(overallPeriods, period),
+			periodStat(staffPeriods[day.staffID], period),
+		} {
+			stat.AvailableMinutes += availableMinutes
+			stat.BookedMinutes += bookedMinutes
+		}
+	}
+
+	report.Overall.finish()
+	report.Periods = sortedPeriods(overallPeriods)
+	for i := range report.Staff {
+		report.Staff[i].finish()
+		report.Staff[i].Periods = sortedPeriods(staffPeriods[report.Staff[i].StaffID])
+	}
+	for _, cell := range heatmap {
+		cell.finish()
+		report.Heatmap = append(report.Heatmap, *cell)
+	}
+	sort.Slice(report.Heatmap, func(i, j int) bool {
+		if report.Heatmap[i].Weekday != report.Heatmap[j].Weekday {
+			return report.Heatmap[i].Weekday < report.Heatmap[j].Weekday
+		}
+		return report.Heatmap[i].Hour < report.Heatmap[j].Hour
+	})
+
+	return report, nil
+}
+
+// loadStaffDays groups shifts, blocked times and reservations by staff member and date. Reservations on days without a shift still count as booked time
+func (s *AnalyticsService) loadStaffDays(staffID *uuid.UUID, from, to time.Time) ([]*staffDay, error) {
+	end := to.AddDate(0, 0, 1)
+	scope := func(query *gorm.DB) *gorm.DB {
+		if staffID != nil {
+			return query.Where("staff_id = ?", *staffID)
+		}
+		return query
+	}
+
+	var shifts []model.Shift
+	if err := scope(s.db.Where("is_active = ? AND date >= ? AND date < ?", true, from.Format("2006-01-02"), end.Format("2006-01-02"))).
+		Find(&shifts).Error; err != nil {
+		utils.Log.Errorf("Failed to get shifts for utilization: %v", err)
+		return nil, err
+	}
+
+	var reservations []model.Reservation
+	if err := scope(s.db.Select("id", "staff_id", "start_time", "end_time", "total_duration").
+		Where("status <> ? AND start_time >= ? AND start_time < ?", model.ReservationStatusCancelled, from, end)).
+		Find(&reservations).Error; err != nil {
+		utils.Log.Errorf("Failed to get reservations for utilization: %v", err)
+		return nil, err
+	}
+
+	var blockedTimes []model.BlockedTime
+	if err := scope(s.db.Where("is_active = ?", true)).Find(&blockedTimes).Error; err != nil {
+		utils.Log.Errorf("Failed to get blocked times for utilization: %v", err)
+		return nil, err
+	}
+
+	days := map[string]*staffDay{}
+	var ordered []*staffDay
+	dayOf := func(staffID uuid.UUID, date time.Time) *staffDay {
+		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+		key := staffID.String() + date.Format("2006-01-02")
+		if days[key] == nil {
+			days[key] = &staffDay{staffID: staffID, date: date}
+			ordered = append(ordered, days[key])
+		}
+		return days[key]
+	}
+
+	for _, shift := range shifts {
+		day := dayOf(shift.StaffID, shift.Date)
+		day.shift = &timeRange{
+			start: time.Date(day.date.Year(), day.date.Month(), day.date.Day(), shift.StartTime.Hour(), shift.StartTime.Minute(), 0, 0, time.Local),
+			end:   time.Date(day.date.Year(), day.date.Month(), day.date.Day(), shift.EndTime.Hour(), shift.EndTime.Minute(), 0, 0, time.Local),
+		}
+		for _, blockedTime := range blockedTimes {
+			if blockedTime.StaffID != shift.StaffID || !blockedTimeApplies(blockedTime, day.date) {
+				continue
+			}
+			if start, end, ok := blockedRange(blockedTime, day.date); ok {
+				day.blocked = append(day.blocked, timeRange{start, end})
+			}
+		}
+	}
+	for _, reservation := range reservations {
+		day := dayOf(reservation.StaffID, reservation.StartTime.In(time.Local))
+		day.reservations = append(day.reservations, reservation)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].date.Equal(ordered[j].date) {
+			return ordered[i].date.Before(ordered[j].date)
+		}
+		return ordered[i].staffID.String() < ordered[j].staffID.String()
+	})
+	return ordered, nil
+}
+
+// blockedTimeApplies reports whether a blocked time applies to the day, using the same conditions as findBlockedTimes
+func blockedTimeApplies(blockedTime model.BlockedTime, date time.Time) bool {
+	day := date.Format("2006-01-02")
+	if blockedTime.Date != nil {
+		return blockedTime.Date.Format("2006-01-02") == day
+	}
+	if blockedTime.Weekday == nil || *blockedTime.Weekday != int(date.Weekday()) {
+		return false
+	}
+	if blockedTime.ValidFrom != nil && blockedTime.ValidFrom.Format("2006-01-02") > day {
+		return false
+	}
+	if blockedTime.ValidUntil != nil && blockedTime.ValidUntil.Format("2006-01-02") < day {
+		return false
+	}
+	return true
+}
+
+// subtractRanges returns the parts of base that do not overlap cuts
+func subtractRanges(base timeRange, cuts []timeRange) []timeRange {
+	sorted := append([]timeRange{}, cuts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start.Before(sorted[j].start) })
+
+	var result []timeRange
+	cursor := base.start
+	for _, cut := range sorted {
+		if !cut.end.After(cursor) {
+			continue
+		}
+		if !cut.start.Before(base.end) {
+			break
+		}
+		if cut.start.After(cursor) {
+			result = append(result, timeRange{cursor, cut.start})
+		}
+		cursor = cut.end
+	}
+	if cursor.Before(base.end) {
+		result = append(result, timeRange{cursor, base.end})
+	}
+	return result
+}
+
+// splitByHour splits a time range by hour and passes the start and minutes of each hour to fn
+func splitByHour(r timeRange, fn func(hour time.Time, minutes int)) {
+	for cursor := r.start; cursor.Before(r.end); {
+		next := cursor.Truncate(time.Hour).Add(time.Hour)
+		if next.After(r.end) {
+			next = r.end
+		}
+		if minutes := int(next.Sub(cursor) / time.Minute); minutes > 0 {
+			fn(cursor, minutes)
+		}
+		cursor = next
+	}
+}
+
+func newIdleGapSummary() IdleGapSummary {
+	summary := IdleGapSummary{Histogram: make([]IdleGapBucket, len(idleGapBuckets))}
+	for i, lower := range idleGapBuckets {
+		summary.Histogram[i].MinMinutes = lower
+		if i+1 < len(idleGapBuckets) {
+			upper := idleGapBuckets[i+1]
+			summary.Histogram[i].MaxMinutes = &upper
+		}
+	}
+	return summary
+}
+
+func (g *IdleGapSummary) add(minutes, minMenuDuration int) {
+	if minutes <= 0 {
+		return
+	}
+	g.Count++
+	g.TotalMinutes += minutes
+	if minutes > g.LongestMinutes {
+		g.LongestMinutes = minutes
+	}
+	if minutes < minMenuDuration {
+		g.UnsellableCount++
+		g.UnsellableMinutes += minutes
+	}
+	for i := len(g.Histogram) - 1; i >= 0; i-- {
+		if minutes >= g.Histogram[i].MinMinutes {
+			g.Histogram[i].Count++
+			g.Histogram[i].Minutes += minutes
+			break
+		}
+	}
+}
+
+func (u *UtilizationStat) finish() {
+	if u.AvailableMinutes > 0 {
+		u.Utilization = math.Round(float64(u.BookedMinutes)/float64(u.AvailableMinutes)*1000) / 1000
+	}
+}
+
+func periodStat(periods map[string]*UtilizationStat, period string) *UtilizationStat {
+	if periods[period] == nil {
+		periods[period] = &UtilizationStat{}
+	}
+	return periods[period]
+}
+
+func sortedPeriods(periods map[string]*UtilizationStat) []UtilizationPeriod {
+	result := make([]UtilizationPeriod, 0, len(periods))
+	for start, stat := range periods {
+		stat.finish()
+		result = append(result, UtilizationPeriod{PeriodStart: start, UtilizationStat: *stat})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PeriodStart < result[j].PeriodStart })
+	return result
+}
+
+// analyticsPeriodStart moves a date to the first day of its period (Monday for weeks)
+func analyticsPeriodStart(date time.Time, granularity string) string {
+	if granularity == AnalyticsGranularityWeek {
+		offset := (int(date.Weekday()) + 6) % 7
+		date = date.AddDate(0, 0, -offset)
+	}
+	return date.Format("2006-01-02")
+}
+
+// analyticsDateRange returns the dates to analyse, defaulting to the four weeks up to today
+func analyticsDateRange(filter UtilizationFilter) (time.Time, time.Time, error) {
+	today := time.Now()
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	if filter.DateTo != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateTo
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -27)
+	if filter.DateFrom != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateFrom
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, ErrDateRangeReversed
+	}
+	if to.Sub(from) > maxAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, apperror.Validation("error.date_range_too_long").WithParams(i18n.Params{"days": maxAnalyticsDays})
+	}
+	return from, to, nil
+}
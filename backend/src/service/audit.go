@@ -0,0 +1,63 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"encoding/json"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit log actions
+const (
+	AuditActionCreate = "CREATE"
+	AuditActionUpdate = "UPDATE"
+	AuditActionDelete = "DELETE"
+	AuditActionMerge  = "MERGE"
+	AuditActionExport = "EXPORT"
+	AuditActionErase  = "ERASE"
+)
+
+// AuditActor is the actor recorded in audit logs
+type AuditActor struct {
+	UserID    *uuid.UUID
+	IPAddress string
+	UserAgent string
+}
+
+// writeAuditLog writes an audit log with the old and new values as JSON. Pass tx inside a transaction.
+// Audit logs are not encrypted, so pass only IDs, counts and field names and never customers' personal data
+func writeAuditLog(tx *gorm.DB, validate *validator.Validate, table string, recordID uuid.UUID, action string, oldValues, newValues interface{}, actor AuditActor) error {
+	// nil is stored as JSON null, since an empty string is not valid jsonb
+	encodedOld, err := json.Marshal(oldValues)
+	if err != nil {
+		return err
+	}
+	encodedNew, err := json.Marshal(newValues)
+	if err != nil {
+		return err
+	}
+
+	audit := &model.AuditLog{
+		Table:     table,
+		RecordID:  recordID,
+		Action:    action,
+		OldValues: string(encodedOld),
+		NewValues: string(encodedNew),
+		UserID:    actor.UserID,
+		IPAddress: actor.IPAddress,
+		UserAgent: actor.UserAgent,
+	}
+
+	if err := validate.Struct(audit); err != nil {
+		utils.Log.Errorf("Audit log validation failed: %v", err)
+		return err
+	}
+	if err := tx.Create(audit).Error; err != nil {
+		utils.Log.Errorf("Failed to write %s audit log for %s: %v", action, table, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditLogService struct {
+	db *gorm.DB
+}
+
+func NewAuditLogService(db *gorm.DB) *AuditLogService {
+	return &AuditLogService{
+		db: db,
+	}
+}
+
+// AuditLogFilter is the audit log list filter. Empty fields do not filter
+type AuditLogFilter struct {
+	Table    string
+	RecordID *uuid.UUID
+	Action   string
+	UserID   *uuid.UUID
+}
+
+// auditLogSortFields are the fields the audit log list can be sorted by
+var auditLogSortFields = map[string]sortField[model.AuditLog]{
+	"created_at": {
+		columns: []string{"created_at", "id"},
+		values: func(a *model.AuditLog) []interface{} {
+			return []interface{}{a.CreatedAt, a.ID}
+		},
+	},
+}
+
+// GetAuditLogs returns audit logs, newest first by default
+func (s *AuditLogService) GetAuditLogs(options ListOptions, filter AuditLogFilter) ([]model.AuditLog, PageInfo, error) {
+	query := s.db.Model(&model.AuditLog{})
+	if filter.Table != "" {
+		query = query.Where("table_name = ?", filter.Table)
+	}
+	if filter.RecordID != nil {
+		query = query.Where("record_id = ?", *filter.RecordID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+
+	auditLogs, page, err := paginate(query, options, auditLogSortFields, "-created_at")
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCursor) && !errors.Is(err, ErrInvalidSort) {
+			utils.Log.Errorf("Failed to get audit logs: %v", err)
+		}
+		return nil, PageInfo{}, err
+	}
+	return auditLogs, page, nil
+}
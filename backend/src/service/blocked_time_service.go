@@ -0,0 +1,130 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BlockedTimeService struct {
+	db        *gorm.DB
+	validator *validator.Validate
+}
+
+func NewBlockedTimeService(db *gorm.DB) *BlockedTimeService {
+	return &BlockedTimeService{
+		db:        db,
+		validator: validation.Validator(),
+	}
+}
+
+// GetBlockedTimes returns the active blocked times of a staff member. With date, only those that apply on that day are returned
+func (s *BlockedTimeService) GetBlockedTimes(staffID uuid.UUID, date string) ([]model.BlockedTime, error) {
+	if date != "" {
+		parsedDate, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		return findBlockedTimes(s.db, staffID, parsedDate)
+	}
+
+	var blockedTimes []model.BlockedTime
+	if err := s.db.Where("staff_id = ? AND is_active = ?", staffID, true).
+		Order("date ASC, weekday ASC, start_time ASC").
+		Find(&blockedTimes).Error; err != nil {
+		utils.Log.Errorf("Failed to get blocked times: %v", err)
+		return nil, err
+	}
+	return blockedTimes, nil
+}
+
+func (s *BlockedTimeService) CreateBlockedTime(blockedTime *model.BlockedTime) (*model.BlockedTime, error) {
+	if err := s.validator.Struct(blockedTime); err != nil {
+		utils.Log.Errorf("Blocked time validation failed: %v", err)
+		return nil, err
+	}
+
+	if (blockedTime.Date == nil) == (blockedTime.Weekday == nil) {
+		return nil, apperror.Validation("error.blocked_time_date_or_weekday")
+	}
+
+	start, err := time.Parse("15:04:05", blockedTime.StartTime)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	end, err := time.Parse("15:04:05", blockedTime.EndTime)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	if !end.After(start) {
+		return nil, ErrEndBeforeStart
+	}
+
+	var staff model.Staff
+	if err := s.db.Where("id = ? AND is_active = ?", blockedTime.StaffID, true).First(&staff).Error; err != nil {
+		return nil, ErrStaffNotFound
+	}
+
+	blockedTime.IsActive = true
+	if err := s.db.Create(blockedTime).Error; err != nil {
+		utils.Log.Errorf("Failed to create blocked time: %v", err)
+		return nil, err
+	}
+
+	return blockedTime, nil
+}
+
+func (s *BlockedTimeService) DeleteBlockedTime(staffID, id uuid.UUID) error {
+	result := s.db.Model(&model.BlockedTime{}).
+		Where("id = ? AND staff_id = ? AND is_active = ?", id, staffID, true).
+		Update("is_active", false)
+	if result.Error != nil {
+		utils.Log.Errorf("Failed to delete blocked time: %v", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("error.blocked_time_not_found")
+	}
+
+	return nil
+}
+
+// findBlockedTimes returns the one-off and weekly blocked times that apply on the date
+func findBlockedTimes(db *gorm.DB, staffID uuid.UUID, date time.Time) ([]model.BlockedTime, error) {
+	dateStr := date.Format("2006-01-02")
+
+	var blockedTimes []model.BlockedTime
+	if err := db.Where("staff_id = ? AND is_active = ?", staffID, true).
+		Where(db.Where("date = ?", dateStr).
+			Or("weekday = ? AND (valid_from IS NULL OR valid_from <= ?) AND (valid_until IS NULL OR valid_until >= ?)",
+				int(date.Weekday()), dateStr, dateStr)).
+		Order("start_time ASC").
+		Find(&blockedTimes).Error; err != nil {
+		return nil, err
+	}
+	return blockedTimes, nil
+}
+
+// blockedRange turns a blocked time into a time range on the same date as day
+func blockedRange(blockedTime model.BlockedTime, day time.Time) (time.Time, time.Time, bool) {
+	start, err := time.Parse("15:04:05", blockedTime.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := time.Parse("15:04:05", blockedTime.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	y, m, d := day.Date()
+	return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, day.Location()),
+		time.Date(y, m, d, end.Hour(), end.Minute(), end.Second(), 0, day.Location()),
+		true
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// calendarFeedPastDays and calendarFeedFutureDays are the period included in calendar feeds
+	calendarFeedPastDays   = 30
+	calendarFeedFutureDays = 180
+
+	calendarContentType = "text/calendar; charset=utf-8"
+)
+
+// CalendarService serves the iCalendar feeds of staff and customers and the events attached to confirmation emails
+type CalendarService struct {
+	db           *gorm.DB
+	validator    *validator.Validate
+	emailService EmailService
+}
+
+func NewCalendarService(db *gorm.DB, emailService EmailService) *CalendarService {
+	return &CalendarService{
+		db:           db,
+		validator:    validation.Validator(),
+		emailService: emailService,
+	}
+}
+
+// CalendarFeedURL builds the URL added to calendar apps from a feed token
+func CalendarFeedURL(token string) string {
+	return fmt.Sprintf("%s/v1/calendar/%s.ics", config.AppURL, token)
+}
+
+// IssueFeed issues a feed token for a staff member or customer. An existing feed is replaced and its old URL stops working
+func (s *CalendarService) IssueFeed(ownerType model.CalendarFeedOwner, ownerID uuid.UUID) (string, error) {
+	if err := s.checkFeedOwner(ownerType, ownerID); err != nil {
+		return "", err
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	feed := &model.CalendarFeed{
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+		TokenHash: hashSecret(token),
+	}
+	if err := s.validator.Struct(feed); err != nil {
+		return "", err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(&model.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(feed).Error
+	}); err != nil {
+		utils.Log.Errorf("Failed to issue calendar feed: %v", err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RevokeFeed deletes the feed token so the feed URL stops working
+func (s *CalendarService) RevokeFeed(ownerType model.CalendarFeedOwner, ownerID uuid.UUID) error {
+	result := s.db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(&model.CalendarFeed{})
+	if result.Error != nil {
+		utils.Log.Errorf("Failed to revoke calendar feed: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// FeedCalendar returns the calendar of a feed token in iCalendar format.
+// Cancelled reservations and inactive shifts are included as STATUS:CANCELLED events so subscribers remove them
+func (s *CalendarService) FeedCalendar(token string) (string, error) {
+	var feed model.CalendarFeed
+	if err := s.db.Where("token_hash = ?", hashSecret(token)).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrCalendarFeedNotFound
+		}
+		return "", err
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -calendarFeedPastDays)
+	to := now.AddDate(0, 0, calendarFeedFutureDays)
+
+	var name string
+	var events []utils.CalendarEvent
+	var err error
+	switch feed.OwnerType {
+	case model.CalendarFeedOwnerStaff:
+		name, events, err = s.staffEvents(feed.OwnerID, from, to)
+	case model.CalendarFeedOwnerCustomer:
+		name, events, err = s.customerEvents(feed.OwnerID, from, to)
+	default:
+		return "", ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.db.Model(&feed).UpdateColumn("last_accessed_at", now).Error; err != nil {
+		utils.Log.Errorf("Failed to record calendar feed access: %v", err)
+	}
+
+	return utils.BuildCalendar(name, events), nil
+}
+
+// OnReservationConfirmed is called when a reservation is confirmed and emails the customer a confirmation with the event attached as .ics.
+// Customers not notified by email get nothing. It sends asynchronously so the booking request does not wait
+func (s *CalendarService) OnReservationConfirmed(reservation *model.Reservation) {
+	recipient, ok := s.confirmationRecipient(reservation.Customer)
+	if !ok {
+		return
+	}
+
+	confirmed := *reservation
+	go s.sendConfirmation(&confirmed, recipient)
+}
+
+// OnSeriesConfirmed sends one confirmation for a recurring series, listing every date and
+// attaching all occurrences in a single .ics, instead of one email per occurrence
+func (s *CalendarService) OnSeriesConfirmed(reservations []*model.Reservation) {
+	if len(reservations) == 0 {
+		return
+	}
+	recipient, ok := s.confirmationRecipient(reservations[0].Customer)
+	if !ok {
+		return
+	}
+
+	confirmed := make([]*model.Reservation, len(reservations))
+	for i, reservation := range reservations {
+		copied := *reservation
+		confirmed[i] = &copied
+	}
+	go s.sendSeriesConfirmation(confirmed, recipient)
+}
+
+func (s *CalendarService) confirmationRecipient(customer model.Customer) (string, bool) {
+	if customer.ErasedAt != nil {
+		return "", false
+	}
+	notificationType, recipient := notificationChannel(s.db, customer)
+	if notificationType != "email" || recipient == "" {
+		return "", false
+	}
+	return recipient, true
+}
+
+func (s *CalendarService) sendConfirmation(reservation *model.Reservation, recipient string) {
+	locale := reservation.Customer.Locale
+	message := i18n.T(locale, "notification.reservation_confirmed.body", i18n.Params{
+		"name":       reservation.Customer.Name,
+		"date":       reservation.ReservationDate.Format("2006-01-02"),
+		"start_time": reservation.StartTime.Format("15:04"),
+		"end_time":   reservation.EndTime.Format("15:04"),
+		"menus":      reservationMenuSummary(reservation, locale),
+		"staff":      reservation.Staff.Name,
+		"price":      reservation.TotalPrice,
+	})
+
+	s.sendInvite(reservation.CustomerID, recipient, i18n.T(locale, "notification.reservation_confirmed.subject", nil),
+		"notification.reservation_confirmed.body", message, []utils.CalendarEvent{customerReservationEvent(reservation, locale)})
+}
+
+func (s *CalendarService) sendSeriesConfirmation(reservations []*model.Reservation, recipient string) {
+	first := reservations[0]
+	locale := first.Customer.Locale
+
+	dates := make([]string, 0, len(reservations))
+	events := make([]utils.CalendarEvent, 0, len(reservations))
+	for _, reservation := range reservations {
+		dates = append(dates, fmt.Sprintf("%s %s-%s", reservation.ReservationDate.Format("2006-01-02"),
+			reservation.StartTime.Format("15:04"), reservation.EndTime.Format("15:04")))
+		events = append(events, customerReservationEvent(reservation, locale))
+	}
+
+	message := i18n.T(locale, "notification.series_confirmed.body", i18n.Params{
+		"name":  first.Customer.Name,
+		"count": len(reservations),
+		"dates": strings.Join(dates, "\n"),
+		"menus": reservationMenuSummary(first, locale),
+		"staff": first.Staff.Name,
+		"price": first.TotalPrice,
+	})
+
+	s.sendInvite(first.CustomerID, recipient, i18n.T(locale, "notification.series_confirmed.subject", nil),
+		"notification.series_confirmed.body", message, events)
+}
+
+// sendInvite sends the confirmation email with the events attached as reservation.ics and logs it
+func (s *CalendarService) sendInvite(customerID uuid.UUID, recipient, subject, messageKey, message string, events []utils.CalendarEvent) {
+	invite := utils.BuildCalendar("", events)
+
+	now := time.Now()
+	notification := &model.NotificationLog{
+		CustomerID: &customerID,
+		Type:       "email",
+		Recipient:  recipient,
+		Subject:    subject,
+		Message:    messageKey,
+		Status:     model.NotificationStatusSent,
+		SentAt:     &now,
+	}
+	if err := s.emailService.SendEmailWithAttachments(recipient, subject, message, EmailAttachment{
+		Filename:    "reservation.ics",
+		ContentType: calendarContentType + "; method=PUBLISH",
+		Content:     []byte(invite),
+	}); err != nil {
+		notification.Status = model.NotificationStatusFailed
+		notification.ErrorMessage = err.Error()
+		notification.SentAt = nil
+	}
+
+	if err := s.db.Create(notification).Error; err != nil {
+		utils.Log.Errorf("Failed to log reservation confirmation: %v", err)
+	}
+}
+
+func (s *CalendarService) checkFeedOwner(ownerType model.CalendarFeedOwner, ownerID uuid.UUID) error {
+	switch ownerType {
+	case model.CalendarFeedOwnerStaff:
+		if err := s.db.Where("id = ? AND is_active = ?", ownerID, true).First(&model.Staff{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStaffNotFound
+			}
+			return err
+		}
+	case model.CalendarFeedOwnerCustomer:
+		if err := s.db.Where("id = ? AND erased_at IS NULL", ownerID).First(&model.Customer{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCustomerNotFound
+			}
+			return err
+		}
+	default:
+		return errors.New("invalid calendar feed owner")
+	}
+	return nil
+}
+
+// staffEvents turns the reservations and shifts of a staff member into events. Shifts combine their date and times.
+// Staff have no language setting, so the feed uses the salon default
+func (s *CalendarService) staffEvents(staffID uuid.UUID, from, to time.Time) (string, []utils.CalendarEvent, error) {
+	var staff model.Staff
+	if err := s.db.Where("id = ?", staffID).First(&staff).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrCalendarFeedNotFound
+		}
+		return "", nil, err
+	}
+
+	var reservations []model.Reservation
+	if err := s.db.Preload("Customer").Preload("ReservationMenus.Menu").Preload("ReservationOptions.Option").
+		Where("staff_id = ? AND start_time >= ? AND start_time < ?", staffID, from, to).
+		Order("start_time ASC").Find(&reservations).Error; err != nil {
+		utils.Log.Errorf("Failed to get reservations for calendar feed: %v", err)
+		return "", nil, err
+	}
+
+	var shifts []model.Shift
+	if err := s.db.Where("staff_id = ? AND date >= ? AND date < ?", staffID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC").Find(&shifts).Error; err != nil {
+		utils.Log.Errorf("Failed to get shifts for calendar feed: %v", err)
+		return "", nil, err
+	}
+
+	locale := i18n.Default()
+	events := make([]utils.CalendarEvent, 0, len(reservations)+len(shifts))
+	for i := range reservations {
+		reservation := &reservations[i]
+		event := reservationEvent(reservation, locale)
+		event.Summary = i18n.T(locale, "calendar.staff_event_summary", i18n.Params{
+			"menus":    reservationMenuSummary(reservation, locale),
+			"customer": reservation.Customer.Name,
+		})
+		events = append(events, event)
+	}
+	for _, shift := range shifts {
+		date := shift.Date
+		events = append(events, utils.CalendarEvent{
+			UID:          fmt.Sprintf("shift-%s", shift.ID),
+			Summary:      i18n.T(locale, "calendar.shift", nil),
+			Start:        time.Date(date.Year(), date.Month(), date.Day(), shift.StartTime.Hour(), shift.StartTime.Minute(), 0, 0, time.Local),
+			End:          time.Date(date.Year(), date.Month(), date.Day(), shift.EndTime.Hour(), shift.EndTime.Minute(), 0, 0, time.Local),
+			Cancelled:    !shift.IsActive,
+			Sequence:     calendarSequence(shift.CreatedAt, shift.UpdatedAt),
+			LastModified: shift.UpdatedAt,
+		})
+	}
+
+	return i18n.T(locale, "calendar.staff_feed_name", i18n.Params{"staff": staff.Name}), events, nil
+}
+
+// customerEvents turns the reservations of a customer into events in the customer's language
+func (s *CalendarService) customerEvents(customerID uuid.UUID, from, to time.Time) (string, []utils.CalendarEvent, error) {
+	var customer model.Customer
+	if err := s.db.Where("id = ?", customerID).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrCalendarFeedNotFound
+		}
+		return "", nil, err
+	}
+	locale := customer.Locale
+
+	var reservations []model.Reservation
+	if err := s.db.Preload("Staff").Preload("ReservationMenus.Menu").Preload("ReservationOptions.Option").
+		Where("customer_id = ? AND start_time >= ? AND start_time < ?", customerID, from, to).
+		Order("start_time ASC").Find(&reservations).Error; err != nil {
+		utils.Log.Errorf("Failed to get reservations for calendar feed: %v", err)
+		return "", nil, err
+	}
+
+	events := make([]utils.CalendarEvent, 0, len(reservations))
+	for i := range reservations {
+		events = append(events, customerReservationEvent(&reservations[i], locale))
+	}
+	return i18n.T(locale, "calendar.customer_feed_name", nil), events, nil
+}
+
+// reservationEvent turns a reservation into an event. Callers set the summary for whoever views it
+func reservationEvent(reservation *model.Reservation, locale string) utils.CalendarEvent {
+	description := []string{i18n.T(locale, "calendar.menus", i18n.Params{"menus": reservationMenuSummary(reservation, locale)})}
+	var options []string
+	for _, ro := range reservation.ReservationOptions {
+		options = append(options, ro.Option.Name)
+	}
+	if len(options) > 0 {
+		description = append(description, i18n.T(locale, "calendar.options", i18n.Params{"options": strings.Join(options, "・")}))
+	}
+	description = append(description, i18n.T(locale, "calendar.price", i18n.Params{"price": reservation.TotalPrice}))
+
+	return utils.CalendarEvent{
+		UID:          fmt.Sprintf("reservation-%s", reservation.ID),
+		Summary:      reservationMenuSummary(reservation, locale),
+		Description:  strings.Join(description, "\n"),
+		Start:        reservation.StartTime,
+		End:          reservation.EndTime,
+		Cancelled:    reservation.Status == model.ReservationStatusCancelled,
+		Sequence:     calendarSequence(reservation.CreatedAt, reservation.UpdatedAt),
+		LastModified: reservation.UpdatedAt,
+	}
+}
+
+// customerReservationEvent is the event for customers, with the staff member's name in the summary
+func customerReservationEvent(reservation *model.Reservation, locale string) utils.CalendarEvent {
+	event := reservationEvent(reservation, locale)
+	if reservation.Staff.Name != "" {
+		event.Summary = i18n.T(locale, "calendar.customer_event_summary", i18n.Params{
+			"menus": reservationMenuSummary(reservation, locale),
+			"staff": reservation.Staff.Name,
+		})
+	}
+	return event
+}
+
+func reservationMenuSummary(reservation *model.Reservation, locale string) string {
+	var names []string
+	for _, rm := range reservation.ReservationMenus {
+		names = append(names, rm.Menu.Name)
+	}
+	if len(names) == 0 {
+		return i18n.T(locale, "calendar.reservation", nil)
+	}
+	return strings.Join(names, "・")
+}
+
+// calendarSequence uses the seconds since creation as SEQUENCE in place of an update counter.
+// It grows with every update, so calendar apps replace the event with the new content
+func calendarSequence(createdAt, updatedAt time.Time) int {
+	if updatedAt.Before(createdAt) {
+		return 0
+	}
+	return int(updatedAt.Sub(createdAt) / time.Second)
+}
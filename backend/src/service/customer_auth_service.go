@@ -0,0 +1,317 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustomerTokens are the access and refresh tokens of the customer role
+type CustomerTokens struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// CustomerAuthService handles passwordless customer login (email magic links and SMS one-time codes)
+type CustomerAuthService struct {
+	db        *gorm.DB
+	validator *validator.Validate
+	sender    LoginSender
+}
+
+func NewCustomerAuthService(db *gorm.DB, sender LoginSender) *CustomerAuthService {
+	return &CustomerAuthService{
+		db:        db,
+		validator: validation.Validator(),
+		sender:    sender,
+	}
+}
+
+// RequestLogin issues and sends a one-time code (and a magic link for email) and returns when it expires.
+// Unknown destinations get the same response so it does not reveal who is registered. The message is sent in locale
+func (s *CustomerAuthService) RequestLogin(channel, destination, locale string) (*time.Time, error) {
+	loginChannel, normalized, err := s.normalizeDestination(channel, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent int64
+	if err := s.db.Model(&model.LoginChallenge{}).
+		Where("channel = ? AND destination = ? AND created_at > ?", loginChannel, normalized, time.Now().Add(-time.Hour)).
+		Count(&recent).Error; err != nil {
+		utils.Log.Errorf("Failed to count login challenges: %v", err)
+		return nil, err
+	}
+	if recent >= int64(config.LoginCodeMaxRequests) {
+		return nil, apperror.TooManyRequests("error.too_many_login_requests")
+	}
+
+	code, err := generateLoginCode()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &model.LoginChallenge{
+		Channel:     loginChannel,
+		Destination: normalized,
+		CodeHash:    hashSecret(code),
+		ExpiresAt:   time.Now().Add(time.Duration(config.LoginCodeExp) * time.Minute),
+		Locale:      locale,
+	}
+
+	magicLink := ""
+	if loginChannel == model.LoginChannelEmail {
+		linkToken, err := generateRandomToken()
+		if err != nil {
+			return nil, err
+		}
+		challenge.LinkHash = hashSecret(linkToken)
+		magicLink = fmt.Sprintf("%s/v1/auth/customer/magic/%s", strings.TrimRight(config.AppURL, "/"), linkToken)
+	}
+
+	if err := s.validator.Struct(challenge); err != nil {
+		utils.Log.Errorf("Login challenge validation failed: %v", err)
+		return nil, err
+	}
+
+	// Only the latest code for a destination can be used
+	now := time.Now()
+	if err := s.db.Model(&model.LoginChallenge{}).
+		Where("channel = ? AND destination = ? AND consumed_at IS NULL", loginChannel, normalized).
+		Update("consumed_at", now).Error; err != nil {
+		utils.Log.Errorf("Failed to invalidate previous login challenges: %v", err)
+		return nil, err
+	}
+
+	if err := s.db.Create(challenge).Error; err != nil {
+		utils.Log.Errorf("Failed to create login challenge: %v", err)
+		return nil, err
+	}
+
+	if err := s.sender.SendLoginCode(loginChannel, normalized, code, magicLink, locale); err != nil {
+		utils.Log.Errorf("Failed to send login code: %v", err)
+		return nil, errors.New("failed to send login code")
+	}
+
+	return &challenge.ExpiresAt, nil
+}
+
+// VerifyCode checks a one-time code and logs the customer in.
+// Unknown destinations create a customer and return isNew as true
+func (s *CustomerAuthService) VerifyCode(channel, destination, code, name string) (*model.Customer, *CustomerTokens, bool, error) {
+	loginChannel, normalized, err := s.normalizeDestination(channel, destination)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var challenge model.LoginChallenge
+	if err := s.db.Where("channel = ? AND destination = ? AND consumed_at IS NULL AND expires_at > ?", loginChannel, normalized, time.Now()).
+		Order("created_at DESC").
+		First(&challenge).Error; err != nil {
+		return nil, nil, false, apperror.Unauthorized("error.invalid_login_code")
+	}
+
+	// Count the attempt before comparing, so concurrent guesses cannot all pass the limit
+	result := s.db.Model(&model.LoginChallenge{}).
+		Where("id = ? AND attempts < ? AND consumed_at IS NULL", challenge.ID, config.LoginCodeMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		utils.Log.Errorf("Failed to record login attempt: %v", result.Error)
+		return nil, nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, false, apperror.Unauthorized("error.invalid_login_code")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(code)), []byte(challenge.CodeHash)) != 1 {
+		return nil, nil, false, apperror.Unauthorized("error.invalid_login_code")
+	}
+
+	return s.completeLogin(&challenge, name)
+}
+
+// CheckMagicLink checks that a magic link token can be used and returns when it expires.
+// Opening the link alone must not log in, so the challenge is not marked as used
+func (s *CustomerAuthService) CheckMagicLink(token string) (*time.Time, error) {
+	challenge, err := s.findMagicLinkChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+	return &challenge.ExpiresAt, nil
+}
+
+// VerifyMagicLink checks an email magic link token and logs the customer in
+func (s *CustomerAuthService) VerifyMagicLink(token string) (*model.Customer, *CustomerTokens, bool, error) {
+	challenge, err := s.findMagicLinkChallenge(token)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return s.completeLogin(challenge, "")
+}
+
+func (s *CustomerAuthService) findMagicLinkChallenge(token string) (*model.LoginChallenge, error) {
+	var challenge model.LoginChallenge
+	if err := s.db.Where("link_hash = ? AND consumed_at IS NULL AND expires_at > ?", hashSecret(token), time.Now()).
+		First(&challenge).Error; err != nil {
+		return nil, apperror.Unauthorized("error.invalid_login_link")
+	}
+	return &challenge, nil
+}
+
+// RefreshTokens issues new tokens from a refresh token
+func (s *CustomerAuthService) RefreshTokens(refreshToken string) (*CustomerTokens, error) {
+	sub, _, err := utils.VerifyTokenRole(refreshToken, config.JWTSecret, config.TokenTypeRefresh, config.RoleCustomer)
+	if err != nil {
+		return nil, apperror.Unauthorized("error.invalid_refresh_token")
+	}
+
+	customerID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, apperror.Unauthorized("error.invalid_refresh_token")
+	}
+
+	var customer model.Customer
+	if err := s.db.Where("id = ? AND is_active = ?", customerID, true).First(&customer).Error; err != nil {
+		return nil, apperror.Unauthorized("error.invalid_refresh_token")
+	}
+
+	return issueCustomerTokens(customer.ID)
+}
+
+// completeLogin marks the challenge as used and issues tokens for the customer of the destination, creating one if needed
+func (s *CustomerAuthService) completeLogin(challenge *model.LoginChallenge, name string) (*model.Customer, *CustomerTokens, bool, error) {
+	// Guard against the same code being used twice concurrently
+	result := s.db.Model(&model.LoginChallenge{}).
+		Where("id = ? AND consumed_at IS NULL", challenge.ID).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		utils.Log.Errorf("Failed to consume login challenge: %v", result.Error)
+		return nil, nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, false, apperror.Unauthorized("error.invalid_login_code")
+	}
+
+	query := s.db.Where("email_index = ?", model.CustomerEmailIndex(challenge.Destination))
+	if challenge.Channel == model.LoginChannelSMS {
+		query = s.db.Where("phone_index = ?", model.CustomerPhoneIndex(challenge.Destination))
+	}
+
+	isNew := false
+	var customer model.Customer
+	if err := query.First(&customer).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.Log.Errorf("Failed to find customer for login: %v", err)
+			return nil, nil, false, err
+		}
+
+		// A customer who signs up gets the language the login was requested in
+		customer = model.Customer{Name: strings.TrimSpace(name), Locale: challenge.Locale, IsActive: true}
+		if customer.Name == "" {
+			customer.Name = i18n.T(challenge.Locale, "customer.default_name", nil)
+		}
+		if challenge.Channel == model.LoginChannelSMS {
+			customer.Phone = challenge.Destination
+		} else {
+			customer.Email = challenge.Destination
+		}
+
+		if err := s.validator.Struct(&customer); err != nil {
+			utils.Log.Errorf("Customer validation failed: %v", err)
+			return nil, nil, false, err
+		}
+		if err := s.db.Create(&customer).Error; err != nil {
+			utils.Log.Errorf("Failed to create customer on first login: %v", err)
+			return nil, nil, false, err
+		}
+		isNew = true
+	} else if !customer.IsActive {
+		return nil, nil, false, apperror.Forbidden("error.customer_deactivated")
+	}
+
+	if err := s.db.Model(challenge).Update("customer_id", customer.ID).Error; err != nil {
+		utils.Log.Errorf("Failed to link login challenge to customer: %v", err)
+	}
+
+	tokens, err := issueCustomerTokens(customer.ID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return &customer, tokens, isNew, nil
+}
+
+// normalizeDestination lowercases email addresses and formats phone numbers as E.164
+func (s *CustomerAuthService) normalizeDestination(channel, destination string) (model.LoginChannel, string, error) {
+	destination = strings.TrimSpace(destination)
+
+	switch model.LoginChannel(channel) {
+	case model.LoginChannelEmail:
+		normalized := strings.ToLower(destination)
+		if err := s.validator.Var(normalized, "required,email,max=255"); err != nil {
+			return "", "", apperror.Validation("error.invalid_email")
+		}
+		return model.LoginChannelEmail, normalized, nil
+	case model.LoginChannelSMS:
+		phone, err := validation.ParsePhone(destination)
+		if err != nil {
+			return "", "", apperror.Validation("error.invalid_phone")
+		}
+		return model.LoginChannelSMS, phone.E164, nil
+	default:
+		return "", "", apperror.Validation("error.invalid_login_channel")
+	}
+}
+
+func issueCustomerTokens(customerID uuid.UUID) (*CustomerTokens, error) {
+	accessExpiresAt := time.Now().Add(time.Duration(config.JWTAccessExp) * time.Minute)
+	accessToken, err := utils.GenerateRoleToken(customerID.String(), config.RoleCustomer, config.TokenTypeAccess, config.JWTSecret, accessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().AddDate(0, 0, config.JWTRefreshExp)
+	refreshToken, err := utils.GenerateRoleToken(customerID.String(), config.RoleCustomer, config.TokenTypeRefresh, config.JWTSecret, refreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CustomerTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// generateLoginCode generates a six digit one-time code
+func generateLoginCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,559 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+	"gorm.io/gorm"
+)
+
+const (
+	// CSV encodings. auto treats files that are not valid UTF-8 as Shift_JIS
+	CSVEncodingAuto     = "auto"
+	CSVEncodingUTF8     = "utf-8"
+	CSVEncodingShiftJIS = "shift_jis"
+
+	// How to handle rows whose phone number matches an existing customer
+	CustomerImportSkip   = "skip"   // leave the existing customer unchanged
+	CustomerImportUpdate = "update" // overwrite with the values present in the CSV
+	CustomerImportMerge  = "merge"  // only fill the empty fields of the existing customer
+
+	// Import result of each row
+	CustomerImportActionCreate = "create"
+	CustomerImportActionUpdate = "update"
+	CustomerImportActionMerge  = "merge"
+	CustomerImportActionSkip   = "skip"
+	CustomerImportActionError  = "error"
+
+	maxCustomerImportRows = 5000
+	customerExportBatch   = 500
+)
+
+// customerCSVColumns are the fields that can be imported and exported, with the headers looked for when no mapping is given.
+// Exports use the first header, so an exported CSV can be imported as is
+var customerCSVColumns = []struct {
+	field   string
+	headers []string
+}{
+	{"name", []string{"氏名", "名前", "お名前", "name"}},
+	{"name_kana", []string{"フリガナ", "カナ", "ふりがな", "name_kana"}},
+	{"phone", []string{"電話番号", "電話", "携帯電話", "tel", "phone"}},
+	{"email", []string{"メールアドレス", "メール", "email"}},
+	{"birthday", []string{"生年月日", "誕生日", "birthday"}},
+	{"gender", []string{"性別", "gender"}},
+	{"notes", []string{"備考", "メモ", "notes"}},
+}
+
+// customerGenderLabels are the gender values and their CSV labels
+var customerGenderLabels = map[string]string{
+	"male":   "男性",
+	"female": "女性",
+	"other":  "その他",
+}
+
+// CustomerImportOptions are the options of a customer CSV import
+type CustomerImportOptions struct {
+	Encoding  string            // auto, utf-8 or shift_jis
+	Mapping   map[string]string // maps field names (name, phone and so on) to CSV headers. Fields left out use the default headers
+	Duplicate string            // skip, update or merge
+	DryRun    bool              // validate only, without saving
+	Locale    string            // language of the row errors
+}
+
+// CustomerImportRow is the import result of one row. Row is the CSV line number, counting the header as line 1
+type CustomerImportRow struct {
+	Row        int        `json:"row"`
+	Action     string     `json:"action"`
+	CustomerID *uuid.UUID `json:"customer_id,omitempty"`
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+// CustomerImportReport is the result of a customer CSV import. With DryRun it is the result saving would have had
+type CustomerImportReport struct {
+	DryRun   bool                `json:"dry_run"`
+	Encoding string              `json:"encoding"`
+	Columns  map[string]string   `json:"columns"`
+	Total    int                 `json:"total"`
+	Created  int                 `json:"created"`
+	Updated  int                 `json:"updated"`
+	Merged   int                 `json:"merged"`
+	Skipped  int                 `json:"skipped"`
+	Failed   int                 `json:"failed"`
+	Rows     []CustomerImportRow `json:"rows"`
+}
+
+// CustomerExportFilter selects the customers to export as CSV
+type CustomerExportFilter struct {
+	Status      string // active (default), inactive or all
+	Gender      string
+	CreatedFrom string // YYYY-MM-DD
+	CreatedTo   string // YYYY-MM-DD
+	Encoding    string // utf-8 (default, with BOM) or shift_jis
+}
+
+// ImportCustomersCSV bulk registers customers moved from paper records or other systems from a CSV.
+// Rows whose phone number matches an existing customer follow Duplicate. Valid rows are saved and invalid rows are reported with the reason
+func (s *CustomerService) ImportCustomersCSV(data []byte, options CustomerImportOptions) (*CustomerImportReport, error) {
+	if options.Duplicate == "" {
+		options.Duplicate = CustomerImportSkip
+	}
+	switch options.Duplicate {
+	case CustomerImportSkip, CustomerImportUpdate, CustomerImportMerge:
+	default:
+		return nil, apperror.Validation("error.invalid_duplicate_handling")
+	}
+
+	text, detected, err := decodeCSV(data, options.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	records, lines, err := readCSVRecords(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, apperror.Validation("error.csv_empty")
+	}
+	if len(records)-1 > maxCustomerImportRows {
+		return nil, apperror.Validation("error.too_many_rows").WithParams(i18n.Params{"max": maxCustomerImportRows})
+	}
+
+	columns, headers, err := resolveCustomerColumns(records[0], options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CustomerImportReport{
+		DryRun:   options.DryRun,
+		Encoding: detected,
+		Columns:  headers,
+		Rows:     []CustomerImportRow{},
+	}
+
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		row := CustomerImportRow{Row: lines[i+1]}
+		report.Total++
+
+		customer, problems := s.parseCustomerRecord(record, columns, options.Locale)
+		if len(problems) == 0 {
+			// Each row gets its own savepoint, so that a failing row does not abort the others
+			if err := tx.SavePoint("customer_import_row").Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := s.importCustomer(tx, customer, options.Duplicate, &row); err != nil {
+				tx.RollbackTo("customer_import_row")
+				problems = append(problems, errorMessage(err, options.Locale))
+			}
+		}
+
+		if len(problems) > 0 {
+			row.Action = CustomerImportActionError
+			row.Errors = problems
+			row.CustomerID = nil
+		}
+		switch row.Action {
+		case CustomerImportActionCreate:
+			report.Created++
+		case CustomerImportActionUpdate:
+			report.Updated++
+		case CustomerImportActionMerge:
+			report.Merged++
+		case CustomerImportActionSkip:
+			report.Skipped++
+		case CustomerImportActionError:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if options.DryRun {
+		tx.Rollback()
+		return report, nil
+	}
+	if err := tx.Commit().Error; err != nil {
+		utils.Log.Errorf("Failed to commit customer import: %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// importCustomer saves the customer of one row. When a customer with the same phone number exists it follows duplicate
+func (s *CustomerService) importCustomer(tx *gorm.DB, customer *model.Customer, duplicate string, row *CustomerImportRow) error {
+	var existing model.Customer
+	err := tx.Where("phone_index = ?", model.CustomerPhoneIndex(customer.Phone)).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if customer.Email != "" {
+			var count int64
+			if err := tx.Model(&model.Customer{}).Where("email_index = ?", model.CustomerEmailIndex(customer.Email)).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrEmailExists
+			}
+		}
+		if err := tx.Create(customer).Error; err != nil {
+			utils.Log.Errorf("Failed to import customer: %v", err)
+			return err
+		}
+		row.Action = CustomerImportActionCreate
+		row.CustomerID = &customer.ID
+		return nil
+	}
+
+	row.CustomerID = &existing.ID
+	if duplicate == CustomerImportSkip {
+		row.Action = CustomerImportActionSkip
+		return nil
+	}
+
+	overwrite := duplicate == CustomerImportUpdate
+	fill := func(current *string, value string) {
+		if value != "" && (overwrite || *current == "") {
+			*current = value
+		}
+	}
+	fill(&existing.Name, customer.Name)
+	fill(&existing.NameKana, customer.NameKana)
+	fill(&existing.Email, customer.Email)
+	fill(&existing.Gender, customer.Gender)
+	if customer.Birthday != nil && (overwrite || existing.Birthday == nil) {
+		existing.Birthday = customer.Birthday
+	}
+	// Notes are kept when merging, the imported memo is added below them
+	if overwrite {
+		fill(&existing.Notes, customer.Notes)
+	} else if customer.Notes != "" && !strings.Contains(existing.Notes, customer.Notes) {
+		existing.Notes = strings.TrimSpace(existing.Notes + "\n" + customer.Notes)
+	}
+
+	if existing.Email != "" {
+		var count int64
+		if err := tx.Model(&model.Customer{}).Where("email_index = ? AND id != ?", model.CustomerEmailIndex(existing.Email), existing.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailExists
+		}
+	}
+	if err := s.validator.Struct(&existing); err != nil {
+		return err
+	}
+	if err := tx.Save(&existing).Error; err != nil {
+		utils.Log.Errorf("Failed to update imported customer: %v", err)
+		return err
+	}
+
+	if overwrite {
+		row.Action = CustomerImportActionUpdate
+	} else {
+		row.Action = CustomerImportActionMerge
+	}
+	return nil
+}
+
+// parseCustomerRecord turns a CSV row into a customer, collecting every invalid value
+func (s *CustomerService) parseCustomerRecord(record []string, columns map[string]int, locale string) (*model.Customer, []string) {
+	value := func(field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	var problems []string
+	customer := &model.Customer{
+		Name:     value("name"),
+		NameKana: value("name_kana"),
+		Phone:    value("phone"),
+		Email:    value("email"),
+		Notes:    value("notes"),
+		IsActive: true,
+	}
+
+	if customer.Phone == "" {
+		problems = append(problems, i18n.T(locale, "error.phone_required", nil))
+	}
+
+	if birthday := value("birthday"); birthday != "" {
+		parsed, err := parseCSVDate(birthday)
+		if err != nil {
+			problems = append(problems, i18n.T(locale, "error.invalid_birthday", i18n.Params{"value": birthday}))
+		} else {
+			customer.Birthday = &parsed
+		}
+	}
+
+	if gender := value("gender"); gender != "" {
+		customer.Gender = strings.ToLower(gender)
+		for code, label := range customerGenderLabels {
+			if gender == label {
+				customer.Gender = code
+			}
+		}
+	}
+
+	if err := s.validator.Struct(customer); err != nil {
+		var messages []string
+		for _, message := range validation.CustomErrorMessages(err, locale) {
+			messages = append(messages, message)
+		}
+		sort.Strings(messages)
+		problems = append(problems, messages...)
+	}
+
+	return customer, problems
+}
+
+// ExportCustomersCSV returns a function that writes the matching customers as CSV.
+// Filter errors are returned before writing starts, and customers are read in batches however many there are
+func (s *CustomerService) ExportCustomersCSV(filter CustomerExportFilter) (func(w io.Writer) error, error) {
+	query := s.db.Model(&model.Customer{}).Where("erased_at IS NULL")
+
+	switch filter.Status {
+	case "", "active":
+		query = query.Where("is_active = ?", true)
+	case "inactive":
+		query = query.Where("is_active = ?", false)
+	case "all":
+	default:
+		return nil, apperror.Validation("error.invalid_status")
+	}
+	if filter.Gender != "" {
+		if _, ok := customerGenderLabels[filter.Gender]; !ok {
+			return nil, apperror.Validation("error.invalid_gender")
+		}
+		query = query.Where("gender = ?", filter.Gender)
+	}
+	if filter.CreatedFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", filter.CreatedFrom, time.Local)
+		if err != nil {
+			return nil, apperror.Validation("error.invalid_created_from")
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if filter.CreatedTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", filter.CreatedTo, time.Local)
+		if err != nil {
+			return nil, apperror.Validation("error.invalid_created_to")
+		}
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var encoder *encoding.Encoder
+	switch filter.Encoding {
+	case "", CSVEncodingUTF8:
+	case CSVEncodingShiftJIS:
+		// Characters outside Shift_JIS, such as emoji, become "?" rather than failing the whole export
+		encoder = encoding.ReplaceUnsupported(japanese.ShiftJIS.NewEncoder())
+	default:
+		return nil, apperror.Validation("error.invalid_encoding")
+	}
+
+	return func(w io.Writer) error {
+		if encoder != nil {
+			w = encoder.Writer(w)
+		} else if _, err := w.Write([]byte("\ufeff")); err != nil {
+			// The byte order mark lets Excel recognise UTF-8
+			return err
+		}
+
+		writer := csv.NewWriter(w)
+		header := []string{"顧客ID"}
+		for _, column := range customerCSVColumns {
+			header = append(header, column.headers[0])
+		}
+		header = append(header, "登録日")
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+
+		var customers []model.Customer
+		result := query.Order("created_at ASC").FindInBatches(&customers, customerExportBatch, func(tx *gorm.DB, batch int) error {
+			for _, customer := range customers {
+				var birthday string
+				if customer.Birthday != nil {
+					birthday = customer.Birthday.Format("2006-01-02")
+				}
+				if err := writer.Write([]string{
+					customer.ID.String(),
+					csvSafe(customer.Name),
+					csvSafe(customer.NameKana),
+					customer.PhoneDisplay,
+					csvSafe(customer.Email),
+					birthday,
+					customerGenderLabels[customer.Gender],
+					csvSafe(customer.Notes),
+					customer.CreatedAt.Format("2006-01-02"),
+				}); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		})
+		if result.Error != nil {
+			utils.Log.Errorf("Failed to export customers: %v", result.Error)
+			return result.Error
+		}
+
+		writer.Flush()
+		return writer.Error()
+	}, nil
+}
+
+// resolveCustomerColumns finds the column of each field from the header row. The name column is required
+func resolveCustomerColumns(header []string, mapping map[string]string) (map[string]int, map[string]string, error) {
+	positions := map[string]int{}
+	for i, title := range header {
+		title = strings.TrimPrefix(title, "\ufeff")
+		if _, ok := positions[utils.NormalizeSearchText(title)]; !ok {
+			positions[utils.NormalizeSearchText(title)] = i
+		}
+	}
+
+	known := map[string]bool{}
+	for _, column := range customerCSVColumns {
+		known[column.field] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, nil, apperror.Validation("error.unknown_mapping_field").WithParams(i18n.Params{"field": field})
+		}
+	}
+
+	columns := map[string]int{}
+	headers := map[string]string{}
+	for _, column := range customerCSVColumns {
+		if title, ok := mapping[column.field]; ok {
+			index, found := positions[utils.NormalizeSearchText(title)]
+			if !found {
+				return nil, nil, apperror.Validation("error.mapped_column_not_found").WithParams(i18n.Params{"column": title})
+			}
+			columns[column.field] = index
+			headers[column.field] = strings.TrimPrefix(header[index], "\ufeff")
+			continue
+		}
+		for _, title := range column.headers {
+			if index, found := positions[utils.NormalizeSearchText(title)]; found {
+				columns[column.field] = index
+				headers[column.field] = strings.TrimPrefix(header[index], "\ufeff")
+				break
+			}
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, apperror.Validation("error.name_column_required")
+	}
+	return columns, headers, nil
+}
+
+// decodeCSV turns the CSV into a UTF-8 string, dropping the BOM Excel adds
+func decodeCSV(data []byte, encodingName string) (string, string, error) {
+	switch encodingName {
+	case "", CSVEncodingAuto:
+		encodingName = CSVEncodingUTF8
+		if !utf8.Valid(data) {
+			encodingName = CSVEncodingShiftJIS
+		}
+	case CSVEncodingUTF8, CSVEncodingShiftJIS:
+	default:
+		return "", "", apperror.Validation("error.invalid_encoding")
+	}
+
+	if encodingName == CSVEncodingShiftJIS {
+		decoded, _, err := transform.Bytes(japanese.ShiftJIS.NewDecoder(), data)
+		if err != nil {
+			return "", "", apperror.Validation("error.csv_invalid_shift_jis")
+		}
+		data = decoded
+	} else if !utf8.Valid(data) {
+		return "", "", apperror.Validation("error.csv_invalid_utf8")
+	}
+
+	return string(bytes.TrimPrefix(data, []byte("\ufeff"))), encodingName, nil
+}
+
+// readCSVRecords returns every CSV record and the file line each record starts on.
+// Blank lines are skipped, so line numbers come from the read position rather than the record count
+func readCSVRecords(text string) ([][]string, []int, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, apperror.Validation("error.invalid_csv").WithParams(i18n.Params{"detail": err.Error()})
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	return records, lines, nil
+}
+
+// parseCSVDate accepts dates as 2006-01-02, 2006/01/02, 2006/1/2 and 20060102
+func parseCSVDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "2006/1/2", "20060102"} {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// csvSafe prefixes values starting with = + - @ with ' so spreadsheets do not run them as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
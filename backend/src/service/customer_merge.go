@@ -0,0 +1,347 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/utils"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Customers whose name similarity is at least duplicateNameThreshold are duplicate candidates
+const duplicateNameThreshold = 0.8
+
+// nameTrigramThreshold is the trigram similarity SQL uses to narrow down name pairs.
+// It is looser than duplicateNameThreshold because the bigram similarity scored in Go uses a different scale
+const nameTrigramThreshold = 0.4
+
+// maxSimilarNamePairs is the most name pairs scored in Go for one column
+const maxSimilarNamePairs = 1000
+
+// Reasons a pair is a duplicate candidate
+const (
+	DuplicateReasonPhone = "phone"
+	DuplicateReasonEmail = "email"
+	DuplicateReasonName  = "name"
+)
+
+// DuplicateCandidate is a pair of customers that may be the same person
+type DuplicateCandidate struct {
+	Customers      [2]model.Customer `json:"customers"`
+	Reasons        []string          `json:"reasons"`
+	NameSimilarity float64           `json:"name_similarity"`
+}
+
+// CustomerMergeResult is the merged customer and the number of records moved from the duplicate
+type CustomerMergeResult struct {
+	Customer *model.Customer  `json:"customer"`
+	Moved    map[string]int64 `json:"moved"`
+}
+
+// FindDuplicateCandidates returns duplicate candidates from matching phone and email blind indexes and similar names.
+// Pairs are narrowed down in SQL and only candidate customers are decrypted. Pairs with more reasons and more similar names come first
+func (s *CustomerService) FindDuplicateCandidates(limit int) ([]DuplicateCandidate, error) {
+	type pair struct{ a, b uuid.UUID }
+	reasons := map[pair][]string{}
+	addReason := func(a, b uuid.UUID, reason string) {
+		if b.String() < a.String() {
+			a, b = b, a
+		}
+		key := pair{a, b}
+		for _, r := range reasons[key] {
+			if r == reason {
+				return
+			}
+		}
+		reasons[key] = append(reasons[key], reason)
+	}
+
+	for reason, column := range map[string]string{DuplicateReasonPhone: "phone_index", DuplicateReasonEmail: "email_index"} {
+		groups, err := s.duplicateContactGroups(column)
+		if err != nil {
+			return nil, err
+		}
+		for _, ids := range groups {
+			for x := 0; x < len(ids); x++ {
+				for y := x + 1; y < len(ids); y++ {
+					addReason(ids[x], ids[y], reason)
+				}
+			}
+		}
+	}
+
+	for _, column := range []string{"name_search", "kana_search"} {
+		pairs, err := s.similarNamePairs(column)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			if utils.NameSimilarity(p.AName, p.BName) >= duplicateNameThreshold {
+				addReason(p.A, p.B, DuplicateReasonName)
+			}
+		}
+	}
+
+	if len(reasons) == 0 {
+		return []DuplicateCandidate{}, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(reasons)*2)
+	for key := range reasons {
+		ids = append(ids, key.a, key.b)
+	}
+	var customers []model.Customer
+	if err := s.db.Where("id IN ?", ids).Find(&customers).Error; err != nil {
+		utils.Log.Errorf("Failed to get duplicate candidates: %v", err)
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]model.Customer, len(customers))
+	for _, customer := range customers {
+		byID[customer.ID] = customer
+	}
+
+	candidates := make([]DuplicateCandidate, 0, len(reasons))
+	for key, pairReasons := range reasons {
+		a, b := byID[key.a], byID[key.b]
+		if b.CreatedAt.Before(a.CreatedAt) {
+			a, b = b, a
+		}
+		sort.Strings(pairReasons)
+		candidates = append(candidates, DuplicateCandidate{
+			Customers:      [2]model.Customer{a, b},
+			Reasons:        pairReasons,
+			NameSimilarity: customerNameSimilarity(&a, &b),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if len(candidates[i].Reasons) != len(candidates[j].Reasons) {
+			return len(candidates[i].Reasons) > len(candidates[j].Reasons)
+		}
+		if candidates[i].NameSimilarity != candidates[j].NameSimilarity {
+			return candidates[i].NameSimilarity > candidates[j].NameSimilarity
+		}
+		return candidates[i].Customers[0].CreatedAt.Before(candidates[j].Customers[0].CreatedAt)
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// duplicateContactGroups returns the IDs of active customers grouped by matching values of the given blind index
+func (s *CustomerService) duplicateContactGroups(column string) ([][]uuid.UUID, error) {
+	duplicated := s.db.Model(&model.Customer{}).Select(column).
+		Where("is_active = ? AND "+column+" <> ''", true).
+		Group(column).
+		Having("COUNT(*) > 1")
+
+	var rows []struct {
+		ID    uuid.UUID
+		Value string
+	}
+	if err := s.db.Model(&model.Customer{}).Select("id, "+column+" AS value").
+		Where("is_active = ? AND "+column+" IN (?)", true, duplicated).
+		Find(&rows).Error; err != nil {
+		utils.Log.Errorf("Failed to find customers sharing %s: %v", column, err)
+		return nil, err
+	}
+
+	byValue := map[string][]uuid.UUID{}
+	for _, row := range rows {
+		byValue[row.Value] = append(byValue[row.Value], row.ID)
+	}
+	groups := make([][]uuid.UUID, 0, len(byValue))
+	for _, ids := range byValue {
+		groups = append(groups, ids)
+	}
+	return groups, nil
+}
+
+// namePair is a pair of customers whose names are compared. Only normalized names are read, so nothing is decrypted
+type namePair struct {
+	A     uuid.UUID
+	B     uuid.UUID
+	AName string
+	BName string
+}
+
+// similarNamePairs returns up to maxSimilarNamePairs pairs of active customers with similar normalized names, most similar first.
+// Postgres narrows them down with the trigram index % operator and similarity(), and Go scores only the remaining pairs
+func (s *CustomerService) similarNamePairs(column string) ([]namePair, error) {
+	query := s.db.Table("customers AS a").
+		Select("a.id AS a, b.id AS b, a."+column+" AS a_name, b."+column+" AS b_name").
+		Where("a.is_active = ? AND b.is_active = ? AND a."+column+" <> ''", true, true).
+		Limit(maxSimilarNamePairs)
+	if s.db.Dialector.Name() == "postgres" {
+		query = query.
+			Joins("JOIN customers AS b ON a.id < b.id AND a."+column+" % b."+column).
+			Where("similarity(a."+column+", b."+column+") >= ?", nameTrigramThreshold).
+			Order("similarity(a." + column + ", b." + column + ") DESC")
+	} else {
+		// SQLite, used by the tests, has no pg_trgm. Compare names sharing the first character
+		// whose bigram counts are close enough to reach the threshold
+		query = query.
+			Joins("JOIN customers AS b ON a.id < b.id AND substr(a." + column + ", 1, 1) = substr(b." + column + ", 1, 1)").
+			Where("3 * (length(a." + column + ") - 1) >= 2 * (length(b." + column + ") - 1) AND 3 * (length(b." + column + ") - 1) >= 2 * (length(a." + column + ") - 1)")
+	}
+
+	var pairs []namePair
+	if err := query.Scan(&pairs).Error; err != nil {
+		utils.Log.Errorf("Failed to find customers with similar %s: %v", column, err)
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// MergeCustomers moves the reservations, waitlist entries, notifications and notification settings of the duplicate to the kept customer and deactivates the duplicate.
+// Empty fields of the kept customer are filled from the duplicate, all in one transaction with an audit log
+func (s *CustomerService) MergeCustomers(survivorID, duplicateID uuid.UUID, actor AuditActor) (*CustomerMergeResult, error) {
+	if survivorID == duplicateID {
+		return nil, apperror.Validation("error.merge_into_itself")
+	}
+
+	result := &CustomerMergeResult{Moved: map[string]int64{}}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var survivor, duplicate model.Customer
+		if err := tx.Where("id = ? AND is_active = ?", survivorID, true).First(&survivor).Error; err != nil {
+			return ErrCustomerNotFound
+		}
+		if err := tx.Where("id = ? AND is_active = ?", duplicateID, true).First(&duplicate).Error; err != nil {
+			return apperror.NotFound("error.duplicate_customer_not_found")
+		}
+
+		for _, move := range []struct {
+			name  string
+			model interface{}
+		}{
+			{"reservations", &model.Reservation{}},
+			{"reservation_series", &model.ReservationSeries{}},
+			{"waitlist_entries", &model.WaitlistEntry{}},
+			{"login_challenges", &model.LoginChallenge{}},
+		} {
+			moved := tx.Model(move.model).Where("customer_id = ?", duplicate.ID).Update("customer_id", survivor.ID)
+			if moved.Error != nil {
+				utils.Log.Errorf("Failed to move %s to merged customer: %v", move.name, moved.Error)
+				return moved.Error
+			}
+			result.Moved[move.name] = moved.RowsAffected
+		}
+
+		moved := customerNotifications(tx.Model(&model.NotificationLog{}), &duplicate).Update("customer_id", survivor.ID)
+		if moved.Error != nil {
+			utils.Log.Errorf("Failed to move notification logs to merged customer: %v", moved.Error)
+			return moved.Error
+		}
+		result.Moved["notification_logs"] = moved.RowsAffected
+
+		// The survivor's own preferences win when both have them
+		var preferenceCount int64
+		if err := tx.Model(&model.NotificationPreference{}).Where("customer_id = ?", survivor.ID).Count(&preferenceCount).Error; err != nil {
+			return err
+		}
+		preferences := tx.Where("customer_id = ?", duplicate.ID)
+		if preferenceCount > 0 {
+			moved = preferences.Delete(&model.NotificationPreference{})
+		} else {
+			moved = preferences.Model(&model.NotificationPreference{}).Update("customer_id", survivor.ID)
+		}
+		if moved.Error != nil {
+			utils.Log.Errorf("Failed to move notification preferences to merged customer: %v", moved.Error)
+			return moved.Error
+		}
+		result.Moved["notification_preferences"] = moved.RowsAffected
+
+		// The duplicate's calendar subscription would only ever be empty from now on
+		if err := tx.Where("owner_type = ? AND owner_id = ?", model.CalendarFeedOwnerCustomer, duplicate.ID).
+			Delete(&model.CalendarFeed{}).Error; err != nil {
+			utils.Log.Errorf("Failed to revoke calendar feed of merged customer: %v", err)
+			return err
+		}
+
+		// Release the duplicate's contacts first so that the unique indexes allow copying them
+		if err := tx.Model(&model.Customer{}).Where("id = ?", duplicate.ID).Updates(map[string]interface{}{
+			"phone":                 "",
+			"email":                 "",
+			"phone_index":           "",
+			"phone_last_four_index": "",
+			"email_index":           "",
+			"is_active":             false,
+		}).Error; err != nil {
+			utils.Log.Errorf("Failed to deactivate merged customer: %v", err)
+			return err
+		}
+
+		changed := []string{}
+		if survivor.Phone == "" && duplicate.Phone != "" {
+			survivor.Phone = duplicate.Phone
+			changed = append(changed, "phone")
+		}
+		if survivor.Email == "" && duplicate.Email != "" {
+			survivor.Email = duplicate.Email
+			changed = append(changed, "email")
+		}
+		if survivor.NameKana == "" && duplicate.NameKana != "" {
+			survivor.NameKana = duplicate.NameKana
+			changed = append(changed, "name_kana")
+		}
+		if survivor.Birthday == nil && duplicate.Birthday != nil {
+			survivor.Birthday = duplicate.Birthday
+			changed = append(changed, "birthday")
+		}
+		if survivor.Gender == "" && duplicate.Gender != "" {
+			survivor.Gender = duplicate.Gender
+			changed = append(changed, "gender")
+		}
+		if duplicate.Notes != "" && !strings.Contains(survivor.Notes, duplicate.Notes) {
+			survivor.Notes = strings.TrimSpace(survivor.Notes + "\n" + duplicate.Notes)
+			changed = append(changed, "notes")
+		}
+
+		if err := s.validator.Struct(&survivor); err != nil {
+			utils.Log.Errorf("Merged customer validation failed: %v", err)
+			return err
+		}
+		if err := tx.Save(&survivor).Error; err != nil {
+			utils.Log.Errorf("Failed to update merged customer: %v", err)
+			return err
+		}
+
+		// Only IDs and the names of the filled fields, the values are personal data
+		if err := writeAuditLog(tx, s.validator, survivor.TableName(), survivor.ID, AuditActionMerge,
+			map[string]interface{}{"customer_id": survivor.ID, "duplicate_id": duplicate.ID},
+			map[string]interface{}{"customer_id": survivor.ID, "merged_customer_id": duplicate.ID, "changed_fields": changed, "moved": result.Moved},
+			actor); err != nil {
+			return err
+		}
+
+		result.Customer = &survivor
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// customerNameSimilarity returns the higher of the name and kana similarities.
+// Kana catches different kanji spellings, and the name catches customers without kana
+func customerNameSimilarity(a, b *model.Customer) float64 {
+	return max(utils.NameSimilarity(a.NameSearch, b.NameSearch), utils.NameSimilarity(a.KanaSearch, b.KanaSearch))
+}
+
+// customerContactIndexes returns the blind indexes of the contacts a notification to the customer may have been sent to
+func customerContactIndexes(customer *model.Customer) []string {
+	var indexes []string
+	if customer.EmailIndex != "" {
+		indexes = append(indexes, customer.EmailIndex)
+	}
+	if customer.PhoneIndex != "" {
+		indexes = append(indexes, customer.PhoneIndex)
+	}
+	return indexes
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Customer reservation list filters
+const (
+	CustomerReservationsUpcoming = "upcoming"
+	CustomerReservationsPast     = "past"
+)
+
+// CustomerPortalService handles operations by the signed-in customer.
+// Every method takes the customerID from the token and never touches other customers' data
+type CustomerPortalService struct {
+	db                 *gorm.DB
+	customerService    *CustomerService
+	reservationService *ReservationService
+}
+
+func NewCustomerPortalService(db *gorm.DB, reservationService *ReservationService) *CustomerPortalService {
+	return &CustomerPortalService{
+		db:                 db,
+		customerService:    NewCustomerService(db),
+		reservationService: reservationService,
+	}
+}
+
+func (s *CustomerPortalService) GetProfile(customerID uuid.UUID) (*model.Customer, error) {
+	return s.customerService.GetCustomerByID(customerID)
+}
+
+// UpdateProfile updates the fields customers may change themselves (name, kana, birthday, gender and notification language).
+// Phone and email are used to log in, so customers cannot change them. The current email address is accepted as is
+func (s *CustomerPortalService) UpdateProfile(customerID uuid.UUID, name, nameKana, email string, birthday *time.Time, gender, locale string) (*model.Customer, error) {
+	customer, err := s.customerService.GetCustomerByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if email != "" && model.CustomerEmailIndex(email) != customer.EmailIndex {
+		return nil, ErrEmailNotChangeable
+	}
+
+	if name != "" {
+		customer.Name = name
+	}
+	if nameKana != "" {
+		customer.NameKana = nameKana
+	}
+	if birthday != nil {
+		customer.Birthday = birthday
+	}
+	if gender != "" {
+		customer.Gender = gender
+	}
+	if locale != "" {
+		customer.Locale = locale
+	}
+
+	return s.customerService.UpdateCustomer(customer)
+}
+
+// GetReservations returns the customer's reservations, upcoming (soonest first) or past (latest first)
+func (s *CustomerPortalService) GetReservations(customerID uuid.UUID, scope string, page, limit int) ([]model.Reservation, int64, error) {
+	var reservations []model.Reservation
+	var total int64
+
+	now := time.Now()
+	query := s.db.Model(&model.Reservation{}).Where("customer_id = ?", customerID)
+	order := "start_time ASC"
+	switch scope {
+	case CustomerReservationsUpcoming, "":
+		query = query.Where("start_time >= ? AND status IN ?", now,
+			[]model.ReservationStatus{model.ReservationStatusPending, model.ReservationStatusConfirmed})
+	case CustomerReservationsPast:
+		query = query.Where("(start_time < ? OR status IN ?)", now,
+			[]model.ReservationStatus{model.ReservationStatusCompleted, model.ReservationStatusCancelled, model.ReservationStatusNoShow})
+		order = "start_time DESC"
+	default:
+		return nil, 0, apperror.Validation("error.invalid_filter")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		utils.Log.Errorf("Failed to count customer reservations: %v", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Staff").
+		Preload("ReservationMenus.Menu").
+		Preload("ReservationOptions.Option").
+		Order(order).
+		Offset(offset).
+		Limit(limit).
+		Find(&reservations).Error; err != nil {
+		utils.Log.Errorf("Failed to get customer reservations: %v", err)
+		return nil, 0, err
+	}
+
+	return reservations, total, nil
+}
+
+// GetReservation returns a reservation of the customer. Other customers' reservations are treated as missing
+func (s *CustomerPortalService) GetReservation(customerID, id uuid.UUID) (*model.Reservation, error) {
+	reservation, err := s.reservationService.GetReservationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.CustomerID != customerID {
+		return nil, ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+// CreateReservation books a reservation for the customer, following the same policy as other bookings, such as the booking window
+func (s *CustomerPortalService) CreateReservation(customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes, staffAssignment string) (*model.Reservation, error) {
+	if _, err := s.customerService.GetCustomerByID(customerID); err != nil {
+		return nil, err
+	}
+
+	return s.reservationService.CreateReservationFromRequest(customerID, staffID, reservationDate, startTime, menuIDs, optionIDs, notes, false, staffAssignment, false)
+}
+
+// RescheduleReservation changes the date, time or staff of the customer's reservation.
+// It can be changed until config.CustomerChangeCutoff hours before it starts
+func (s *CustomerPortalService) RescheduleReservation(customerID, id, staffID uuid.UUID, reservationDate, startTime string) (*model.Reservation, error) {
+	reservation, err := s.changeableReservation(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedDate := reservation.ReservationDate
+	if reservationDate != "" {
+		parsedDate, err = time.Parse("2006-01-02", reservationDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+
+		tomorrow := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+		if parsedDate.Before(tomorrow) {
+			return nil, ErrBookingTooSoon
+		}
+		if parsedDate.After(time.Now().AddDate(0, 0, maxBookingDays)) {
+			return nil, ErrBookingTooFar
+		}
+	}
+
+	clock := reservation.StartTime
+	if startTime != "" {
+		clock, err = time.Parse("15:04:05", startTime)
+		if err != nil {
+			return nil, ErrInvalidTime
+		}
+	}
+
+	if staffID == uuid.Nil {
+		staffID = reservation.StaffID
+	}
+
+	start := time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local)
+	end := start.Add(reservation.EndTime.Sub(reservation.StartTime))
+	if err := s.reservationService.checkSlot(staffID, start, end, reservation.ID, false); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&model.Reservation{}).Where("id = ?", reservation.ID).Updates(map[string]interface{}{
+		"staff_id":         staffID,
+		"reservation_date": parsedDate,
+		"start_time":       start,
+		"end_time":         end,
+	}).Error; err != nil {
+		utils.Log.Errorf("Failed to reschedule reservation: %v", err)
+		return nil, err
+	}
+
+	// The previous slot is free again for the waitlist
+	s.reservationService.notifySlotReleased(reservation.StaffID, reservation.ReservationDate)
+
+	return s.reservationService.GetReservationByID(reservation.ID)
+}
+
+// CancelReservation cancels the customer's reservation until config.CustomerChangeCutoff hours before it starts
+func (s *CustomerPortalService) CancelReservation(customerID, id uuid.UUID) error {
+	reservation, err := s.changeableReservation(customerID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.reservationService.CancelReservation(reservation.ID)
+}
+
+// changeableReservation returns a reservation of the customer that can still be changed or cancelled before the cutoff
+func (s *CustomerPortalService) changeableReservation(customerID, id uuid.UUID) (*model.Reservation, error) {
+	reservation, err := s.GetReservation(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation.Status != model.ReservationStatusPending && reservation.Status != model.ReservationStatusConfirmed {
+		return nil, ErrReservationClosed
+	}
+
+	cutoff := time.Duration(config.CustomerChangeCutoff) * time.Hour
+	if time.Until(reservation.StartTime) < cutoff {
+		return nil, apperror.PolicyViolation("error.change_deadline_passed")
+	}
+
+	return reservation, nil
+}
+
+// GetNotificationPreferences returns the customer's notification settings, creating the defaults when unset
+func (s *CustomerPortalService) GetNotificationPreferences(customerID uuid.UUID) (*model.NotificationPreference, error) {
+	if _, err := s.customerService.GetCustomerByID(customerID); err != nil {
+		return nil, err
+	}
+
+	preference := model.NotificationPreference{CustomerID: customerID}
+	if err := s.db.Where(model.NotificationPreference{CustomerID: customerID}).
+		Attrs(model.NotificationPreference{EmailEnabled: true, SMSEnabled: true, ReminderEnabled: true}).
+		FirstOrCreate(&preference).Error; err != nil {
+		utils.Log.Errorf("Failed to get notification preferences: %v", err)
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// UpdateNotificationPreferences updates only the given notification settings
+func (s *CustomerPortalService) UpdateNotificationPreferences(customerID uuid.UUID, emailEnabled, smsEnabled, reminderEnabled, marketingEnabled *bool) (*model.NotificationPreference, error) {
+	preference, err := s.GetNotificationPreferences(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if emailEnabled != nil {
+		updates["email_enabled"] = *emailEnabled
+	}
+	if smsEnabled != nil {
+		updates["sms_enabled"] = *smsEnabled
+	}
+	if reminderEnabled != nil {
+		updates["reminder_enabled"] = *reminderEnabled
+	}
+	if marketingEnabled != nil {
+		updates["marketing_enabled"] = *marketingEnabled
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(preference).Updates(updates).Error; err != nil {
+			utils.Log.Errorf("Failed to update notification preferences: %v", err)
+			return nil, err
+		}
+	}
+
+	return s.GetNotificationPreferences(customerID)
+}
+
+// notificationChannel returns how and where to notify the customer according to their settings.
+// Even when both email and SMS are turned off, messages about reservations go to the registered contact
+func notificationChannel(db *gorm.DB, customer model.Customer) (string, string) {
+	var preference model.NotificationPreference
+	if err := db.Where("customer_id = ?", customer.ID).First(&preference).Error; err == nil {
+		if preference.EmailEnabled && customer.Email != "" {
+			return "email", customer.Email
+		}
+		if preference.SMSEnabled && customer.Phone != "" {
+			return "sms", customer.Phone
+		}
+	}
+
+	if customer.Email != "" {
+		return "email", customer.Email
+	}
+	return "sms", customer.Phone
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// erasedCustomerFields are the customer fields cleared on erasure
+var erasedCustomerFields = []string{"name", "name_kana", "phone", "email", "birthday", "gender", "notes"}
+
+// CustomerDataExport is all the data held on one customer, exported for a disclosure request under the Personal Information Protection Act
+type CustomerDataExport struct {
+	ExportedAt             time.Time                     `json:"exported_at"`
+	Customer               model.Customer                `json:"customer"`
+	NotificationPreference *model.NotificationPreference `json:"notification_preference"`
+	Reservations           []model.Reservation           `json:"reservations"`
+	ReservationSeries      []model.ReservationSeries     `json:"reservation_series"`
+	WaitlistEntries        []model.WaitlistEntry         `json:"waitlist_entries"`
+	Notifications          []model.NotificationLog       `json:"notifications"`
+	LoginHistory           []model.LoginChallenge        `json:"login_history"`
+	AuditLogs              []model.AuditLog              `json:"audit_logs"`
+}
+
+// ExportCustomerData returns the profile, reservations, notifications, login history and audit logs of a customer and records the disclosure in the audit log.
+// Staff personal data is left out, so staff are referenced by ID only
+func (s *CustomerService) ExportCustomerData(id uuid.UUID, actor AuditActor) (*CustomerDataExport, error) {
+	export := &CustomerDataExport{
+		ExportedAt:        time.Now(),
+		Reservations:      []model.Reservation{},
+		ReservationSeries: []model.ReservationSeries{},
+		WaitlistEntries:   []model.WaitlistEntry{},
+		Notifications:     []model.NotificationLog{},
+		LoginHistory:      []model.LoginChallenge{},
+		AuditLogs:         []model.AuditLog{},
+	}
+
+	if err := s.db.Where("id = ?", id).First(&export.Customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		utils.Log.Errorf("Failed to get customer for export: %v", err)
+		return nil, err
+	}
+
+	var preference model.NotificationPreference
+	if err := s.db.Where("customer_id = ?", id).First(&preference).Error; err == nil {
+		export.NotificationPreference = &preference
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.Log.Errorf("Failed to get notification preferences for export: %v", err)
+		return nil, err
+	}
+
+	queries := []struct {
+		name  string
+		query *gorm.DB
+		dest  interface{}
+	}{
+		{"reservations", s.db.Preload("ReservationMenus.Menu").Preload("ReservationOptions.Option").
+			Where("customer_id = ?", id).Order("start_time ASC"), &export.Reservations},
+		{"reservation series", s.db.Where("customer_id = ?", id).Order("created_at ASC"), &export.ReservationSeries},
+		{"waitlist entries", s.db.Preload("WaitlistMenus").Where("customer_id = ?", id).Order("created_at ASC"), &export.WaitlistEntries},
+		{"notifications", customerNotifications(s.db, &export.Customer).Order("created_at ASC"), &export.Notifications},
+		{"login history", customerLoginChallenges(s.db, &export.Customer).Order("created_at ASC"), &export.LoginHistory},
+		{"audit logs", s.db.Where("table_name = ? AND record_id = ?", export.Customer.TableName(), id).Order("created_at ASC"), &export.AuditLogs},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			utils.Log.Errorf("Failed to get %s for export: %v", q.name, err)
+			return nil, err
+		}
+	}
+
+	// The disclosure is only handed out once it has been recorded
+	if err := writeAuditLog(s.db, s.validator, export.Customer.TableName(), id, AuditActionExport, nil, map[string]interface{}{
+		"reservations":       len(export.Reservations),
+		"reservation_series": len(export.ReservationSeries),
+		"waitlist_entries":   len(export.WaitlistEntries),
+		"notifications":      len(export.Notifications),
+		"login_history":      len(export.LoginHistory),
+		"audit_logs":         len(export.AuditLogs),
+	}, actor); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// EraseCustomer anonymizes a customer's personal data for an erasure request under the Personal Information Protection Act.
+// Reservations and amounts are kept for sales reports, and only identifying content such as reservation notes and notification recipients and bodies is removed
+func (s *CustomerService) EraseCustomer(id uuid.UUID, actor AuditActor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var customer model.Customer
+		if err := tx.Where("id = ?", id).First(&customer).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCustomerNotFound
+			}
+			return err
+		}
+		if customer.ErasedAt != nil {
+			return apperror.Conflict("error.customer_already_erased")
+		}
+
+		anonymized := map[string]int64{}
+		steps := []struct {
+			name  string
+			apply func() *gorm.DB
+		}{
+			{"reservations", func() *gorm.DB {
+				return tx.Model(&model.Reservation{}).Where("customer_id = ?", id).
+					Updates(map[string]interface{}{"notes": "", "cancellation_reason": ""})
+			}},
+			{"reservation_series", func() *gorm.DB {
+				return tx.Model(&model.ReservationSeries{}).Where("customer_id = ?", id).Update("notes", "")
+			}},
+			{"waitlist_entries", func() *gorm.DB {
+				return tx.Model(&model.WaitlistEntry{}).Where("customer_id = ?", id).
+					Updates(map[string]interface{}{
+						"notes":  "",
+						"status": gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", model.WaitlistStatusWaiting, model.WaitlistStatusCancelled),
+					})
+			}},
+			{"notification_logs", func() *gorm.DB {
+				return customerNotifications(tx.Model(&model.NotificationLog{}), &customer).
+					Updates(map[string]interface{}{"customer_id": id, "recipient": "", "recipient_index": "", "subject": "", "message": "", "error_message": ""})
+			}},
+			{"login_challenges", func() *gorm.DB {
+				return customerLoginChallenges(tx, &customer).Delete(&model.LoginChallenge{})
+			}},
+			{"notification_preferences", func() *gorm.DB {
+				return tx.Where("customer_id = ?", id).Delete(&model.NotificationPreference{})
+			}},
+			{"calendar_feeds", func() *gorm.DB {
+				return tx.Where("owner_type = ? AND owner_id = ?", model.CalendarFeedOwnerCustomer, id).Delete(&model.CalendarFeed{})
+			}},
+			// Earlier audit entries hold snapshots of the profile
+			{"audit_logs", func() *gorm.DB {
+				return tx.Model(&model.AuditLog{}).
+					Where("table_name = ? AND record_id = ?", customer.TableName(), id).
+					Updates(map[string]interface{}{"old_values": "null", "new_values": "null"})
+			}},
+		}
+		for _, step := range steps {
+			result := step.apply()
+			if result.Error != nil {
+				utils.Log.Errorf("Failed to anonymize %s of customer: %v", step.name, result.Error)
+				return result.Error
+			}
+			anonymized[step.name] = result.RowsAffected
+		}
+
+		// The placeholder name is stored, so it is written in the customer's language
+		erasedName := i18n.T(customer.Locale, "customer.erased_name", nil)
+		now := time.Now()
+		if err := tx.Model(&model.Customer{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"name":                  erasedName,
+			"name_kana":             "",
+			"phone":                 "",
+			"email":                 "",
+			"birthday":              nil,
+			"gender":                "",
+			"notes":                 "",
+			"is_active":             false,
+			"erased_at":             now,
+			"name_search":           utils.NormalizeSearchText(erasedName),
+			"kana_search":           "",
+			"phone_index":           "",
+			"phone_last_four_index": "",
+			"email_index":           "",
+		}).Error; err != nil {
+			utils.Log.Errorf("Failed to anonymize customer: %v", err)
+			return err
+		}
+
+		return writeAuditLog(tx, s.validator, customer.TableName(), id, AuditActionErase, nil, map[string]interface{}{
+			"erased_fields": erasedCustomerFields,
+			"anonymized":    anonymized,
+			"erased_at":     now,
+		}, actor)
+	})
+}
+
+// customerNotifications selects the notifications sent to the customer. Those sent before the customer ID was recorded are found by recipient
+func customerNotifications(db *gorm.DB, customer *model.Customer) *gorm.DB {
+	condition := db.Session(&gorm.Session{NewDB: true}).Where("customer_id = ?", customer.ID)
+	if indexes := customerContactIndexes(customer); len(indexes) > 0 {
+		condition = condition.Or("customer_id IS NULL AND recipient_index IN ?", indexes)
+	}
+	return db.Where(condition)
+}
+
+// customerLoginChallenges selects the customer's login history. Destinations are stored normalized
+func customerLoginChallenges(db *gorm.DB, customer *model.Customer) *gorm.DB {
+	condition := db.Session(&gorm.Session{NewDB: true}).Where("customer_id = ?", customer.ID)
+	var destinations []string
+	if customer.Email != "" {
+		destinations = append(destinations, strings.ToLower(customer.Email))
+	}
+	if customer.Phone != "" {
+		destinations = append(destinations, strings.Map(func(r rune) rune {
+			if (r >= '0' && r <= '9') || r == '+' {
+				return r
+			}
+			return -1
+		}, customer.Phone))
+	}
+	if len(destinations) > 0 {
+		condition = condition.Or("destination IN ?", destinations)
+	}
+	return db.Where(condition)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// favoriteMenuCount is how many favorite menus are returned
+const favoriteMenuCount = 3
+
+// CustomerStatistics is the customer's usage computed from completed reservations
+type CustomerStatistics struct {
+	FirstVisit          *time.Time      `json:"first_visit"`
+	LastVisit           *time.Time      `json:"last_visit"`
+	VisitCount          int64           `json:"visit_count"`
+	AverageIntervalDays *float64        `json:"average_interval_days"` // only after two or more visits
+	LifetimeSpend       int64           `json:"lifetime_spend"`        // yen
+	FavoriteMenus       []FavoriteCount `json:"favorite_menus"`
+	FavoriteStaff       *FavoriteCount  `json:"favorite_staff"`
+	CancellationCount   int64           `json:"cancellation_count"`
+	NoShowCount         int64           `json:"no_show_count"`
+}
+
+// FavoriteCount is how often a menu or staff member was used
+type FavoriteCount struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int64     `json:"count"`
+}
+
+// CustomerProfile is the customer, their usage, risk and one page of reservation history
+type CustomerProfile struct {
+	Customer     *model.Customer     `json:"customer"`
+	Statistics   *CustomerStatistics `json:"statistics"`
+	Risk         *CustomerRisk       `json:"risk"`
+	Reservations []model.Reservation `json:"reservations"`
+}
+
+// GetCustomerProfile returns the customer details and usage, and their reservation history newest first, one page at a time
+func (s *CustomerService) GetCustomerProfile(id uuid.UUID, page, limit int) (*CustomerProfile, int64, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	statistics, err := s.GetCustomerStatistics(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	risk, err := customerRisk(s.db, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	query := s.db.Model(&model.Reservation{}).Where("customer_id = ?", id)
+	if err := query.Count(&total).Error; err != nil {
+		utils.Log.Errorf("Failed to count customer reservation history: %v", err)
+		return nil, 0, err
+	}
+
+	reservations := []model.Reservation{}
+	if err := query.Preload("Staff").
+		Preload("ReservationMenus.Menu").
+		Preload("ReservationOptions.Option").
+		Order("start_time DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reservations).Error; err != nil {
+		utils.Log.Errorf("Failed to get customer reservation history: %v", err)
+		return nil, 0, err
+	}
+
+	return &CustomerProfile{
+		Customer:     customer,
+		Statistics:   statistics,
+		Risk:         risk,
+		Reservations: reservations,
+	}, total, nil
+}
+
+// GetCustomerStatistics computes the customer's visit count, visit interval, total spent, favorite menus and staff, and cancellation and no-show counts
+func (s *CustomerService) GetCustomerStatistics(id uuid.UUID) (*CustomerStatistics, error) {
+	statistics := &CustomerStatistics{FavoriteMenus: []FavoriteCount{}}
+
+	var statusCounts []struct {
+		Status model.ReservationStatus
+		Count  int64
+		Spend  int64
+	}
+	if err := s.db.Model(&model.Reservation{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(total_price), 0) AS spend").
+		Where("customer_id = ?", id).
+		Group("status").
+		Scan(&statusCounts).Error; err != nil {
+		utils.Log.Errorf("Failed to count customer reservations by status: %v", err)
+		return nil, err
+	}
+	for _, row := range statusCounts {
+		switch row.Status {
+		case model.ReservationStatusCompleted:
+			statistics.VisitCount = row.Count
+			statistics.LifetimeSpend = row.Spend
+		case model.ReservationStatusCancelled:
+			statistics.CancellationCount = row.Count
+		case model.ReservationStatusNoShow:
+			statistics.NoShowCount = row.Count
+		}
+	}
+
+	if statistics.VisitCount == 0 {
+		return statistics, nil
+	}
+
+	visits := s.db.Model(&model.Reservation{}).
+		Where("customer_id = ? AND status = ?", id, model.ReservationStatusCompleted)
+
+	var first, last model.Reservation
+	if err := visits.Session(&gorm.Session{}).Order("start_time ASC").First(&first).Error; err != nil {
+		utils.Log.Errorf("Failed to get first visit: %v", err)
+		return nil, err
+	}
+	if err := visits.Session(&gorm.Session{}).Order("start_time DESC").First(&last).Error; err != nil {
+		utils.Log.Errorf("Failed to get last visit: %v", err)
+		return nil, err
+	}
+	statistics.FirstVisit = &first.StartTime
+	statistics.LastVisit = &last.StartTime
+	if statistics.VisitCount > 1 {
+		days := last.StartTime.Sub(first.StartTime).Hours() / 24 / float64(statistics.VisitCount-1)
+		statistics.AverageIntervalDays = &days
+	}
+
+	if err := s.db.Table("reservation_menus").
+		Select("menus.id AS id, menus.name AS name, SUM(reservation_menus.quantity) AS count").
+		Joins("JOIN reservations ON reservations.id = reservation_menus.reservation_id").
+		Joins("JOIN menus ON menus.id = reservation_menus.menu_id").
+		Where("reservations.customer_id = ? AND reservations.status = ?", id, model.ReservationStatusCompleted).
+		Group("menus.id, menus.name").
+		Order("count DESC, menus.name ASC").
+		Limit(favoriteMenuCount).
+		Scan(&statistics.FavoriteMenus).Error; err != nil {
+		utils.Log.Errorf("Failed to get favorite menus: %v", err)
+		return nil, err
+	}
+
+	var favoriteStaff []FavoriteCount
+	if err := s.db.Table("reservations").
+		Select("staff.id AS id, staff.name AS name, COUNT(*) AS count").
+		Joins("JOIN staff ON staff.id = reservations.staff_id").
+		Where("reservations.customer_id = ? AND reservations.status = ?", id, model.ReservationStatusCompleted).
+		Group("staff.id, staff.name").
+		Order("count DESC, MAX(reservations.start_time) DESC").
+		Limit(1).
+		Scan(&favoriteStaff).Error; err != nil {
+		utils.Log.Errorf("Failed to get favorite staff: %v", err)
+		return nil, err
+	}
+	if len(favoriteStaff) > 0 {
+		statistics.FavoriteStaff = &favoriteStaff[0]
+	}
+
+	return statistics, nil
+}
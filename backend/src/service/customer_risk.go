@@ -0,0 +1,74 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// No-shows hurt the salon more than late cancellations, so they weigh more
+const (
+	noShowRiskWeight     = 2
+	lateCancelRiskWeight = 1
+)
+
+const (
+	CustomerRiskLevelNone    = "none"
+	CustomerRiskLevelWarning = "warning" // staff see a warning when booking
+	CustomerRiskLevelHigh    = "high"    // staff must confirm (acknowledge_risk) when booking
+)
+
+// CustomerRisk is the risk of a customer computed from no-shows and late cancellations in the last config.RiskLookbackDays days
+type CustomerRisk struct {
+	CustomerID      uuid.UUID `json:"customer_id"`
+	NoShowCount     int64     `json:"no_show_count"`
+	LateCancelCount int64     `json:"late_cancel_count"`
+	Score           int64     `json:"score"`
+	Level           string    `json:"level"`
+}
+
+// customerRisk computes a customer's risk. Counts come from reservations every time, so they stay correct after customers are merged
+func customerRisk(db *gorm.DB, customerID uuid.UUID) (*CustomerRisk, error) {
+	since := time.Now().AddDate(0, 0, -config.RiskLookbackDays)
+
+	risk := &CustomerRisk{CustomerID: customerID}
+	if err := db.Model(&model.Reservation{}).
+		Where("customer_id = ? AND status = ? AND start_time >= ?", customerID, model.ReservationStatusNoShow, since).
+		Count(&risk.NoShowCount).Error; err != nil {
+		utils.Log.Errorf("Failed to count customer no-shows: %v", err)
+		return nil, err
+	}
+	if err := db.Model(&model.Reservation{}).
+		Where("customer_id = ? AND status = ? AND late_cancellation = ? AND start_time >= ?", customerID, model.ReservationStatusCancelled, true, since).
+		Count(&risk.LateCancelCount).Error; err != nil {
+		utils.Log.Errorf("Failed to count customer late cancellations: %v", err)
+		return nil, err
+	}
+
+	risk.Score = risk.NoShowCount*noShowRiskWeight + risk.LateCancelCount*lateCancelRiskWeight
+	risk.Level = customerRiskLevel(risk.Score)
+	return risk, nil
+}
+
+func customerRiskLevel(score int64) string {
+	switch {
+	case config.RiskConfirmScore > 0 && score >= int64(config.RiskConfirmScore):
+		return CustomerRiskLevelHigh
+	case config.RiskWarnScore > 0 && score >= int64(config.RiskWarnScore):
+		return CustomerRiskLevelWarning
+	default:
+		return CustomerRiskLevelNone
+	}
+}
+
+// GetCustomerRisk returns the customer's no-show and late cancellation risk
+func (s *CustomerService) GetCustomerRisk(id uuid.UUID) (*CustomerRisk, error) {
+	if _, err := s.GetCustomerByID(id); err != nil {
+		return nil, err
+	}
+	return customerRisk(s.db, id)
+}
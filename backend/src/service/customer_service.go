@@ -1,15 +1,34 @@
 package service
 
 import (
+	"app/src/apperror"
 	"app/src/model"
 	"app/src/utils"
+	"app/src/validation"
 	"errors"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// How customer searches match
+const (
+	CustomerSearchPrefix = "prefix"
+	CustomerSearchFuzzy  = "fuzzy"
+)
+
+// Number of search results
+const (
+	defaultCustomerSearchLimit = 20
+	maxCustomerSearchLimit     = 50
+)
+
+// likeEscaper disables LIKE wildcards in search terms
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type CustomerService struct {
 	db        *gorm.DB
 	validator *validator.Validate
@@ -18,45 +37,48 @@ type CustomerService struct {
 func NewCustomerService(db *gorm.DB) *CustomerService {
 	return &CustomerService{
 		db:        db,
-		validator: validator.New(),
+		validator: validation.Validator(),
 	}
 }
 
-func (s *CustomerService) GetCustomers(page, limit int) ([]model.Customer, int64, error) {
-	var customers []model.Customer
-	var total int64
+// customerSortFields are the fields the customer list can be sorted by
+var customerSortFields = map[string]sortField[model.Customer]{
+	"created_at": {
+		columns: []string{"created_at", "id"},
+		values: func(c *model.Customer) []interface{} {
+			return []interface{}{c.CreatedAt, c.ID}
+		},
+	},
+	"name": {
+		columns: []string{"name", "id"},
+		values: func(c *model.Customer) []interface{} {
+			return []interface{}{c.Name, c.ID}
+		},
+	},
+}
 
+func (s *CustomerService) GetCustomers(options ListOptions) ([]model.Customer, PageInfo, error) {
 	// Check if database is available
 	if s.db == nil {
-		return customers, 0, errors.New("database connection not available")
-	}
-
-	offset := (page - 1) * limit
-
-	// Count total records
-	if err := s.db.Model(&model.Customer{}).Where("is_active = ?", true).Count(&total).Error; err != nil {
-		utils.Log.Errorf("Failed to count customers: %v", err)
-		return nil, 0, err
+		return nil, PageInfo{}, errors.New("database connection not available")
 	}
 
-	// Get paginated records
-	if err := s.db.Where("is_active = ?", true).
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&customers).Error; err != nil {
-		utils.Log.Errorf("Failed to get customers: %v", err)
-		return nil, 0, err
+	customers, page, err := paginate(s.db.Model(&model.Customer{}).Where("is_active = ?", true), options, customerSortFields, "-created_at")
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCursor) && !errors.Is(err, ErrInvalidSort) {
+			utils.Log.Errorf("Failed to get customers: %v", err)
+		}
+		return nil, PageInfo{}, err
 	}
 
-	return customers, total, nil
+	return customers, page, nil
 }
 
 func (s *CustomerService) GetCustomerByID(id uuid.UUID) (*model.Customer, error) {
 	var customer model.Customer
 	if err := s.db.Where("id = ? AND is_active = ?", id, true).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, ErrCustomerNotFound
 		}
 		utils.Log.Errorf("Failed to get customer: %v", err)
 		return nil, err
@@ -71,16 +93,21 @@ func (s *CustomerService) CreateCustomer(customer *model.Customer) (*model.Custo
 		return nil, err
 	}
 
-	// Check if phone already exists
+	// Customers registered at the front desk always have a phone number
+	if customer.Phone == "" {
+		return nil, apperror.Validation("error.phone_required")
+	}
+
+	// Phone and email are encrypted, so duplicates are looked up by their blind indexes
 	var existingCustomer model.Customer
-	if err := s.db.Where("phone = ? AND is_active = ?", customer.Phone, true).First(&existingCustomer).Error; err == nil {
-		return nil, errors.New("phone number already exists")
+	if err := s.db.Where("phone_index = ? AND is_active = ?", model.CustomerPhoneIndex(customer.Phone), true).First(&existingCustomer).Error; err == nil {
+		return nil, ErrPhoneExists
 	}
 
 	// Check if email already exists (if provided)
 	if customer.Email != "" {
-		if err := s.db.Where("email = ? AND is_active = ?", customer.Email, true).First(&existingCustomer).Error; err == nil {
-			return nil, errors.New("email already exists")
+		if err := s.db.Where("email_index = ? AND is_active = ?", model.CustomerEmailIndex(customer.Email), true).First(&existingCustomer).Error; err == nil {
+			return nil, ErrEmailExists
 		}
 	}
 
@@ -103,22 +130,24 @@ func (s *CustomerService) UpdateCustomer(customer *model.Customer) (*model.Custo
 	var existingCustomer model.Customer
 	if err := s.db.Where("id = ? AND is_active = ?", customer.ID, true).First(&existingCustomer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, ErrCustomerNotFound
 		}
 		return nil, err
 	}
 
-	// Check if phone already exists for other customers
-	var phoneCheck model.Customer
-	if err := s.db.Where("phone = ? AND id != ? AND is_active = ?", customer.Phone, customer.ID, true).First(&phoneCheck).Error; err == nil {
-		return nil, errors.New("phone number already exists")
+	// Check if phone already exists for other customers (if provided)
+	if customer.Phone != "" {
+		var phoneCheck model.Customer
+		if err := s.db.Where("phone_index = ? AND id != ? AND is_active = ?", model.CustomerPhoneIndex(customer.Phone), customer.ID, true).First(&phoneCheck).Error; err == nil {
+			return nil, ErrPhoneExists
+		}
 	}
 
 	// Check if email already exists for other customers (if provided)
 	if customer.Email != "" {
 		var emailCheck model.Customer
-		if err := s.db.Where("email = ? AND id != ? AND is_active = ?", customer.Email, customer.ID, true).First(&emailCheck).Error; err == nil {
-			return nil, errors.New("email already exists")
+		if err := s.db.Where("email_index = ? AND id != ? AND is_active = ?", model.CustomerEmailIndex(customer.Email), customer.ID, true).First(&emailCheck).Error; err == nil {
+			return nil, ErrEmailExists
 		}
 	}
 
@@ -139,8 +168,73 @@ func (s *CustomerService) DeleteCustomer(id uuid.UUID) error {
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("customer not found")
+		return ErrCustomerNotFound
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// SearchCustomers searches customers by name, kana, phone number and email address.
+// Every space separated term must match. Names and kana match by prefix with prefix and anywhere with fuzzy.
+// Phone and email are encrypted, so they match the whole phone number, its last four digits or the whole email address exactly.
+// Full-width and half-width characters, hiragana and katakana, and hyphens in phone numbers are ignored
+func (s *CustomerService) SearchCustomers(query, match string, limit int) ([]model.Customer, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, apperror.Validation("error.search_query_required")
+	}
+	if match == "" {
+		match = CustomerSearchPrefix
+	}
+	if match != CustomerSearchPrefix && match != CustomerSearchFuzzy {
+		return nil, apperror.Validation("error.invalid_match")
+	}
+	if limit <= 0 {
+		limit = defaultCustomerSearchLimit
+	}
+	if limit > maxCustomerSearchLimit {
+		limit = maxCustomerSearchLimit
+	}
+
+	pattern := func(value string) string {
+		if match == CustomerSearchFuzzy {
+			return "%" + likeEscaper.Replace(value) + "%"
+		}
+		return likeEscaper.Replace(value) + "%"
+	}
+
+	db := s.db.Where("is_active = ?", true)
+	for _, term := range terms {
+		text := utils.NormalizeSearchText(term)
+		condition := s.db.Where(`name_search LIKE ? ESCAPE '\'`, pattern(text)).
+			Or(`kana_search LIKE ? ESCAPE '\'`, pattern(text))
+		if strings.Contains(term, "@") {
+			condition = condition.Or("email_index = ?", model.CustomerEmailIndex(term))
+		}
+		switch digits := utils.NormalizePhoneDigits(term); {
+		case len(digits) == 4:
+			condition = condition.Or("phone_last_four_index = ?", model.CustomerPhoneLastFourIndex(digits))
+		case len(digits) >= 10:
+			condition = condition.Or("phone_index = ?", model.CustomerPhoneIndex(term))
+		}
+		db = db.Where(condition)
+	}
+
+	// Exact matches first, then prefix matches, judged by the first term
+	first := utils.NormalizeSearchText(terms[0])
+	firstPrefix := likeEscaper.Replace(first) + "%"
+	firstPhone := model.CustomerPhoneIndex(terms[0])
+	db = db.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL: `CASE WHEN name_search = ? OR kana_search = ? OR (phone_index <> '' AND phone_index = ?) THEN 0
+			WHEN name_search LIKE ? ESCAPE '\' OR kana_search LIKE ? ESCAPE '\' THEN 1 ELSE 2 END, kana_search, name_search`,
+		Vars: []interface{}{first, first, firstPhone, firstPrefix, firstPrefix},
+	}})
+
+	var customers []model.Customer
+	if err := db.Limit(limit).Find(&customers).Error; err != nil {
+		utils.Log.Errorf("Failed to search customers: %v", err)
+		return nil, err
+	}
+
+	return customers, nil
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"app/src/config"
+	"app/src/metrics"
 	"app/src/utils"
 	"fmt"
+	"io"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/gomail.v2"
@@ -11,10 +13,18 @@ import (
 
 type EmailService interface {
 	SendEmail(to, subject, body string) error
+	SendEmailWithAttachments(to, subject, body string, attachments ...EmailAttachment) error
 	SendResetPasswordEmail(to, token string) error
 	SendVerificationEmail(to, token string) error
 }
 
+// EmailAttachment is a file attached to an email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
 type emailService struct {
 	Log    *logrus.Logger
 	Dialer *gomail.Dialer
@@ -33,17 +43,33 @@ func NewEmailService() EmailService {
 }
 
 func (s *emailService) SendEmail(to, subject, body string) error {
+	return s.SendEmailWithAttachments(to, subject, body)
+}
+
+func (s *emailService) SendEmailWithAttachments(to, subject, body string, attachments ...EmailAttachment) error {
 	mailer := gomail.NewMessage()
 	mailer.SetHeader("From", config.EmailFrom)
 	mailer.SetHeader("To", to)
 	mailer.SetHeader("Subject", subject)
 	mailer.SetBody("text/plain", body)
+	for _, attachment := range attachments {
+		content := attachment.Content
+		mailer.Attach(attachment.Filename,
+			gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(content)
+				return err
+			}),
+		)
+	}
 
 	if err := s.Dialer.DialAndSend(mailer); err != nil {
 		s.Log.Errorf("Failed to send email: %v", err)
+		metrics.Notifications.WithLabelValues("email", "failed").Inc()
 		return err
 	}
 
+	metrics.Notifications.WithLabelValues("email", "sent").Inc()
 	return nil
 }
 
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"errors"
+)
+
+// maxBookingDays is how many days ahead reservations are accepted
+const maxBookingDays = 90
+
+// Domain errors returned by several services. Errors used by a single service are defined in that service
+var (
+	ErrReservationNotFound = apperror.NotFound("error.reservation_not_found")
+	ErrCustomerNotFound    = apperror.NotFound("error.customer_not_found")
+	ErrStaffNotFound       = apperror.NotFound("error.staff_not_found")
+
+	ErrInvalidDate       = apperror.Validation("error.invalid_date")
+	ErrInvalidTime       = apperror.Validation("error.invalid_time")
+	ErrEndBeforeStart    = apperror.Validation("error.end_before_start")
+	ErrMenuRequired      = apperror.Validation("error.menu_required")
+	ErrMenuNotFound      = apperror.Validation("error.menu_not_found")
+	ErrOptionNotFound    = apperror.Validation("error.option_not_found")
+	ErrInvalidDateFrom   = apperror.Validation("error.invalid_date_from")
+	ErrInvalidDateTo     = apperror.Validation("error.invalid_date_to")
+	ErrDateRangeReversed = apperror.Validation("error.date_range_reversed")
+	ErrInvalidCursor     = apperror.Validation("error.invalid_cursor")
+	ErrInvalidSort       = apperror.Validation("error.invalid_sort")
+
+	ErrBookingTooSoon = apperror.PolicyViolation("error.booking_too_soon")
+	ErrBookingTooFar  = apperror.PolicyViolation("error.booking_too_far").WithParams(i18n.Params{"days": maxBookingDays})
+
+	ErrTimeSlotBooked        = apperror.Conflict("error.time_slot_booked")
+	ErrBlockedTimeOverlap    = apperror.Conflict("error.blocked_time_overlap")
+	ErrNoStaffAvailable      = apperror.Conflict("error.no_staff_available")
+	ErrStaffHasNoShift       = apperror.Conflict("error.staff_has_no_shift")
+	ErrOutsideShift          = apperror.Conflict("error.outside_shift")
+	ErrEmailExists           = apperror.Conflict("error.email_exists")
+	ErrPhoneExists           = apperror.Conflict("error.phone_exists")
+	ErrRiskConfirmation      = apperror.Conflict("error.risk_confirmation_required").WithCode("RISK_CONFIRMATION_REQUIRED")
+	ErrReservationClosed     = apperror.PolicyViolation("error.reservation_closed")
+	ErrAlreadyCancelled      = apperror.PolicyViolation("error.already_cancelled")
+	ErrCancelCompleted       = apperror.PolicyViolation("error.cancel_completed")
+	ErrInvalidStatusChange   = apperror.PolicyViolation("error.invalid_status_transition")
+	ErrEmailNotChangeable    = apperror.PolicyViolation("error.email_not_changeable")
+	ErrCalendarFeedNotFound  = apperror.NotFound("error.calendar_feed_not_found")
+	ErrWaitlistEntryNotFound = apperror.NotFound("error.waitlist_entry_not_found")
+)
+
+// errorMessage renders an error included in a response body (such as import row errors) in the given language
+func errorMessage(err error, locale string) string {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Message(locale)
+	}
+	return err.Error()
+}
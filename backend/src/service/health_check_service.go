@@ -1,9 +1,17 @@
 package service
 
 import (
+	"app/src/config"
+	"app/src/database"
 	"app/src/utils"
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -12,6 +20,9 @@ import (
 type HealthCheckService interface {
 	GormCheck() error
 	MemoryHeapCheck() error
+	MigrationCheck() error
+	SMTPCheck() error
+	WorkerCheck() error
 }
 
 type healthCheckService struct {
@@ -38,7 +49,10 @@ func (s *healthCheckService) GormCheck() error {
 		return errDB
 	}
 
-	if err := sqlDB.Ping(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
 		s.Log.Errorf("failed to ping the database: %v", err)
 		return err
 	}
@@ -51,10 +65,10 @@ func (s *healthCheckService) MemoryHeapCheck() error {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats) // Collect memory statistics
 
-	heapAlloc := memStats.HeapAlloc            // Heap memory currently allocated
-	heapThreshold := uint64(300 * 1024 * 1024) // Example threshold: 300 MB
+	heapAlloc := memStats.HeapAlloc // Heap memory currently allocated
+	heapThreshold := uint64(config.HealthHeapThreshold) * 1024 * 1024
 
-	s.Log.Infof("Heap Memory Allocation: %v bytes", heapAlloc)
+	s.Log.Debugf("Heap Memory Allocation: %v bytes", heapAlloc)
 
 	// If the heap allocation exceeds the threshold, return an error
 	if heapAlloc > heapThreshold {
@@ -64,3 +78,75 @@ func (s *healthCheckService) MemoryHeapCheck() error {
 
 	return nil
 }
+
+// MigrationCheck checks that the schema is at the newest migration embedded in the app and that no AutoMigrate schema or data migration is pending.
+// A newer applied version is allowed, in case a newer version of the app migrated first
+func (s *healthCheckService) MigrationCheck() error {
+	if s.DB == nil {
+		return errors.New("database connection not available")
+	}
+
+	expected, err := database.LatestMigrationVersion()
+	if err != nil {
+		s.Log.Errorf("failed to read the bundled migrations: %v", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	applied, dirty, err := database.AppliedMigrationVersion(s.DB.WithContext(ctx))
+	if err != nil {
+		s.Log.Errorf("failed to read the applied migration version: %v", err)
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d failed and left the schema dirty", applied)
+	}
+	if applied < expected {
+		return fmt.Errorf("schema is at migration %d, expected %d", applied, expected)
+	}
+
+	// Tables added through AutoMigrate and the Go data backfills are not in schema_migrations
+	migrations, err := database.DataMigrations()
+	if err != nil {
+		s.Log.Errorf("failed to list the data migrations: %v", err)
+		return err
+	}
+	pending, err := database.PendingDataMigrations(s.DB.WithContext(ctx), migrations)
+	if err != nil {
+		s.Log.Errorf("failed to read the applied data migrations: %v", err)
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("data migrations not applied: %s", strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+// WorkerCheck fails when a background worker, such as the waitlist offer dispatcher,
+// has not run for several of its intervals
+func (s *healthCheckService) WorkerCheck() error {
+	return workerHeartbeats.Check(time.Now())
+}
+
+// SMTPCheck checks that the SMTP server accepts TCP connections. It is skipped when SMTP_HOST is unset
+func (s *healthCheckService) SMTPCheck() error {
+	if config.SMTPHost == "" {
+		return nil
+	}
+
+	address := net.JoinHostPort(config.SMTPHost, strconv.Itoa(config.SMTPPort))
+	conn, err := net.DialTimeout("tcp", address, s.timeout())
+	if err != nil {
+		s.Log.Errorf("failed to reach the SMTP server: %v", err)
+		return errors.New("smtp server unreachable")
+	}
+
+	return conn.Close()
+}
+
+func (s *healthCheckService) timeout() time.Duration {
+	return time.Duration(config.HealthCheckTimeout) * time.Millisecond
+}
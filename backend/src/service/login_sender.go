@@ -0,0 +1,83 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// LoginSender delivers passwordless login one-time codes and magic links to customers
+type LoginSender interface {
+	SendLoginCode(channel model.LoginChannel, destination, code, magicLink, locale string) error
+}
+
+// NewLoginSender returns the sender for config.LoginSender. config rejects stub in production
+func NewLoginSender(db *gorm.DB, emailService EmailService, smsService SMSService) LoginSender {
+	if config.LoginSender == "notification" {
+		return &notificationLoginSender{db: db, emailService: emailService, smsService: smsService}
+	}
+	return &stubLoginSender{}
+}
+
+// stubLoginSender is for development. It sends nothing and logs the code and link so you can log in locally.
+// The destination is not logged. config rejects it in production, but just in case neither the code nor the link is logged there
+type stubLoginSender struct{}
+
+func (s *stubLoginSender) SendLoginCode(channel model.LoginChannel, destination, code, magicLink, locale string) error {
+	if config.IsProd {
+		utils.Log.WithField("channel", channel).Warn("[login stub] login code issued but not delivered, set LOGIN_SENDER=notification to send it")
+		return nil
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"channel":    channel,
+		"code":       code,
+		"magic_link": magicLink,
+	}).Info("[login stub] login code issued but not delivered, set LOGIN_SENDER=notification to send it")
+	return nil
+}
+
+// notificationLoginSender sends the code directly by email or SMS.
+// The notification log keeps only the result and the message key, never the code or link
+type notificationLoginSender struct {
+	db           *gorm.DB
+	emailService EmailService
+	smsService   SMSService
+}
+
+func (s *notificationLoginSender) SendLoginCode(channel model.LoginChannel, destination, code, magicLink, locale string) error {
+	params := i18n.Params{"code": code, "link": magicLink, "minutes": config.LoginCodeExp}
+
+	notification := &model.NotificationLog{
+		Recipient: destination,
+		Subject:   i18n.T(locale, "notification.login_code.subject", nil),
+	}
+	var err error
+	if channel == model.LoginChannelEmail {
+		notification.Type = "email"
+		notification.Message = "notification.login_code.email"
+		err = s.emailService.SendEmail(destination, notification.Subject, i18n.T(locale, "notification.login_code.email", params))
+	} else {
+		notification.Type = "sms"
+		notification.Message = "notification.login_code.sms"
+		err = s.smsService.SendSMS(destination, i18n.T(locale, "notification.login_code.sms", params))
+	}
+
+	now := time.Now()
+	notification.Status = model.NotificationStatusSent
+	notification.SentAt = &now
+	if err != nil {
+		notification.Status = model.NotificationStatusFailed
+		notification.ErrorMessage = err.Error()
+		notification.SentAt = nil
+	}
+	if logErr := s.db.Create(notification).Error; logErr != nil {
+		utils.Log.Errorf("Failed to log login code notification: %v", logErr)
+	}
+	return err
+}
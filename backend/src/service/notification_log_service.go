@@ -0,0 +1,93 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationLogService struct {
+	db *gorm.DB
+}
+
+func NewNotificationLogService(db *gorm.DB) *NotificationLogService {
+	return &NotificationLogService{
+		db: db,
+	}
+}
+
+// NotificationLogFilter is the notification list filter. Empty fields do not filter
+type NotificationLogFilter struct {
+	CustomerID *uuid.UUID
+	Type       string
+	Status     string
+}
+
+// NotificationLogSummary is one entry of the notification list. The body may contain login messages, so it is not returned
+type NotificationLogSummary struct {
+	ID           uuid.UUID                `json:"id"`
+	CustomerID   *uuid.UUID               `json:"customer_id"`
+	Type         string                   `json:"type"`
+	Recipient    string                   `json:"recipient"`
+	Subject      string                   `json:"subject"`
+	Status       model.NotificationStatus `json:"status"`
+	ErrorMessage string                   `json:"error_message"`
+	ScheduledAt  *time.Time               `json:"scheduled_at"`
+	SentAt       *time.Time               `json:"sent_at"`
+	CreatedAt    time.Time                `json:"created_at"`
+}
+
+// notificationLogSortFields are the fields the notification list can be sorted by
+var notificationLogSortFields = map[string]sortField[model.NotificationLog]{
+	"created_at": {
+		columns: []string{"created_at", "id"},
+		values: func(n *model.NotificationLog) []interface{} {
+			return []interface{}{n.CreatedAt, n.ID}
+		},
+	},
+}
+
+// GetNotificationLogs returns sent (and scheduled) notifications, newest first by default. Bodies are not loaded
+func (s *NotificationLogService) GetNotificationLogs(options ListOptions, filter NotificationLogFilter) ([]NotificationLogSummary, PageInfo, error) {
+	query := s.db.Model(&model.NotificationLog{})
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	notifications, page, err := paginate(query, options, notificationLogSortFields, "-created_at", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("message")
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCursor) && !errors.Is(err, ErrInvalidSort) {
+			utils.Log.Errorf("Failed to get notification logs: %v", err)
+		}
+		return nil, PageInfo{}, err
+	}
+
+	summaries := make([]NotificationLogSummary, len(notifications))
+	for i, notification := range notifications {
+		summaries[i] = NotificationLogSummary{
+			ID:           notification.ID,
+			CustomerID:   notification.CustomerID,
+			Type:         notification.Type,
+			Recipient:    notification.Recipient,
+			Subject:      notification.Subject,
+			Status:       notification.Status,
+			ErrorMessage: notification.ErrorMessage,
+			ScheduledAt:  notification.ScheduledAt,
+			SentAt:       notification.SentAt,
+			CreatedAt:    notification.CreatedAt,
+		}
+	}
+	return summaries, page, nil
+}
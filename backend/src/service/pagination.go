@@ -0,0 +1,211 @@
+package service
+
+import (
+	"app/src/i18n"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// List page sizes
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ListOptions are the paging and sort options of a list.
+// With Cursor the list continues after the previous page (cursor pagination), otherwise Page sets the position (offset pagination)
+type ListOptions struct {
+	Page      int
+	Limit     int
+	Cursor    string
+	Sort      string // field to sort by, descending with a leading -. Empty uses the list's default order
+	WithTotal bool   // whether to count the total. COUNT(*) is slow on large tables, so only set it when needed
+}
+
+// PageInfo is the page information of a list result
+type PageInfo struct {
+	Page       int // page number with offset pagination, 0 with cursor pagination
+	Limit      int
+	Sort       string
+	HasNext    bool
+	NextCursor string // cursor of the next page, empty on the last page
+	Total      *int64 // nil unless WithTotal was set
+}
+
+// sortField is a field the sort parameter can name.
+// The last of columns must be unique (id), so page boundaries do not shift between rows with equal values
+type sortField[T any] struct {
+	columns []string
+	values  func(row *T) []interface{} // values of the row in the order of columns, stored in the cursor
+}
+
+// listCursor is the content of a cursor. Clients get it as opaque base64
+type listCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// paginate fetches one page of query's results in the given order.
+// It fetches one extra row to tell whether there is a next page, so the total is only counted with WithTotal.
+// scopes are options such as Preload that counting does not need, applied only when fetching rows
+func paginate[T any](query *gorm.DB, options ListOptions, fields map[string]sortField[T], defaultSort string, scopes ...func(*gorm.DB) *gorm.DB) ([]T, PageInfo, error) {
+	if options.Limit < 1 {
+		options.Limit = defaultListLimit
+	}
+	if options.Limit > maxListLimit {
+		options.Limit = maxListLimit
+	}
+	if options.Page < 1 {
+		options.Page = 1
+	}
+
+	var cursor *listCursor
+	if options.Cursor != "" {
+		decoded, err := decodeListCursor(options.Cursor)
+		if err != nil || (options.Sort != "" && options.Sort != decoded.Sort) {
+			return nil, PageInfo{}, ErrInvalidCursor
+		}
+		cursor = decoded
+		options.Sort = decoded.Sort
+	}
+	if options.Sort == "" {
+		options.Sort = defaultSort
+	}
+
+	field, ok := fields[strings.TrimPrefix(options.Sort, "-")]
+	if !ok {
+		return nil, PageInfo{}, ErrInvalidSort.WithParams(i18n.Params{"sorts": strings.Join(sortNames(fields), ", ")})
+	}
+	descending := strings.HasPrefix(options.Sort, "-")
+
+	info := PageInfo{Limit: options.Limit, Sort: options.Sort}
+	if options.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, PageInfo{}, err
+		}
+		info.Total = &total
+	}
+
+	rows := query.Session(&gorm.Session{}).Scopes(scopes...)
+	if cursor != nil {
+		values, err := cursorValues(cursor, field)
+		if err != nil {
+			return nil, PageInfo{}, ErrInvalidCursor
+		}
+		condition, args := keysetCondition(field.columns, values, descending)
+		rows = rows.Where(condition, args...)
+	} else {
+		rows = rows.Offset((options.Page - 1) * options.Limit)
+		info.Page = options.Page
+	}
+
+	direction := " ASC"
+	if descending {
+		direction = " DESC"
+	}
+	for _, column := range field.columns {
+		rows = rows.Order(column + direction)
+	}
+
+	var results []T
+	if err := rows.Limit(options.Limit + 1).Find(&results).Error; err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	if len(results) > options.Limit {
+		results = results[:options.Limit]
+		next, err := encodeListCursor(options.Sort, field.values(&results[len(results)-1]))
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		info.HasNext = true
+		info.NextCursor = next
+	}
+	return results, info, nil
+}
+
+// keysetCondition builds the condition selecting the rows after the cursor row in the sort order.
+// For columns (a, b, id) it is a > ? OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?) (with < when descending)
+func keysetCondition(columns []string, values []interface{}, descending bool) (string, []interface{}) {
+	operator := " > ?"
+	if descending {
+		operator = " < ?"
+	}
+
+	conditions := make([]string, 0, len(columns))
+	var args []interface{}
+	for i, column := range columns {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = ?")
+			args = append(args, values[j])
+		}
+		parts = append(parts, column+operator)
+		args = append(args, values[i])
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+func encodeListCursor(sortName string, values []interface{}) (string, error) {
+	cursor := listCursor{Sort: sortName, Values: make([]json.RawMessage, len(values))}
+	for i, value := range values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		cursor.Values[i] = encoded
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeListCursor(encoded string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// cursorValues converts the values stored in a cursor back to the row's types (such as time.Time and uuid.UUID)
+func cursorValues[T any](cursor *listCursor, field sortField[T]) ([]interface{}, error) {
+	var zero T
+	types := field.values(&zero)
+	if len(cursor.Values) != len(types) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(types))
+	for i, raw := range cursor.Values {
+		value := reflect.New(reflect.TypeOf(types[i]))
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return nil, err
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+// sortNames returns the sorted values the sort parameter accepts
+func sortNames[T any](fields map[string]sortField[T]) []string {
+	names := make([]string, 0, len(fields)*2)
+	for name := range fields {
+		names = append(names, name, "-"+name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ReportPeriodDaily   = "daily"
+	ReportPeriodWeekly  = "weekly"
+	ReportPeriodMonthly = "monthly"
+
+	// Breakdowns that can be exported as CSV
+	ReportSectionPeriods    = "periods"
+	ReportSectionMenus      = "menus"
+	ReportSectionOptions    = "options"
+	ReportSectionCategories = "categories"
+
+	// maxReportDays is the longest period a single report may cover
+	maxReportDays = 366 * 3
+
+	uncategorizedLabel = "未分類"
+)
+
+// reportStatuses are the reservation statuses counted. Statuses without reservations are returned as 0
+var reportStatuses = []model.ReservationStatus{
+	model.ReservationStatusPending,
+	model.ReservationStatusConfirmed,
+	model.ReservationStatusCompleted,
+	model.ReservationStatusCancelled,
+	model.ReservationStatusNoShow,
+}
+
+// ReportService builds sales and reservation reports, all aggregated with SQL GROUP BY
+type ReportService struct {
+	db *gorm.DB
+}
+
+func NewReportService(db *gorm.DB) *ReportService {
+	return &ReportService{
+		db: db,
+	}
+}
+
+// ReportFilter is the report query. Dates are reservation dates (YYYY-MM-DD) and DateTo is inclusive
+type ReportFilter struct {
+	Period   string
+	DateFrom string
+	DateTo   string
+	StaffID  *uuid.UUID
+}
+
+// ReportTotals is the revenue and the reservation count of each status. Revenue and average spend come from the totals of completed reservations
+type ReportTotals struct {
+	Sales          int64            `json:"sales"`
+	CompletedCount int64            `json:"completed_count"`
+	AverageTicket  int64            `json:"average_ticket"`
+	Reservations   int64            `json:"reservations"`
+	StatusCounts   map[string]int64 `json:"status_counts"`
+}
+
+// ReportBucket is the total of a day, week (starting Monday) or month
+type ReportBucket struct {
+	PeriodStart string `json:"period_start"`
+	ReportTotals
+}
+
+// ReportRevenueItem is the revenue from completed reservations of a menu, option or category
+type ReportRevenueItem struct {
+	ID       *uuid.UUID `json:"id,omitempty"`
+	Name     string     `json:"name"`
+	Category string     `json:"category,omitempty"`
+	Quantity int64      `json:"quantity"`
+	Revenue  int64      `json:"revenue"`
+}
+
+// SalesReport is the sales and reservation report
+type SalesReport struct {
+	Period     string              `json:"period"`
+	DateFrom   string              `json:"date_from"`
+	DateTo     string              `json:"date_to"`
+	StaffID    *uuid.UUID          `json:"staff_id,omitempty"`
+	Summary    ReportTotals        `json:"summary"`
+	Periods    []ReportBucket      `json:"periods"`
+	Menus      []ReportRevenueItem `json:"menus"`
+	Options    []ReportRevenueItem `json:"options"`
+	Categories []ReportRevenueItem `json:"categories"`
+}
+
+// GetSalesReport computes revenue, reservation counts by status and average spend per period, and revenue by menu, option and category.
+// Without dates it covers the latest periods up to today (30 days, 12 weeks or 12 months)
+func (s *ReportService) GetSalesReport(filter ReportFilter) (*SalesReport, error) {
+	if filter.Period == "" {
+		filter.Period = ReportPeriodDaily
+	}
+	bucket, err := reportBucketExpression(s.db.Dialector.Name(), filter.Period)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := reportDateRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SalesReport{
+		Period:   filter.Period,
+		DateFrom: from.Format("2006-01-02"),
+		DateTo:   to.Format("2006-01-02"),
+		StaffID:  filter.StaffID,
+		Summary:  newReportTotals(),
+		Periods:  []ReportBucket{},
+	}
+
+	// reservations restricts a query on the reservations table (aliased r) to the filter
+	reservations := func() *gorm.DB {
+		query := s.db.Table("reservations AS r").
+			Where("r.reservation_date >= ? AND r.reservation_date < ?", from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02"))
+		if filter.StaffID != nil {
+			query = query.Where("r.staff_id = ?", *filter.StaffID)
+		}
+		return query
+	}
+
+	var rows []struct {
+		PeriodStart string
+		Status      string
+		Count       int64
+		Amount      int64
+	}
+	if err := reservations().
+		Select(fmt.Sprintf("%s AS period_start, r.status AS status, COUNT(*) AS count, COALESCE(SUM(r.total_price), 0) AS amount", bucket)).
+		Group("period_start, r.status").Order("period_start").
+		Scan(&rows).Error; err != nil {
+		utils.Log.Errorf("Failed to aggregate reservations: %v", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		if len(report.Periods) == 0 || report.Periods[len(report.Periods)-1].PeriodStart != row.PeriodStart {
+			report.Periods = append(report.Periods, ReportBucket{PeriodStart: row.PeriodStart, ReportTotals: newReportTotals()})
+		}
+		report.Periods[len(report.Periods)-1].add(row.Status, row.Count, row.Amount)
+		report.Summary.add(row.Status, row.Count, row.Amount)
+	}
+	for i := range report.Periods {
+		report.Periods[i].finish()
+	}
+	report.Summary.finish()
+
+	completed := func() *gorm.DB {
+		return reservations().Where("r.status = ?", model.ReservationStatusCompleted)
+	}
+	menuLines := completed().
+		Select("m.id AS id, m.name AS name, m.category AS category, rm.quantity AS quantity, rm.total_price AS revenue").
+		Joins("JOIN reservation_menus AS rm ON rm.reservation_id = r.id").
+		Joins("JOIN menus AS m ON m.id = rm.menu_id")
+	optionLines := completed().
+		Select("o.id AS id, o.name AS name, o.category AS category, ro.quantity AS quantity, ro.total_price AS revenue").
+		Joins("JOIN reservation_options AS ro ON ro.reservation_id = r.id").
+		Joins("JOIN options AS o ON o.id = ro.option_id")
+
+	breakdowns := []struct {
+		name  string
+		query *gorm.DB
+		dest  *[]ReportRevenueItem
+	}{
+		{"menu", s.db.Raw("SELECT id, name, category, SUM(quantity) AS quantity, SUM(revenue) AS revenue FROM (?) AS report_lines GROUP BY id, name, category ORDER BY revenue DESC, name", menuLines), &report.Menus},
+		{"option", s.db.Raw("SELECT id, name, category, SUM(quantity) AS quantity, SUM(revenue) AS revenue FROM (?) AS report_lines GROUP BY id, name, category ORDER BY revenue DESC, name", optionLines), &report.Options},
+		{"category", s.db.Raw("SELECT COALESCE(NULLIF(category, ''), ?) AS name, SUM(quantity) AS quantity, SUM(revenue) AS revenue FROM (? UNION ALL ?) AS report_lines GROUP BY COALESCE(NULLIF(category, ''), ?) ORDER BY revenue DESC, name",
+			uncategorizedLabel, menuLines, optionLines, uncategorizedLabel), &report.Categories},
+	}
+	for _, breakdown := range breakdowns {
+		*breakdown.dest = []ReportRevenueItem{}
+		if err := breakdown.query.Scan(breakdown.dest).Error; err != nil {
+			utils.Log.Errorf("Failed to aggregate %s revenue: %v", breakdown.name, err)
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// WriteSalesReportCSV writes one breakdown of the report as CSV, in UTF-8 with a BOM so Excel opens it
+func WriteSalesReportCSV(w io.Writer, report *SalesReport, section string) error {
+	var records [][]string
+	switch section {
+	case "", ReportSectionPeriods:
+		header := []string{"期間開始", "売上", "完了件数", "平均客単価", "予約件数"}
+		for _, status := range reportStatuses {
+			header = append(header, reportStatusLabel(status))
+		}
+		records = append(records, header)
+		for _, bucket := range report.Periods {
+			record := []string{
+				bucket.PeriodStart,
+				strconv.FormatInt(bucket.Sales, 10),
+				strconv.FormatInt(bucket.CompletedCount, 10),
+				strconv.FormatInt(bucket.AverageTicket, 10),
+				strconv.FormatInt(bucket.Reservations, 10),
+			}
+			for _, status := range reportStatuses {
+				record = append(record, strconv.FormatInt(bucket.StatusCounts[string(status)], 10))
+			}
+			records = append(records, record)
+		}
+	case ReportSectionMenus, ReportSectionOptions:
+		items := report.Menus
+		if section == ReportSectionOptions {
+			items = report.Options
+		}
+		records = append(records, []string{"名前", "カテゴリ", "数量", "売上"})
+		for _, item := range items {
+			records = append(records, []string{csvSafe(item.Name), csvSafe(item.Category), strconv.FormatInt(item.Quantity, 10), strconv.FormatInt(item.Revenue, 10)})
+		}
+	case ReportSectionCategories:
+		records = append(records, []string{"カテゴリ", "数量", "売上"})
+		for _, item := range report.Categories {
+			records = append(records, []string{csvSafe(item.Name), strconv.FormatInt(item.Quantity, 10), strconv.FormatInt(item.Revenue, 10)})
+		}
+	default:
+		return apperror.Validation("error.invalid_section")
+	}
+
+	if _, err := w.Write([]byte("\ufeff")); err != nil {
+		return err
+	}
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+func newReportTotals() ReportTotals {
+	totals := ReportTotals{StatusCounts: map[string]int64{}}
+	for _, status := range reportStatuses {
+		totals.StatusCounts[string(status)] = 0
+	}
+	return totals
+}
+
+func (t *ReportTotals) add(status string, count, amount int64) {
+	t.Reservations += count
+	t.StatusCounts[status] += count
+	if status == string(model.ReservationStatusCompleted) {
+		t.CompletedCount += count
+		t.Sales += amount
+	}
+}
+
+func (t *ReportTotals) finish() {
+	if t.CompletedCount > 0 {
+		// Rounded to the nearest yen
+		t.AverageTicket = (t.Sales + t.CompletedCount/2) / t.CompletedCount
+	}
+}
+
+// reportBucketExpression returns the SQL expression that moves a reservation date to the first day (YYYY-MM-DD) of its period. Weeks start on Monday
+func reportBucketExpression(dialect, period string) (string, error) {
+	postgres := map[string]string{
+		ReportPeriodDaily:   "to_char(r.reservation_date, 'YYYY-MM-DD')",
+		ReportPeriodWeekly:  "to_char(date_trunc('week', r.reservation_date), 'YYYY-MM-DD')",
+		ReportPeriodMonthly: "to_char(date_trunc('month', r.reservation_date), 'YYYY-MM-DD')",
+	}
+	// SQLite is only used by the tests
+	sqlite := map[string]string{
+		ReportPeriodDaily:   "date(r.reservation_date)",
+		ReportPeriodWeekly:  "date(r.reservation_date, '-6 days', 'weekday 1')",
+		ReportPeriodMonthly: "strftime('%Y-%m-01', r.reservation_date)",
+	}
+
+	expressions := postgres
+	if dialect == "sqlite" {
+		expressions = sqlite
+	}
+	expression, ok := expressions[period]
+	if !ok {
+		return "", apperror.Validation("error.invalid_period")
+	}
+	return expression, nil
+}
+
+// reportDateRange returns the reservation dates to report on, filling omitted dates according to the period
+func reportDateRange(filter ReportFilter) (time.Time, time.Time, error) {
+	today := time.Now()
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	if filter.DateTo != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateTo
+		}
+		to = parsed
+	}
+
+	var from time.Time
+	if filter.DateFrom != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateFrom
+		}
+		from = parsed
+	} else {
+		switch filter.Period {
+		case ReportPeriodWeekly:
+			from = to.AddDate(0, 0, -7*12+1)
+		case ReportPeriodMonthly:
+			from = to.AddDate(-1, 0, 1)
+		default:
+			from = to.AddDate(0, 0, -29)
+		}
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, ErrDateRangeReversed
+	}
+	if to.Sub(from) > maxReportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, apperror.Validation("error.date_range_too_long").WithParams(i18n.Params{"days": maxReportDays})
+	}
+	return from, to, nil
+}
+
+func reportStatusLabel(status model.ReservationStatus) string {
+	switch status {
+	case model.ReservationStatusPending:
+		return "仮予約"
+	case model.ReservationStatusConfirmed:
+		return "確定"
+	case model.ReservationStatusCompleted:
+		return "完了"
+	case model.ReservationStatusCancelled:
+		return "キャンセル"
+	case model.ReservationStatusNoShow:
+		return "無断キャンセル"
+	}
+	return string(status)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// markCancelled marks a reservation as cancelled.
+// Confirmed reservations cancelled within config.LateCancelWindow hours of the start are recorded as late cancellations
+func markCancelled(reservation *model.Reservation, now time.Time) {
+	if reservation.Status == model.ReservationStatusConfirmed &&
+		reservation.StartTime.Sub(now) < time.Duration(config.LateCancelWindow)*time.Hour {
+		reservation.LateCancellation = true
+	}
+	reservation.Status = model.ReservationStatusCancelled
+	reservation.CancelledAt = &now
+}
+
+// FlagOverdueReservations flags reservations still confirmed config.NoShowGrace minutes after they started for staff review.
+// Staff decide whether it was a no-show, so the status is not changed
+func (s *ReservationService) FlagOverdueReservations() (int64, error) {
+	s, span := s.startSpan("FlagOverdueReservations")
+	defer span.End()
+
+	now := time.Now()
+	result := s.db.Model(&model.Reservation{}).
+		Where("status = ? AND start_time <= ? AND no_show_flagged_at IS NULL",
+			model.ReservationStatusConfirmed, now.Add(-time.Duration(config.NoShowGrace)*time.Minute)).
+		Update("no_show_flagged_at", now)
+	if result.Error != nil {
+		utils.Log.WithContext(s.ctx).Errorf("Failed to flag overdue reservations: %v", result.Error)
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		utils.Log.WithContext(s.ctx).Infof("Flagged %d overdue reservations for no-show review", result.RowsAffected)
+	}
+	return result.RowsAffected, nil
+}
+
+// RunNoShowReviewLoop runs FlagOverdueReservations at a fixed interval until ctx is done
+func (s *ReservationService) RunNoShowReviewLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workerHeartbeats.Beat(WorkerNoShowReview, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.FlagOverdueReservations()
+			workerHeartbeats.Beat(WorkerNoShowReview, interval)
+		}
+	}
+}
+
+// GetNoShowReview returns the reservations waiting for staff review, oldest start first.
+// They leave the list once their status is set to completed or no_show
+func (s *ReservationService) GetNoShowReview(page, limit int) ([]model.Reservation, int64, error) {
+	s, span := s.startSpan("GetNoShowReview")
+	defer span.End()
+
+	var reservations []model.Reservation
+	var total int64
+
+	query := s.db.Model(&model.Reservation{}).
+		Where("status = ? AND no_show_flagged_at IS NOT NULL", model.ReservationStatusConfirmed)
+	if err := query.Count(&total).Error; err != nil {
+		utils.Log.WithContext(s.ctx).Errorf("Failed to count reservations waiting for no-show review: %v", err)
+		return nil, 0, err
+	}
+
+	if err := query.Preload("Customer").Preload("Staff").
+		Preload("ReservationMenus.Menu").
+		Order("start_time ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reservations).Error; err != nil {
+		utils.Log.WithContext(s.ctx).Errorf("Failed to get reservations waiting for no-show review: %v", err)
+		return nil, 0, err
+	}
+
+	return reservations, total, nil
+}
+
+// GetCustomerRisk returns the customer risk shown to staff when booking
+func (s *ReservationService) GetCustomerRisk(customerID uuid.UUID) (*CustomerRisk, error) {
+	s, span := s.startSpan("GetCustomerRisk", attribute.String("customer.id", customerID.String()))
+	defer span.End()
+
+	return customerRisk(s.db, customerID)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// defaultRebookSlots is the default number of slots suggested for rebooking
+	defaultRebookSlots = 3
+	// maxRebookSlots is the most slots suggested for rebooking
+	maxRebookSlots = 10
+	// rebookSearchDays is how many days are searched for slots
+	rebookSearchDays = 30
+)
+
+// RebookItem compares a menu or option of the previous reservation with the current catalog
+type RebookItem struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	PreviousPrice int       `json:"previous_price"`
+	CurrentPrice  int       `json:"current_price"`
+	Deactivated   bool      `json:"deactivated"`
+	Repriced      bool      `json:"repriced"`
+}
+
+// RebookSlot is a slot suggested for rebooking
+type RebookSlot struct {
+	Date      string    `json:"date"`
+	StartTime string    `json:"start_time"`
+	EndTime   string    `json:"end_time"`
+	StaffID   uuid.UUID `json:"staff_id"`
+	StaffName string    `json:"staff_name"`
+}
+
+// RebookSuggestion is a suggestion to book "the same as last time".
+// MenuIDs and OptionIDs only include what is still offered, so they can be used to book as is
+type RebookSuggestion struct {
+	SourceReservationID uuid.UUID    `json:"source_reservation_id"`
+	StaffID             uuid.UUID    `json:"staff_id"`
+	StaffAvailable      bool         `json:"staff_available"`
+	Menus               []RebookItem `json:"menus"`
+	Options             []RebookItem `json:"options"`
+	MenuIDs             []uuid.UUID  `json:"menu_ids"`
+	OptionIDs           []uuid.UUID  `json:"option_ids"`
+	TotalDuration       int          `json:"total_duration"`
+	TotalPrice          int          `json:"total_price"`
+	Slots               []RebookSlot `json:"slots"`
+}
+
+// GetRebookSuggestions returns the nearest slots bookable with the same menus, options and staff as a past reservation.
+// It looks for up to limit slots from fromDate (tomorrow when omitted) that fit between windowStart and windowEnd
+func (s *ReservationService) GetRebookSuggestions(id uuid.UUID, fromDate, windowStart, windowEnd string, limit int) (*RebookSuggestion, error) {
+	s, span := s.startSpan("GetRebookSuggestions", attribute.String("reservation.id", id.String()))
+	defer span.End()
+
+	source, err := s.GetReservationByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(source.ReservationMenus) == 0 {
+		return nil, apperror.PolicyViolation("error.rebook_no_menus")
+	}
+
+	if limit <= 0 {
+		limit = defaultRebookSlots
+	}
+	if limit > maxRebookSlots {
+		limit = maxRebookSlots
+	}
+
+	// Same booking policy as CreateReservationFromRequest: tomorrow up to 90 days ahead
+	tomorrow := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	maxDate := time.Now().AddDate(0, 0, maxBookingDays)
+	startDate := tomorrow
+	if fromDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", fromDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		if parsedDate.Before(tomorrow) {
+			return nil, ErrBookingTooSoon
+		}
+		if parsedDate.After(maxDate) {
+			return nil, ErrBookingTooFar
+		}
+		startDate = parsedDate
+	}
+
+	for _, clock := range []string{windowStart, windowEnd} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04:05", clock); err != nil {
+			return nil, ErrInvalidTime
+		}
+	}
+	if windowStart != "" && windowEnd != "" && windowEnd <= windowStart {
+		return nil, ErrEndBeforeStart
+	}
+
+	suggestion := &RebookSuggestion{
+		SourceReservationID: source.ID,
+		StaffID:             source.StaffID,
+		Menus:               []RebookItem{},
+		Options:             []RebookItem{},
+		MenuIDs:             []uuid.UUID{},
+		OptionIDs:           []uuid.UUID{},
+		Slots:               []RebookSlot{},
+	}
+
+	// Compare every previous line with the current catalog
+	var menus []model.Menu
+	for _, rm := range source.ReservationMenus {
+		var menu model.Menu
+		item := RebookItem{ID: rm.MenuID, Name: rm.Menu.Name, PreviousPrice: rm.UnitPrice}
+		if err := s.db.Where("id = ?", rm.MenuID).First(&menu).Error; err != nil || !menu.IsActive {
+			item.Deactivated = true
+			suggestion.Menus = append(suggestion.Menus, item)
+			continue
+		}
+		item.Name = menu.Name
+		item.CurrentPrice = menu.Price
+		item.Repriced = menu.Price != rm.UnitPrice
+		suggestion.Menus = append(suggestion.Menus, item)
+
+		menus = append(menus, menu)
+		suggestion.MenuIDs = append(suggestion.MenuIDs, menu.ID)
+		suggestion.TotalDuration += menu.Duration
+		suggestion.TotalPrice += menu.Price
+	}
+
+	for _, ro := range source.ReservationOptions {
+		var option model.Option
+		item := RebookItem{ID: ro.OptionID, Name: ro.Option.Name, PreviousPrice: ro.UnitPrice}
+		if err := s.db.Where("id = ?", ro.OptionID).First(&option).Error; err != nil || !option.IsActive {
+			item.Deactivated = true
+			suggestion.Options = append(suggestion.Options, item)
+			continue
+		}
+		item.Name = option.Name
+		item.CurrentPrice = option.Price
+		item.Repriced = option.Price != ro.UnitPrice
+		suggestion.Options = append(suggestion.Options, item)
+
+		suggestion.OptionIDs = append(suggestion.OptionIDs, option.ID)
+		suggestion.TotalPrice += option.Price
+	}
+
+	if len(menus) == 0 {
+		return nil, apperror.PolicyViolation("error.rebook_menus_unavailable")
+	}
+
+	// Prefer the same stylist. If they have left, any qualified stylist will do
+	var staff model.Staff
+	staffIDStr := ""
+	qualified := make(map[uuid.UUID]bool)
+	if err := s.db.Where("id = ? AND is_active = ?", source.StaffID, true).First(&staff).Error; err == nil {
+		suggestion.StaffAvailable = true
+		staffIDStr = staff.ID.String()
+		qualified[staff.ID] = true
+	} else {
+		var staffList []model.Staff
+		if err := s.db.Where("is_active = ?", true).Find(&staffList).Error; err != nil {
+			return nil, err
+		}
+		for _, candidate := range staffList {
+			if isQualifiedForMenus(candidate, menus) {
+				qualified[candidate.ID] = true
+			}
+		}
+	}
+
+	for day := 0; day < rebookSearchDays && len(suggestion.Slots) < limit; day++ {
+		date := startDate.AddDate(0, 0, day)
+		if date.After(maxDate) {
+			break
+		}
+
+		availability, err := s.GetAvailability(date.Format("2006-01-02"), strconv.Itoa(suggestion.TotalDuration), staffIDStr, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var daySlots []RebookSlot
+		for _, staffSlots := range availability {
+			staffID, _ := staffSlots["staff_id"].(uuid.UUID)
+			if !qualified[staffID] {
+				continue
+			}
+			staffName, _ := staffSlots["staff_name"].(string)
+			times, _ := staffSlots["available_times"].([]map[string]interface{})
+			for _, t := range times {
+				startTime, _ := t["start_time"].(string)
+				endTime, _ := t["end_time"].(string)
+				if windowStart != "" && startTime < windowStart {
+					continue
+				}
+				if windowEnd != "" && endTime > windowEnd {
+					continue
+				}
+				daySlots = append(daySlots, RebookSlot{
+					Date:      date.Format("2006-01-02"),
+					StartTime: startTime,
+					EndTime:   endTime,
+					StaffID:   staffID,
+					StaffName: staffName,
+				})
+			}
+		}
+
+		// Offer the earliest times first when several stylists are free
+		sort.SliceStable(daySlots, func(i, j int) bool {
+			return daySlots[i].StartTime < daySlots[j].StartTime
+		})
+		for _, slot := range daySlots {
+			if len(suggestion.Slots) >= limit {
+				break
+			}
+			suggestion.Slots = append(suggestion.Slots, slot)
+		}
+	}
+
+	return suggestion, nil
+}
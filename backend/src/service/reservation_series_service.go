@@ -0,0 +1,449 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/metrics"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxSeriesOccurrences is the most reservations a series can create. The last date of a series must also be within maxBookingDays, like a single reservation
+const maxSeriesOccurrences = 26
+
+var (
+	ErrSeriesTooManyOccurrences = apperror.Validation("error.series_too_many_occurrences").WithParams(i18n.Params{"max": maxSeriesOccurrences})
+	ErrSeriesTooFar             = apperror.PolicyViolation("error.series_too_far").WithParams(i18n.Params{"days": maxBookingDays})
+)
+
+// Scopes for changing or cancelling a series
+const (
+	SeriesScopeThis      = "this"
+	SeriesScopeFollowing = "following"
+	SeriesScopeAll       = "all"
+)
+
+// SeriesConflict is a date of a series that could not be booked and why
+type SeriesConflict struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason"`
+	cause  error
+}
+
+// SeriesConflicts are the dates that could not be booked. Reasons are rendered in the request's language in the response
+type SeriesConflicts []SeriesConflict
+
+func newSeriesConflict(date time.Time, err error) SeriesConflict {
+	return SeriesConflict{Date: date.Format("2006-01-02"), Reason: err.Error(), cause: err}
+}
+
+// Localize returns a copy with the reasons in the given language
+func (c SeriesConflicts) Localize(locale string) interface{} {
+	if c == nil {
+		return nil
+	}
+	localized := make(SeriesConflicts, len(c))
+	for i, conflict := range c {
+		localized[i] = conflict
+		if conflict.cause != nil {
+			localized[i].Reason = errorMessage(conflict.cause, locale)
+		}
+	}
+	return localized
+}
+
+type ReservationSeriesService struct {
+	db                 *gorm.DB
+	validator          *validator.Validate
+	reservationService *ReservationService
+}
+
+func NewReservationSeriesService(db *gorm.DB, reservationService *ReservationService) *ReservationSeriesService {
+	return &ReservationSeriesService{
+		db:                 db,
+		validator:          validation.Validator(),
+		reservationService: reservationService,
+	}
+}
+
+func (s *ReservationSeriesService) GetSeriesByID(id uuid.UUID) (*model.ReservationSeries, error) {
+	var series model.ReservationSeries
+	if err := s.db.Preload("Customer").Preload("Staff").
+		Preload("SeriesMenus.Menu").
+		Preload("SeriesOptions.Option").
+		Preload("Reservations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("reservation_date ASC, start_time ASC")
+		}).
+		Where("id = ?", id).First(&series).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("error.series_not_found")
+		}
+		utils.Log.Errorf("Failed to get reservation series: %v", err)
+		return nil, err
+	}
+	return &series, nil
+}
+
+// CreateSeries creates a reservation series from a recurrence rule.
+// Rules with more occurrences than the limit or that run past maxBookingDays are rejected rather than truncated.
+// Unavailable dates are returned as conflicts, and nothing is created unless skipConflicts is set. The series and all its reservations are saved in one transaction.
+// As with single reservations, a series for a high-risk customer is only created when staff confirm with acknowledgeRisk
+func (s *ReservationSeriesService) CreateSeries(customerID, staffID uuid.UUID, startDate, startTime, rrule string, menuIDs, optionIDs []uuid.UUID, notes string, skipConflicts, acknowledgeRisk bool) (*model.ReservationSeries, SeriesConflicts, error) {
+	parsedDate, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, nil, ErrInvalidDate
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	if parsedDate.Before(tomorrow) {
+		return nil, nil, ErrBookingTooSoon
+	}
+
+	rule, err := utils.ParseRRule(rrule)
+	if err != nil {
+		return nil, nil, apperror.Validation("error.invalid_rrule").WithParams(i18n.Params{"detail": err.Error()})
+	}
+
+	if len(menuIDs) == 0 {
+		return nil, nil, ErrMenuRequired
+	}
+
+	var customer model.Customer
+	if err := s.db.Where("id = ? AND is_active = ?", customerID, true).First(&customer).Error; err != nil {
+		return nil, nil, ErrCustomerNotFound
+	}
+
+	var staff model.Staff
+	if err := s.db.Where("id = ? AND is_active = ?", staffID, true).First(&staff).Error; err != nil {
+		return nil, nil, ErrStaffNotFound
+	}
+
+	if !acknowledgeRisk {
+		risk, err := customerRisk(s.db, customerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if risk.Level == CustomerRiskLevelHigh {
+			return nil, nil, ErrRiskConfirmation
+		}
+	}
+
+	// Expand the rule and validate every occurrence before writing anything
+	occurrences := rule.Occurrences(parsedDate, maxSeriesOccurrences+1)
+	if len(occurrences) > maxSeriesOccurrences {
+		return nil, nil, ErrSeriesTooManyOccurrences
+	}
+	horizon := time.Now().AddDate(0, 0, maxBookingDays)
+	if len(occurrences) > 0 && occurrences[len(occurrences)-1].After(horizon) {
+		return nil, nil, ErrSeriesTooFar
+	}
+
+	var planned []*model.Reservation
+	var conflicts SeriesConflicts
+	for _, date := range occurrences {
+		reservation, err := s.reservationService.buildReservation(customerID, staffID, date, startTime, menuIDs, optionIDs, notes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := s.reservationService.checkSlot(staffID, reservation.StartTime, reservation.EndTime, uuid.Nil, false); err != nil {
+			conflicts = append(conflicts, newSeriesConflict(date, err))
+			continue
+		}
+		planned = append(planned, reservation)
+	}
+
+	if len(conflicts) > 0 && !skipConflicts {
+		return nil, conflicts, apperror.Conflict("error.series_conflicts").WithDetails(conflicts)
+	}
+	if len(planned) == 0 {
+		return nil, conflicts, apperror.Conflict("error.series_no_available").WithDetails(conflicts)
+	}
+
+	series := &model.ReservationSeries{
+		CustomerID: customerID,
+		StaffID:    staffID,
+		RRule:      rrule,
+		StartDate:  parsedDate,
+		StartTime:  startTime,
+		Status:     model.ReservationSeriesStatusActive,
+		Notes:      notes,
+	}
+	for _, menuID := range menuIDs {
+		series.SeriesMenus = append(series.SeriesMenus, model.ReservationSeriesMenu{MenuID: menuID})
+	}
+	for _, optionID := range optionIDs {
+		series.SeriesOptions = append(series.SeriesOptions, model.ReservationSeriesOption{OptionID: optionID})
+	}
+
+	if err := s.validator.Struct(series); err != nil {
+		utils.Log.Errorf("Reservation series validation failed: %v", err)
+		return nil, nil, err
+	}
+
+	var inserted []*model.Reservation
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(series).Error; err != nil {
+			utils.Log.Errorf("Failed to create reservation series: %v", err)
+			return err
+		}
+
+		for _, reservation := range planned {
+			reservation.SeriesID = &series.ID
+			reservation.Status = model.ReservationStatusConfirmed
+			if err := s.reservationService.insertReservation(tx, reservation); err != nil {
+				// Another booking may have taken the slot since validation
+				if errors.Is(err, ErrTimeSlotBooked) {
+					conflicts = append(conflicts, newSeriesConflict(reservation.ReservationDate, err))
+					continue
+				}
+				return err
+			}
+			inserted = append(inserted, reservation)
+		}
+
+		if len(conflicts) > 0 && !skipConflicts {
+			return apperror.Conflict("error.series_conflicts").WithDetails(conflicts)
+		}
+		if len(inserted) == 0 {
+			return apperror.Conflict("error.series_no_available").WithDetails(conflicts)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, apperror.Conflict("error.series_conflicts")) || errors.Is(err, apperror.Conflict("error.series_no_available")) {
+			return nil, conflicts, err
+		}
+		return nil, nil, err
+	}
+	metrics.ReservationsCreated.Add(float64(len(inserted)))
+
+	created, err := s.GetSeriesByID(series.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// One confirmation for the whole series rather than one per occurrence
+	confirmed := make([]*model.Reservation, 0, len(inserted))
+	for _, reservation := range inserted {
+		reloaded, err := s.reservationService.GetReservationByID(reservation.ID)
+		if err != nil {
+			utils.Log.Errorf("Failed to reload series reservation %s: %v", reservation.ID, err)
+			continue
+		}
+		confirmed = append(confirmed, reloaded)
+	}
+	if len(confirmed) > 0 {
+		s.reservationService.notifySeriesConfirmed(confirmed)
+	}
+	return created, conflicts, nil
+}
+
+// UpdateOccurrences changes the staff, start time and notes of the reservations in scope.
+// The date can only change with scope this. If any of them conflicts nothing changes, and the conflicting dates are returned as the error details
+func (s *ReservationSeriesService) UpdateOccurrences(seriesID, reservationID uuid.UUID, scope string, staffID uuid.UUID, reservationDate, startTime, notes string) ([]model.Reservation, error) {
+	series, err := s.GetSeriesByID(seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservationDate != "" && scope != SeriesScopeThis {
+		return nil, apperror.Validation("error.series_date_change_scope")
+	}
+
+	targets, err := s.targetOccurrences(series, reservationID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if staffID == uuid.Nil {
+		staffID = series.StaffID
+	} else {
+		var staff model.Staff
+		if err := s.db.Where("id = ? AND is_active = ?", staffID, true).First(&staff).Error; err != nil {
+			return nil, ErrStaffNotFound
+		}
+	}
+
+	var parsedStartTime *time.Time
+	if startTime != "" {
+		t, err := time.Parse("15:04:05", startTime)
+		if err != nil {
+			return nil, ErrInvalidTime
+		}
+		parsedStartTime = &t
+	}
+
+	var parsedDate *time.Time
+	if reservationDate != "" {
+		d, err := time.Parse("2006-01-02", reservationDate)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		parsedDate = &d
+	}
+
+	// Compute the new slot of every target and validate them all first
+	var conflicts SeriesConflicts
+	for i := range targets {
+		target := &targets[i]
+
+		date := target.ReservationDate
+		if parsedDate != nil {
+			date = *parsedDate
+		}
+		clock := target.StartTime
+		if parsedStartTime != nil {
+			clock = *parsedStartTime
+		}
+
+		start := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local)
+		end := start.Add(target.EndTime.Sub(target.StartTime))
+
+		if err := s.reservationService.checkSlot(staffID, start, end, target.ID, false); err != nil {
+			conflicts = append(conflicts, newSeriesConflict(date, err))
+			continue
+		}
+
+		target.StaffID = staffID
+		target.ReservationDate = date
+		target.StartTime = start
+		target.EndTime = end
+		if notes != "" {
+			target.Notes = notes
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, apperror.Conflict("error.series_conflicts").WithDetails(conflicts)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, target := range targets {
+			if err := tx.Model(&model.Reservation{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+				"staff_id":         target.StaffID,
+				"reservation_date": target.ReservationDate,
+				"start_time":       target.StartTime,
+				"end_time":         target.EndTime,
+				"notes":            target.Notes,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Changing following or all occurrences also changes the series template
+		if scope != SeriesScopeThis {
+			updates := map[string]interface{}{"staff_id": staffID}
+			if startTime != "" {
+				updates["start_time"] = startTime
+			}
+			if notes != "" {
+				updates["notes"] = notes
+			}
+			if err := tx.Model(&model.ReservationSeries{}).Where("id = ?", series.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Log.Errorf("Failed to update reservation series occurrences: %v", err)
+		return nil, err
+	}
+
+	updated, err := s.GetSeriesByID(series.ID)
+	if err != nil {
+		return nil, err
+	}
+	return updated.Reservations, nil
+}
+
+// CancelOccurrences cancels the reservations in scope and returns how many were cancelled
+func (s *ReservationSeriesService) CancelOccurrences(seriesID, reservationID uuid.UUID, scope string) (int, error) {
+	series, err := s.GetSeriesByID(seriesID)
+	if err != nil {
+		return 0, err
+	}
+
+	targets, err := s.targetOccurrences(series, reservationID, scope)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, target := range targets {
+		if err := s.reservationService.CancelReservation(target.ID); err != nil {
+			utils.Log.Errorf("Failed to cancel series occurrence %s: %v", target.ID, err)
+			continue
+		}
+		cancelled++
+	}
+
+	if scope == SeriesScopeAll {
+		if err := s.db.Model(&model.ReservationSeries{}).Where("id = ?", series.ID).
+			Update("status", model.ReservationSeriesStatusCancelled).Error; err != nil {
+			utils.Log.Errorf("Failed to cancel reservation series: %v", err)
+			return cancelled, err
+		}
+	}
+
+	return cancelled, nil
+}
+
+// targetOccurrences returns the active reservations that scope applies to
+func (s *ReservationSeriesService) targetOccurrences(series *model.ReservationSeries, reservationID uuid.UUID, scope string) ([]model.Reservation, error) {
+	var anchor *model.Reservation
+	if scope != SeriesScopeAll || reservationID != uuid.Nil {
+		for i := range series.Reservations {
+			if series.Reservations[i].ID == reservationID {
+				anchor = &series.Reservations[i]
+				break
+			}
+		}
+		if anchor == nil {
+			return nil, ErrReservationNotFound
+		}
+	}
+
+	var targets []model.Reservation
+	for _, reservation := range series.Reservations {
+		if reservation.Status == model.ReservationStatusCancelled ||
+			reservation.Status == model.ReservationStatusCompleted ||
+			reservation.Status == model.ReservationStatusNoShow {
+			continue
+		}
+
+		switch scope {
+		case SeriesScopeThis:
+			if reservation.ID != anchor.ID {
+				continue
+			}
+		case SeriesScopeFollowing:
+			if reservation.StartTime.Before(anchor.StartTime) {
+				continue
+			}
+		case SeriesScopeAll:
+		default:
+			return nil, apperror.Validation("error.invalid_scope")
+		}
+
+		targets = append(targets, reservation)
+	}
+
+	if len(targets) == 0 {
+		return nil, ErrReservationClosed
+	}
+
+	return targets, nil
+}
+
+// GetCustomerRisk returns the no-show risk of the customer of a series, shown to staff when the series is created
+func (s *ReservationSeriesService) GetCustomerRisk(customerID uuid.UUID) (*CustomerRisk, error) {
+	return customerRisk(s.db, customerID)
+}
@@ -1,93 +1,187 @@
 package service
 
 import (
+	"app/src/apperror"
+	"app/src/metrics"
 	"app/src/model"
+	"app/src/tracing"
 	"app/src/utils"
+	"app/src/validation"
+	"context"
 	"errors"
 	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
 type ReservationService struct {
-	db        *gorm.DB
-	validator *validator.Validate
+	db                   *gorm.DB
+	ctx                  context.Context
+	validator            *validator.Validate
+	slotReleaseHandlers  []SlotReleaseHandler
+	confirmationHandlers []ReservationConfirmationHandler
+}
+
+// SlotReleaseHandler is notified when a reservation slot is freed
+type SlotReleaseHandler interface {
+	OnSlotReleased(staffID uuid.UUID, date time.Time)
+}
+
+// ReservationConfirmationHandler is notified when reservations are confirmed
+type ReservationConfirmationHandler interface {
+	OnReservationConfirmed(reservation *model.Reservation)
+	// OnSeriesConfirmed is called once for all the reservations created together by a recurring series
+	OnSeriesConfirmed(reservations []*model.Reservation)
 }
 
 func NewReservationService(db *gorm.DB) *ReservationService {
 	return &ReservationService{
 		db:        db,
-		validator: validator.New(),
+		validator: validation.Validator(),
 	}
 }
 
-func (s *ReservationService) GetReservations(page, limit int, status, staffID, customerID, dateFrom, dateTo string) ([]model.Reservation, int64, error) {
-	var reservations []model.Reservation
-	var total int64
+// WithContext returns a service that carries the request context. Spans of operations and SQL are part of its trace
+func (s *ReservationService) WithContext(ctx context.Context) ReservationServiceInterface {
+	traced := *s
+	traced.ctx = ctx
+	if s.db != nil {
+		traced.db = s.db.WithContext(ctx)
+	}
+	return &traced
+}
+
+// startSpan starts the span of an operation and returns a copy of the service whose database access and internal calls use it as their parent
+func (s *ReservationService) startSpan(name string, attributes ...attribute.KeyValue) (*ReservationService, trace.Span) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := tracing.Start(ctx, "ReservationService."+name, trace.WithAttributes(attributes...))
+	return s.WithContext(ctx).(*ReservationService), span
+}
+
+// AddSlotReleaseHandler registers a handler notified when a slot is freed, such as by a cancellation
+func (s *ReservationService) AddSlotReleaseHandler(handler SlotReleaseHandler) {
+	s.slotReleaseHandlers = append(s.slotReleaseHandlers, handler)
+}
+
+func (s *ReservationService) notifySlotReleased(staffID uuid.UUID, date time.Time) {
+	for _, handler := range s.slotReleaseHandlers {
+		handler.OnSlotReleased(staffID, date)
+	}
+}
+
+// AddConfirmationHandler registers a handler notified when a reservation is confirmed (created as confirmed or confirmed from pending)
+func (s *ReservationService) AddConfirmationHandler(handler ReservationConfirmationHandler) {
+	s.confirmationHandlers = append(s.confirmationHandlers, handler)
+}
+
+func (s *ReservationService) notifyConfirmed(reservation *model.Reservation) {
+	for _, handler := range s.confirmationHandlers {
+		handler.OnReservationConfirmed(reservation)
+	}
+}
+
+func (s *ReservationService) notifySeriesConfirmed(reservations []*model.Reservation) {
+	for _, handler := range s.confirmationHandlers {
+		handler.OnSeriesConfirmed(reservations)
+	}
+}
+
+// ReservationFilter is the reservation list filter. Empty fields do not filter
+type ReservationFilter struct {
+	Status     string
+	StaffID    string
+	CustomerID string
+	DateFrom   string
+	DateTo     string
+}
+
+// reservationSortFields are the fields the reservation list can be sorted by
+var reservationSortFields = map[string]sortField[model.Reservation]{
+	"reservation_date": {
+		columns: []string{"reservation_date", "start_time", "id"},
+		values: func(r *model.Reservation) []interface{} {
+			return []interface{}{r.ReservationDate, r.StartTime, r.ID}
+		},
+	},
+	"created_at": {
+		columns: []string{"created_at", "id"},
+		values: func(r *model.Reservation) []interface{} {
+			return []interface{}{r.CreatedAt, r.ID}
+		},
+	},
+}
+
+func (s *ReservationService) GetReservations(options ListOptions, filter ReservationFilter) ([]model.Reservation, PageInfo, error) {
+	s, span := s.startSpan("GetReservations")
+	defer span.End()
 
-	offset := (page - 1) * limit
 	query := s.db.Model(&model.Reservation{})
 
 	// Apply filters
-	if status != "" {
-		query = query.Where("status = ?", status)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
 	}
-	if staffID != "" {
-		query = query.Where("staff_id = ?", staffID)
+	if filter.StaffID != "" {
+		query = query.Where("staff_id = ?", filter.StaffID)
 	}
-	if customerID != "" {
-		query = query.Where("customer_id = ?", customerID)
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
 	}
-	if dateFrom != "" {
-		query = query.Where("reservation_date >= ?", dateFrom)
+	if filter.DateFrom != "" {
+		query = query.Where("reservation_date >= ?", filter.DateFrom)
 	}
-	if dateTo != "" {
-		query = query.Where("reservation_date <= ?", dateTo)
-	}
-
-	// Count total records
-	if err := query.Count(&total).Error; err != nil {
-		utils.Log.Errorf("Failed to count reservations: %v", err)
-		return nil, 0, err
+	if filter.DateTo != "" {
+		query = query.Where("reservation_date <= ?", filter.DateTo)
 	}
 
-	// Get paginated records with preloaded relations
-	if err := query.Preload("Customer").Preload("Staff").
-		Preload("ReservationMenus.Menu").
-		Preload("ReservationOptions.Option").
-		Order("reservation_date DESC, start_time DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&reservations).Error; err != nil {
-		utils.Log.Errorf("Failed to get reservations: %v", err)
-		return nil, 0, err
+	reservations, page, err := paginate(query, options, reservationSortFields, "-reservation_date", func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Customer").Preload("Staff").
+			Preload("ReservationMenus.Menu").
+			Preload("ReservationOptions.Option")
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCursor) && !errors.Is(err, ErrInvalidSort) {
+			utils.Log.WithContext(s.ctx).Errorf("Failed to get reservations: %v", err)
+		}
+		return nil, PageInfo{}, err
 	}
 
-	return reservations, total, nil
+	return reservations, page, nil
 }
 
 func (s *ReservationService) GetReservationByID(id uuid.UUID) (*model.Reservation, error) {
+	s, span := s.startSpan("GetReservationByID", attribute.String("reservation.id", id.String()))
+	defer span.End()
+
 	var reservation model.Reservation
 	if err := s.db.Preload("Customer").Preload("Staff").
 		Preload("ReservationMenus.Menu").
 		Preload("ReservationOptions.Option").
 		Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("reservation not found")
+			return nil, ErrReservationNotFound
 		}
-		utils.Log.Errorf("Failed to get reservation: %v", err)
+		utils.Log.WithContext(s.ctx).Errorf("Failed to get reservation: %v", err)
 		return nil, err
 	}
 	return &reservation, nil
 }
 
 func (s *ReservationService) CreateReservation(reservation *model.Reservation) (*model.Reservation, error) {
+	s, span := s.startSpan("CreateReservation")
+	defer span.End()
+
 	// Validate input
 	if err := s.validator.Struct(reservation); err != nil {
-		utils.Log.Errorf("Reservation validation failed: %v", err)
+		utils.Log.WithContext(s.ctx).Errorf("Reservation validation failed: %v", err)
 		return nil, err
 	}
 
@@ -99,18 +193,43 @@ func (s *ReservationService) CreateReservation(reservation *model.Reservation) (
 		}
 	}()
 
+	if err := s.insertReservation(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		utils.Log.WithContext(s.ctx).Errorf("Failed to commit reservation transaction: %v", err)
+		return nil, err
+	}
+	metrics.ReservationsCreated.Inc()
+
+	// Reload with relations
+	created, err := s.GetReservationByID(reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if created.Status == model.ReservationStatusConfirmed {
+		s.notifyConfirmed(created)
+	}
+
+	return created, nil
+}
+
+// insertReservation checks that the customer and staff exist and that the time is free, then saves the reservation.
+// It runs inside the caller's transaction tx, and the caller commits and sends the confirmation
+func (s *ReservationService) insertReservation(tx *gorm.DB, reservation *model.Reservation) error {
 	// Validate customer exists
 	var customer model.Customer
 	if err := tx.Where("id = ? AND is_active = ?", reservation.CustomerID, true).First(&customer).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.New("customer not found")
+		return ErrCustomerNotFound
 	}
 
 	// Validate staff exists
 	var staff model.Staff
 	if err := tx.Where("id = ? AND is_active = ?", reservation.StaffID, true).First(&staff).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.New("staff not found")
+		return ErrStaffNotFound
 	}
 
 	// Check for time conflicts
@@ -124,8 +243,8 @@ func (s *ReservationService) CreateReservation(reservation *model.Reservation) (
 		reservation.StartTime,
 		reservation.EndTime,
 		reservation.EndTime).First(&conflictReservation).Error; err == nil {
-		tx.Rollback()
-		return nil, errors.New("time slot is already booked")
+		metrics.ReservationsRejected.WithLabelValues("already_booked").Inc()
+		return ErrTimeSlotBooked
 	}
 
 	// Set default status if not provided
@@ -135,25 +254,19 @@ func (s *ReservationService) CreateReservation(reservation *model.Reservation) (
 
 	// Create reservation
 	if err := tx.Create(reservation).Error; err != nil {
-		tx.Rollback()
-		utils.Log.Errorf("Failed to create reservation: %v", err)
-		return nil, err
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		utils.Log.Errorf("Failed to commit reservation transaction: %v", err)
-		return nil, err
+		utils.Log.WithContext(s.ctx).Errorf("Failed to create reservation: %v", err)
+		return err
 	}
-
-	// Reload with relations
-	return s.GetReservationByID(reservation.ID)
+	return nil
 }
 
 func (s *ReservationService) UpdateReservation(reservation *model.Reservation) (*model.Reservation, error) {
+	s, span := s.startSpan("UpdateReservation", attribute.String("reservation.id", reservation.ID.String()))
+	defer span.End()
+
 	// Validate input
 	if err := s.validator.Struct(reservation); err != nil {
-		utils.Log.Errorf("Reservation validation failed: %v", err)
+		utils.Log.WithContext(s.ctx).Errorf("Reservation validation failed: %v", err)
 		return nil, err
 	}
 
@@ -161,7 +274,7 @@ func (s *ReservationService) UpdateReservation(reservation *model.Reservation) (
 	var existingReservation model.Reservation
 	if err := s.db.Where("id = ?", reservation.ID).First(&existingReservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("reservation not found")
+			return nil, ErrReservationNotFound
 		}
 		return nil, err
 	}
@@ -169,11 +282,11 @@ func (s *ReservationService) UpdateReservation(reservation *model.Reservation) (
 	// Don't allow updating cancelled or completed reservations
 	if existingReservation.Status == model.ReservationStatusCancelled ||
 		existingReservation.Status == model.ReservationStatusCompleted {
-		return nil, errors.New("cannot update cancelled or completed reservations")
+		return nil, ErrReservationClosed
 	}
 
 	if err := s.db.Save(reservation).Error; err != nil {
-		utils.Log.Errorf("Failed to update reservation: %v", err)
+		utils.Log.WithContext(s.ctx).Errorf("Failed to update reservation: %v", err)
 		return nil, err
 	}
 
@@ -181,77 +294,148 @@ func (s *ReservationService) UpdateReservation(reservation *model.Reservation) (
 }
 
 func (s *ReservationService) CancelReservation(id uuid.UUID) error {
+	s, span := s.startSpan("CancelReservation", attribute.String("reservation.id", id.String()))
+	defer span.End()
+
 	var reservation model.Reservation
 	if err := s.db.Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("reservation not found")
+			return ErrReservationNotFound
 		}
 		return err
 	}
 
 	// Don't allow cancelling already cancelled or completed reservations
 	if reservation.Status == model.ReservationStatusCancelled {
-		return errors.New("reservation is already cancelled")
+		return ErrAlreadyCancelled
 	}
 	if reservation.Status == model.ReservationStatusCompleted {
-		return errors.New("cannot cancel completed reservation")
+		return ErrCancelCompleted
 	}
 
 	// Update status
-	reservation.Status = model.ReservationStatusCancelled
+	markCancelled(&reservation, time.Now())
 
 	if err := s.db.Save(&reservation).Error; err != nil {
-		utils.Log.Errorf("Failed to cancel reservation: %v", err)
+		utils.Log.WithContext(s.ctx).Errorf("Failed to cancel reservation: %v", err)
 		return err
 	}
+	metrics.ReservationsCancelled.Inc()
+
+	s.notifySlotReleased(reservation.StaffID, reservation.ReservationDate)
 
 	return nil
 }
 
-func (s *ReservationService) CreateReservationFromRequest(customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string) (*model.Reservation, error) {
+// CreateReservationFromRequest creates a reservation from a request.
+// When staffID is uuid.Nil (no preference) a free staff member is assigned with staffAssignment.
+// Reservations for high-risk customers are only created when staff confirm with acknowledgeRisk
+func (s *ReservationService) CreateReservationFromRequest(customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string, overrideBlockedTime bool, staffAssignment string, acknowledgeRisk bool) (*model.Reservation, error) {
+	s, span := s.startSpan("CreateReservationFromRequest")
+	defer span.End()
+
 	// Parse and validate date
 	parsedDate, err := time.Parse("2006-01-02", reservationDate)
 	if err != nil {
-		return nil, errors.New("無効な日付形式です")
+		return nil, ErrInvalidDate
 	}
 
 	// Check if date is in the future (at least tomorrow)
 	tomorrow := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
 	if parsedDate.Before(tomorrow) {
-		return nil, errors.New("予約は翌日以降の日付で設定してください")
+		return nil, ErrBookingTooSoon
 	}
 
-	// Check if date is within 90 days
-	maxDate := time.Now().AddDate(0, 0, 90)
+	// Check if date is within maxBookingDays
+	maxDate := time.Now().AddDate(0, 0, maxBookingDays)
 	if parsedDate.After(maxDate) {
-		return nil, errors.New("予約は90日以内の日付で設定してください")
+		return nil, ErrBookingTooFar
+	}
+
+	if !acknowledgeRisk {
+		risk, err := customerRisk(s.db, customerID)
+		if err != nil {
+			return nil, err
+		}
+		if risk.Level == CustomerRiskLevelHigh {
+			return nil, ErrRiskConfirmation
+		}
 	}
 
+	reservation, err := s.buildReservation(customerID, staffID, parsedDate, startTime, menuIDs, optionIDs, notes)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Status = model.ReservationStatusConfirmed
+
+	if staffID == uuid.Nil {
+		assignedStaffID, strategy, err := s.assignStaff(staffAssignment, customerID, menuIDs, reservation.StartTime, reservation.EndTime)
+		if err != nil {
+			if errors.Is(err, ErrNoStaffAvailable) {
+				metrics.ReservationsRejected.WithLabelValues("no_staff").Inc()
+			}
+			return nil, err
+		}
+		reservation.StaffID = assignedStaffID
+		reservation.StaffAutoAssigned = true
+		reservation.StaffAssignmentStrategy = strategy
+		staffID = assignedStaffID
+	}
+
+	// Staff breaks and other blocked time can only be booked over by an admin
+	if !overrideBlockedTime {
+		if err := s.checkBlockedTime(staffID, reservation.StartTime, reservation.EndTime); err != nil {
+			if errors.Is(err, ErrBlockedTimeOverlap) {
+				metrics.ReservationsRejected.WithLabelValues("blocked_time").Inc()
+			}
+			return nil, err
+		}
+	}
+
+	return s.CreateReservation(reservation)
+}
+
+// buildReservation computes the duration and price from the menus and options and builds an unsaved reservation
+func (s *ReservationService) buildReservation(customerID, staffID uuid.UUID, parsedDate time.Time, startTime string, menuIDs, optionIDs []uuid.UUID, notes string) (*model.Reservation, error) {
 	// Parse start time
 	parsedStartTime, err := time.Parse("15:04:05", startTime)
 	if err != nil {
-		return nil, errors.New("無効な時刻形式です")
+		return nil, ErrInvalidTime
 	}
 
-	// Calculate total duration from menus
+	// Calculate total duration from menus, keeping the price at booking time on each line
 	var totalDuration int
 	var totalPrice int
+	var reservationMenus []model.ReservationMenu
 	for _, menuID := range menuIDs {
 		var menu model.Menu
 		if err := s.db.Where("id = ? AND is_active = ?", menuID, true).First(&menu).Error; err != nil {
-			return nil, errors.New("選択されたメニューが見つかりません")
+			return nil, ErrMenuNotFound
 		}
 		totalDuration += menu.Duration
 		totalPrice += menu.Price
+		reservationMenus = append(reservationMenus, model.ReservationMenu{
+			MenuID:     menu.ID,
+			Quantity:   1,
+			UnitPrice:  menu.Price,
+			TotalPrice: menu.Price,
+		})
 	}
 
 	// Add option prices
+	var reservationOptions []model.ReservationOption
 	for _, optionID := range optionIDs {
 		var option model.Option
 		if err := s.db.Where("id = ? AND is_active = ?", optionID, true).First(&option).Error; err != nil {
-			return nil, errors.New("選択されたオプションが見つかりません")
+			return nil, ErrOptionNotFound
 		}
 		totalPrice += option.Price
+		reservationOptions = append(reservationOptions, model.ReservationOption{
+			OptionID:   option.ID,
+			Quantity:   1,
+			UnitPrice:  option.Price,
+			TotalPrice: option.Price,
+		})
 	}
 
 	// Calculate end time
@@ -259,21 +443,77 @@ func (s *ReservationService) CreateReservationFromRequest(customerID, staffID uu
 
 	// Create reservation
 	reservation := &model.Reservation{
-		CustomerID:      customerID,
-		StaffID:         staffID,
-		ReservationDate: parsedDate,
-		StartTime:       time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), parsedStartTime.Hour(), parsedStartTime.Minute(), parsedStartTime.Second(), 0, time.Local),
-		EndTime:         time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), endTime.Hour(), endTime.Minute(), endTime.Second(), 0, time.Local),
-		Status:          model.ReservationStatusConfirmed,
-		TotalDuration:   totalDuration,
-		TotalPrice:      totalPrice,
-		Notes:           notes,
+		CustomerID:         customerID,
+		StaffID:            staffID,
+		ReservationDate:    parsedDate,
+		StartTime:          time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), parsedStartTime.Hour(), parsedStartTime.Minute(), parsedStartTime.Second(), 0, time.Local),
+		EndTime:            time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), endTime.Hour(), endTime.Minute(), endTime.Second(), 0, time.Local),
+		TotalDuration:      totalDuration,
+		TotalPrice:         totalPrice,
+		Notes:              notes,
+		ReservationMenus:   reservationMenus,
+		ReservationOptions: reservationOptions,
+	}
+
+	return reservation, nil
+}
+
+// checkSlot checks that the time fits in the staff member's shift and does not overlap other reservations.
+// The reservation given as excludeID is ignored
+func (s *ReservationService) checkSlot(staffID uuid.UUID, start, end time.Time, excludeID uuid.UUID, overrideBlockedTime bool) error {
+	date := start.Format("2006-01-02")
+
+	var shift model.Shift
+	if err := s.db.Where("staff_id = ? AND date = ?", staffID, date).First(&shift).Error; err != nil {
+		return ErrStaffHasNoShift
+	}
+	if start.Format("15:04:05") < shift.StartTime.Format("15:04:05") ||
+		end.Format("15:04:05") > shift.EndTime.Format("15:04:05") {
+		return ErrOutsideShift
 	}
 
-	return s.CreateReservation(reservation)
+	query := s.db.Where("staff_id = ? AND reservation_date = ? AND status NOT IN (?, ?) AND start_time < ? AND end_time > ?",
+		staffID, date,
+		model.ReservationStatusCancelled, model.ReservationStatusNoShow,
+		end, start)
+	if excludeID != uuid.Nil {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var conflictReservation model.Reservation
+	if err := query.First(&conflictReservation).Error; err == nil {
+		return ErrTimeSlotBooked
+	}
+
+	if !overrideBlockedTime {
+		return s.checkBlockedTime(staffID, start, end)
+	}
+
+	return nil
 }
 
-func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string) (*model.Reservation, error) {
+// checkBlockedTime checks that the time does not overlap the staff member's blocked time, such as breaks or training
+func (s *ReservationService) checkBlockedTime(staffID uuid.UUID, start, end time.Time) error {
+	blockedTimes, err := findBlockedTimes(s.db, staffID, start)
+	if err != nil {
+		utils.Log.WithContext(s.ctx).Errorf("Failed to get blocked times: %v", err)
+		return err
+	}
+
+	for _, blockedTime := range blockedTimes {
+		blockStart, blockEnd, ok := blockedRange(blockedTime, start)
+		if ok && start.Before(blockEnd) && end.After(blockStart) {
+			return ErrBlockedTimeOverlap
+		}
+	}
+
+	return nil
+}
+
+func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string, overrideBlockedTime bool) (*model.Reservation, error) {
+	s, span := s.startSpan("UpdateReservationFromRequest", attribute.String("reservation.id", id.String()))
+	defer span.End()
+
 	// Get existing reservation
 	existingReservation, err := s.GetReservationByID(id)
 	if err != nil {
@@ -283,7 +523,7 @@ func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffI
 	// Check if can be updated (not completed or cancelled)
 	if existingReservation.Status == model.ReservationStatusCompleted ||
 		existingReservation.Status == model.ReservationStatusCancelled {
-		return nil, errors.New("cannot update cancelled or completed reservations")
+		return nil, ErrReservationClosed
 	}
 
 	// Parse and validate date if provided
@@ -291,7 +531,7 @@ func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffI
 	if reservationDate != "" {
 		parsedDate, err = time.Parse("2006-01-02", reservationDate)
 		if err != nil {
-			return nil, errors.New("無効な日付形式です")
+			return nil, ErrInvalidDate
 		}
 	} else {
 		parsedDate = existingReservation.ReservationDate
@@ -302,7 +542,7 @@ func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffI
 	if startTime != "" {
 		parsedStartTime, err = time.Parse("15:04:05", startTime)
 		if err != nil {
-			return nil, errors.New("無効な時刻形式です")
+			return nil, ErrInvalidTime
 		}
 	} else {
 		parsedStartTime = existingReservation.StartTime
@@ -323,7 +563,7 @@ func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffI
 		for _, menuID := range menuIDs {
 			var menu model.Menu
 			if err := s.db.Where("id = ? AND is_active = ?", menuID, true).First(&menu).Error; err != nil {
-				return nil, errors.New("選択されたメニューが見つかりません")
+				return nil, ErrMenuNotFound
 			}
 			totalDuration += menu.Duration
 			totalPrice += menu.Price
@@ -332,7 +572,7 @@ func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffI
 		for _, optionID := range optionIDs {
 			var option model.Option
 			if err := s.db.Where("id = ? AND is_active = ?", optionID, true).First(&option).Error; err != nil {
-				return nil, errors.New("選択されたオプションが見つかりません")
+				return nil, ErrOptionNotFound
 			}
 			totalPrice += option.Price
 		}
@@ -359,14 +599,23 @@ func (s *ReservationService) UpdateReservationFromRequest(id, customerID, staffI
 		existingReservation.Notes = notes
 	}
 
+	if !overrideBlockedTime {
+		if err := s.checkBlockedTime(staffID, existingReservation.StartTime, existingReservation.EndTime); err != nil {
+			return nil, err
+		}
+	}
+
 	return s.UpdateReservation(existingReservation)
 }
 
 func (s *ReservationService) UpdateReservationStatus(id uuid.UUID, status string) (*model.Reservation, error) {
+	s, span := s.startSpan("UpdateReservationStatus", attribute.String("reservation.id", id.String()), attribute.String("reservation.status", status))
+	defer span.End()
+
 	var reservation model.Reservation
 	if err := s.db.Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("reservation not found")
+			return nil, ErrReservationNotFound
 		}
 		return nil, err
 	}
@@ -374,7 +623,7 @@ func (s *ReservationService) UpdateReservationStatus(id uuid.UUID, status string
 	// Validate status transition
 	validTransitions := map[model.ReservationStatus][]model.ReservationStatus{
 		model.ReservationStatusPending:   {model.ReservationStatusConfirmed, model.ReservationStatusCancelled},
-		model.ReservationStatusConfirmed: {model.ReservationStatusCompleted, model.ReservationStatusCancelled},
+		model.ReservationStatusConfirmed: {model.ReservationStatusCompleted, model.ReservationStatusCancelled, model.ReservationStatusNoShow},
 	}
 
 	newStatus := model.ReservationStatus(status)
@@ -387,33 +636,56 @@ func (s *ReservationService) UpdateReservationStatus(id uuid.UUID, status string
 			}
 		}
 		if !valid {
-			return nil, errors.New("invalid status transition")
+			return nil, ErrInvalidStatusChange
 		}
 	} else {
-		return nil, errors.New("invalid status transition")
+		return nil, ErrInvalidStatusChange
 	}
 
 	// Update status
-	reservation.Status = newStatus
+	if newStatus == model.ReservationStatusCancelled {
+		markCancelled(&reservation, time.Now())
+	} else {
+		reservation.Status = newStatus
+	}
 	if err := s.db.Save(&reservation).Error; err != nil {
-		utils.Log.Errorf("Failed to update reservation status: %v", err)
+		utils.Log.WithContext(s.ctx).Errorf("Failed to update reservation status: %v", err)
 		return nil, err
 	}
+	if newStatus == model.ReservationStatusCancelled {
+		metrics.ReservationsCancelled.Inc()
+	}
 
-	return s.GetReservationByID(reservation.ID)
+	updated, err := s.GetReservationByID(reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Status == model.ReservationStatusConfirmed {
+		s.notifyConfirmed(updated)
+	}
+
+	return updated, nil
 }
 
 func (s *ReservationService) GetAvailability(date, durationStr, staffIDStr, menuIDsStr string) ([]map[string]interface{}, error) {
+	s, span := s.startSpan("GetAvailability", attribute.String("availability.date", date))
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.AvailabilityDuration.Observe(time.Since(start).Seconds())
+	}()
+
 	// Parse date
 	parsedDate, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		return nil, errors.New("無効な日付形式です")
+		return nil, ErrInvalidDate
 	}
 
 	// Parse duration
 	duration, err := strconv.Atoi(durationStr)
 	if err != nil {
-		return nil, errors.New("無効な時間形式です")
+		return nil, apperror.Validation("error.invalid_duration")
 	}
 
 	// Get staff list
@@ -423,7 +695,7 @@ func (s *ReservationService) GetAvailability(date, durationStr, staffIDStr, menu
 	if staffIDStr != "" {
 		staffID, err := uuid.Parse(staffIDStr)
 		if err != nil {
-			return nil, errors.New("無効なスタッフIDです")
+			return nil, apperror.Validation("error.invalid_staff_id")
 		}
 		query = query.Where("id = ?", staffID)
 	}
@@ -435,21 +707,11 @@ func (s *ReservationService) GetAvailability(date, durationStr, staffIDStr, menu
 	var availableSlots []map[string]interface{}
 
 	for _, staff := range staffList {
-		// Check if staff has shift for this date
-		var shift model.Shift
-		if err := s.db.Where("staff_id = ? AND date = ?", staff.ID, parsedDate.Format("2006-01-02")).First(&shift).Error; err != nil {
-			continue // No shift for this staff on this date
+		availableTimes, err := s.staffAvailableTimes(staff, parsedDate, duration)
+		if err != nil {
+			return nil, err
 		}
 
-		// Get existing reservations for this staff on this date
-		var reservations []model.Reservation
-		s.db.Where("staff_id = ? AND reservation_date = ? AND status NOT IN (?, ?)",
-			staff.ID, parsedDate.Format("2006-01-02"),
-			model.ReservationStatusCancelled, model.ReservationStatusNoShow).Find(&reservations)
-
-		// Generate available time slots
-		availableTimes := s.calculateAvailableTimes(shift, reservations, duration)
-
 		if len(availableTimes) > 0 {
 			availableSlots = append(availableSlots, map[string]interface{}{
 				"staff_id":         staff.ID,
@@ -462,7 +724,34 @@ func (s *ReservationService) GetAvailability(date, durationStr, staffIDStr, menu
 	return availableSlots, nil
 }
 
-func (s *ReservationService) calculateAvailableTimes(shift model.Shift, reservations []model.Reservation, duration int) []map[string]interface{} {
+// staffAvailableTimes returns the free times of one staff member, in a span per staff member so slow ones stand out
+func (s *ReservationService) staffAvailableTimes(staff model.Staff, parsedDate time.Time, duration int) ([]map[string]interface{}, error) {
+	s, span := s.startSpan("staffAvailableTimes", attribute.String("staff.id", staff.ID.String()))
+	defer span.End()
+
+	// Check if staff has shift for this date
+	var shift model.Shift
+	if err := s.db.Where("staff_id = ? AND date = ?", staff.ID, parsedDate.Format("2006-01-02")).First(&shift).Error; err != nil {
+		return nil, nil // No shift for this staff on this date
+	}
+
+	// Get existing reservations for this staff on this date
+	var reservations []model.Reservation
+	s.db.Where("staff_id = ? AND reservation_date = ? AND status NOT IN (?, ?)",
+		staff.ID, parsedDate.Format("2006-01-02"),
+		model.ReservationStatusCancelled, model.ReservationStatusNoShow).Find(&reservations)
+
+	// Breaks, training and other blocked time are never offered
+	blockedTimes, err := findBlockedTimes(s.db, staff.ID, parsedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate available time slots
+	return s.calculateAvailableTimes(shift, reservations, blockedTimes, duration), nil
+}
+
+func (s *ReservationService) calculateAvailableTimes(shift model.Shift, reservations []model.Reservation, blockedTimes []model.BlockedTime, duration int) []map[string]interface{} {
 	var availableTimes []map[string]interface{}
 	
 	// Use shift times directly
@@ -491,6 +780,14 @@ func (s *ReservationService) calculateAvailableTimes(shift model.Shift, reservat
 				break
 			}
 		}
+
+		for _, blockedTime := range blockedTimes {
+			blockStart, blockEnd, ok := blockedRange(blockedTime, shiftStart)
+			if ok && current.Before(blockEnd) && slotEnd.After(blockStart) {
+				conflicts = true
+				break
+			}
+		}
 		
 		if !conflicts {
 			availableTimes = append(availableTimes, map[string]interface{}{
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How staff are assigned to "no preference" reservations
+const (
+	StaffAssignmentLeastBooked     = "least_booked"
+	StaffAssignmentRoundRobin      = "round_robin"
+	StaffAssignmentPreviousStylist = "previous_stylist"
+)
+
+// assignStaff picks a free staff member qualified for start to end according to strategy
+func (s *ReservationService) assignStaff(strategy string, customerID uuid.UUID, menuIDs []uuid.UUID, start, end time.Time) (uuid.UUID, string, error) {
+	if strategy == "" {
+		strategy = StaffAssignmentLeastBooked
+	}
+	if strategy != StaffAssignmentLeastBooked && strategy != StaffAssignmentRoundRobin && strategy != StaffAssignmentPreviousStylist {
+		return uuid.Nil, "", apperror.Validation("error.invalid_staff_assignment")
+	}
+
+	var menus []model.Menu
+	if err := s.db.Where("id IN ?", menuIDs).Find(&menus).Error; err != nil {
+		return uuid.Nil, "", err
+	}
+
+	var staffList []model.Staff
+	if err := s.db.Where("is_active = ?", true).Order("name ASC, id ASC").Find(&staffList).Error; err != nil {
+		return uuid.Nil, "", err
+	}
+
+	var candidates []model.Staff
+	for _, staff := range staffList {
+		if !isQualifiedForMenus(staff, menus) {
+			continue
+		}
+		if err := s.checkSlot(staff.ID, start, end, uuid.Nil, false); err != nil {
+			continue
+		}
+		candidates = append(candidates, staff)
+	}
+	if len(candidates) == 0 {
+		return uuid.Nil, "", ErrNoStaffAvailable
+	}
+
+	switch strategy {
+	case StaffAssignmentPreviousStylist:
+		var previous model.Reservation
+		if err := s.db.Where("customer_id = ? AND status IN ?", customerID,
+			[]model.ReservationStatus{model.ReservationStatusCompleted, model.ReservationStatusConfirmed}).
+			Order("reservation_date DESC, start_time DESC").
+			First(&previous).Error; err == nil {
+			for _, staff := range candidates {
+				if staff.ID == previous.StaffID {
+					return staff.ID, strategy, nil
+				}
+			}
+		}
+		// The previous stylist is not free, fall back to spreading the load
+		return s.leastBookedStaff(candidates, start), StaffAssignmentLeastBooked, nil
+	case StaffAssignmentRoundRobin:
+		return s.nextRoundRobinStaff(staffList, candidates), strategy, nil
+	default:
+		return s.leastBookedStaff(candidates, start), strategy, nil
+	}
+}
+
+// leastBookedStaff returns the staff member with the fewest reservations that day
+func (s *ReservationService) leastBookedStaff(candidates []model.Staff, day time.Time) uuid.UUID {
+	counts := make(map[uuid.UUID]int64, len(candidates))
+	for _, staff := range candidates {
+		var count int64
+		s.db.Model(&model.Reservation{}).
+			Where("staff_id = ? AND reservation_date = ? AND status NOT IN (?, ?)",
+				staff.ID, day.Format("2006-01-02"),
+				model.ReservationStatusCancelled, model.ReservationStatusNoShow).
+			Count(&count)
+		counts[staff.ID] = count
+	}
+
+	sorted := append([]model.Staff(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return counts[sorted[i].ID] < counts[sorted[j].ID]
+	})
+	return sorted[0].ID
+}
+
+// nextRoundRobinStaff returns the candidate after the staff member assigned most recently
+func (s *ReservationService) nextRoundRobinStaff(staffList, candidates []model.Staff) uuid.UUID {
+	var last model.Reservation
+	lastIndex := -1
+	if err := s.db.Where("staff_auto_assigned = ?", true).Order("created_at DESC").First(&last).Error; err == nil {
+		for i, staff := range staffList {
+			if staff.ID == last.StaffID {
+				lastIndex = i
+				break
+			}
+		}
+	}
+
+	free := make(map[uuid.UUID]bool, len(candidates))
+	for _, staff := range candidates {
+		free[staff.ID] = true
+	}
+	for offset := 1; offset <= len(staffList); offset++ {
+		staff := staffList[(lastIndex+offset)%len(staffList)]
+		if free[staff.ID] {
+			return staff.ID
+		}
+	}
+	return candidates[0].ID
+}
+
+// isQualifiedForMenus reports whether the staff member's specialties (comma separated menu categories) cover every menu.
+// Staff without specialties can do every menu
+func isQualifiedForMenus(staff model.Staff, menus []model.Menu) bool {
+	if strings.TrimSpace(staff.Specialties) == "" {
+		return true
+	}
+
+	specialties := make(map[string]bool)
+	for _, specialty := range strings.FieldsFunc(staff.Specialties, func(r rune) bool { return r == ',' || r == '、' }) {
+		specialties[strings.TrimSpace(specialty)] = true
+	}
+
+	for _, menu := range menus {
+		if menu.Category != "" && !specialties[menu.Category] {
+			return false
+		}
+	}
+	return true
+}
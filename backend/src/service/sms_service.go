@@ -0,0 +1,80 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/metrics"
+	"app/src/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SMSService sends SMS to a customer's phone number (E.164)
+type SMSService interface {
+	SendSMS(to, body string) error
+}
+
+// ErrSMSNotConfigured means SMS cannot be sent because SMS_PROVIDER is not set
+var ErrSMSNotConfigured = errors.New("sms provider is not configured")
+
+// twilioAPIURL is the base URL of the Twilio Messages API
+var twilioAPIURL = "https://api.twilio.com/2010-04-01"
+
+// NewSMSService returns the sender for config.SMSProvider. When unset it always returns ErrSMSNotConfigured
+func NewSMSService() SMSService {
+	if config.SMSProvider == "twilio" {
+		return &twilioSMSService{
+			client:     &http.Client{Timeout: 10 * time.Second},
+			accountSID: config.TwilioAccountSID,
+			authToken:  config.TwilioAuthToken,
+			from:       config.SMSFrom,
+		}
+	}
+	return disabledSMSService{}
+}
+
+type disabledSMSService struct{}
+
+func (disabledSMSService) SendSMS(to, body string) error {
+	metrics.Notifications.WithLabelValues("sms", "failed").Inc()
+	return ErrSMSNotConfigured
+}
+
+// twilioSMSService sends SMS with the Twilio Messages API
+type twilioSMSService struct {
+	client     *http.Client
+	accountSID string
+	authToken  string
+	from       string
+}
+
+func (s *twilioSMSService) SendSMS(to, body string) error {
+	form := url.Values{"To": {to}, "From": {s.from}, "Body": {body}}
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIURL, url.PathEscape(s.accountSID))
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		utils.Log.Errorf("Failed to send SMS: %v", err)
+		metrics.Notifications.WithLabelValues("sms", "failed").Inc()
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		utils.Log.Errorf("Failed to send SMS: twilio responded with status %d", resp.StatusCode)
+		metrics.Notifications.WithLabelValues("sms", "failed").Inc()
+		return fmt.Errorf("twilio responded with status %d", resp.StatusCode)
+	}
+
+	metrics.Notifications.WithLabelValues("sms", "sent").Inc()
+	return nil
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WaitlistService struct {
+	db                 *gorm.DB
+	validator          *validator.Validate
+	reservationService *ReservationService
+	emailService       EmailService
+	smsService         SMSService
+}
+
+func NewWaitlistService(db *gorm.DB, reservationService *ReservationService, emailService EmailService, smsService SMSService) *WaitlistService {
+	return &WaitlistService{
+		db:                 db,
+		validator:          validation.Validator(),
+		reservationService: reservationService,
+		emailService:       emailService,
+		smsService:         smsService,
+	}
+}
+
+func (s *WaitlistService) GetEntries(page, limit int, status, customerID, date string) ([]model.WaitlistEntry, int64, error) {
+	var entries []model.WaitlistEntry
+	var total int64
+
+	offset := (page - 1) * limit
+	query := s.db.Model(&model.WaitlistEntry{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if date != "" {
+		query = query.Where("desired_date = ?", date)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		utils.Log.Errorf("Failed to count waitlist entries: %v", err)
+		return nil, 0, err
+	}
+
+	if err := query.Preload("Customer").Preload("Staff").
+		Preload("WaitlistMenus.Menu").
+		Order("desired_date ASC, created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		utils.Log.Errorf("Failed to get waitlist entries: %v", err)
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (s *WaitlistService) GetEntryByID(id uuid.UUID) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	if err := s.db.Preload("Customer").Preload("Staff").
+		Preload("WaitlistMenus.Menu").
+		Preload("Reservation").
+		Where("id = ?", id).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWaitlistEntryNotFound
+		}
+		utils.Log.Errorf("Failed to get waitlist entry: %v", err)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *WaitlistService) CreateEntry(customerID uuid.UUID, staffID *uuid.UUID, desiredDate, windowStart, windowEnd string, menuIDs []uuid.UUID, notes string) (*model.WaitlistEntry, error) {
+	parsedDate, err := time.Parse("2006-01-02", desiredDate)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	if parsedDate.Before(tomorrow) {
+		return nil, apperror.Validation("error.waitlist_too_soon")
+	}
+
+	// Validate the optional time window
+	for _, t := range []string{windowStart, windowEnd} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04:05", t); err != nil {
+			return nil, ErrInvalidTime
+		}
+	}
+	if windowStart != "" && windowEnd != "" && windowStart >= windowEnd {
+		return nil, apperror.Validation("error.window_end_before_start")
+	}
+
+	if len(menuIDs) == 0 {
+		return nil, ErrMenuRequired
+	}
+
+	var customer model.Customer
+	if err := s.db.Where("id = ? AND is_active = ?", customerID, true).First(&customer).Error; err != nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	if staffID != nil {
+		var staff model.Staff
+		if err := s.db.Where("id = ? AND is_active = ?", *staffID, true).First(&staff).Error; err != nil {
+			return nil, ErrStaffNotFound
+		}
+	}
+
+	entry := &model.WaitlistEntry{
+		CustomerID:  customerID,
+		StaffID:     staffID,
+		DesiredDate: parsedDate,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		Status:      model.WaitlistStatusWaiting,
+		Notes:       notes,
+	}
+	for _, menuID := range menuIDs {
+		var menu model.Menu
+		if err := s.db.Where("id = ? AND is_active = ?", menuID, true).First(&menu).Error; err != nil {
+			return nil, ErrMenuNotFound
+		}
+		entry.WaitlistMenus = append(entry.WaitlistMenus, model.WaitlistMenu{MenuID: menuID})
+	}
+
+	if err := s.validator.Struct(entry); err != nil {
+		utils.Log.Errorf("Waitlist entry validation failed: %v", err)
+		return nil, err
+	}
+
+	if err := s.db.Create(entry).Error; err != nil {
+		utils.Log.Errorf("Failed to create waitlist entry: %v", err)
+		return nil, err
+	}
+
+	return s.GetEntryByID(entry.ID)
+}
+
+// CancelEntry cancels a waitlist entry. A slot being offered to it is released to the next in line
+func (s *WaitlistService) CancelEntry(id uuid.UUID) error {
+	entry, err := s.GetEntryByID(id)
+	if err != nil {
+		return err
+	}
+
+	if entry.Status != model.WaitlistStatusWaiting && entry.Status != model.WaitlistStatusOffered {
+		return apperror.PolicyViolation("error.waitlist_entry_inactive")
+	}
+
+	wasOffered := entry.Status == model.WaitlistStatusOffered
+	if err := s.db.Model(&model.WaitlistEntry{}).Where("id = ?", entry.ID).
+		Update("status", model.WaitlistStatusCancelled).Error; err != nil {
+		utils.Log.Errorf("Failed to cancel waitlist entry: %v", err)
+		return err
+	}
+
+	if wasOffered && entry.ReservationID != nil {
+		return s.reservationService.CancelReservation(*entry.ReservationID)
+	}
+
+	return nil
+}
+
+// GetOffer returns the entry with an open offer, shown on the offer page. It changes nothing
+func (s *WaitlistService) GetOffer(token string) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	if err := s.db.Preload("Reservation.Staff").Preload("Reservation.ReservationMenus.Menu").
+		Where("offer_token = ? AND status = ?", token, model.WaitlistStatusOffered).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("error.offer_not_found")
+		}
+		return nil, err
+	}
+
+	if entry.OfferExpiresAt == nil || time.Now().After(*entry.OfferExpiresAt) || entry.Reservation == nil {
+		return nil, apperror.Gone("error.offer_expired").WithCode("OFFER_EXPIRED")
+	}
+	return &entry, nil
+}
+
+// AcceptOffer accepts the offered slot and confirms the held reservation
+func (s *WaitlistService) AcceptOffer(token string) (*model.Reservation, error) {
+	var entry model.WaitlistEntry
+	if err := s.db.Where("offer_token = ? AND status = ?", token, model.WaitlistStatusOffered).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("error.offer_not_found")
+		}
+		return nil, err
+	}
+
+	if entry.OfferExpiresAt == nil || time.Now().After(*entry.OfferExpiresAt) || entry.ReservationID == nil {
+		if err := s.expireEntry(&entry); err != nil {
+			return nil, err
+		}
+		return nil, apperror.Gone("error.offer_expired").WithCode("OFFER_EXPIRED")
+	}
+
+	reservation, err := s.reservationService.UpdateReservationStatus(*entry.ReservationID, string(model.ReservationStatusConfirmed))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&model.WaitlistEntry{}).Where("id = ?", entry.ID).
+		Update("status", model.WaitlistStatusAccepted).Error; err != nil {
+		utils.Log.Errorf("Failed to accept waitlist offer: %v", err)
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// OnSlotReleased is called when a reservation is cancelled and offers the freed slot to the waitlist
+func (s *WaitlistService) OnSlotReleased(staffID uuid.UUID, date time.Time) {
+	if _, err := s.MatchFreedSlot(staffID, date); err != nil {
+		utils.Log.Errorf("Failed to match waitlist for released slot: %v", err)
+	}
+}
+
+// MatchWaitingEntries assigns the free slots of all staff to the waitlist, for each date with waiting entries.
+// This also offers slots freed other than by cancellations, such as longer shifts or deleted blocked times
+func (s *WaitlistService) MatchWaitingEntries() error {
+	var dates []time.Time
+	if err := s.db.Model(&model.WaitlistEntry{}).
+		Where("status = ? AND desired_date >= ?", model.WaitlistStatusWaiting, time.Now().Format("2006-01-02")).
+		Distinct("desired_date").
+		Pluck("desired_date", &dates).Error; err != nil {
+		utils.Log.Errorf("Failed to find waitlist dates: %v", err)
+		return err
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	var staffIDs []uuid.UUID
+	if err := s.db.Model(&model.Staff{}).Where("is_active = ?", true).Pluck("id", &staffIDs).Error; err != nil {
+		utils.Log.Errorf("Failed to find staff for waitlist matching: %v", err)
+		return err
+	}
+
+	for _, date := range dates {
+		for _, staffID := range staffIDs {
+			// A freed range may fit more than one entry, keep offering until nobody fits
+			for {
+				entry, err := s.MatchFreedSlot(staffID, date)
+				if err != nil {
+					utils.Log.Errorf("Failed to match waitlist for staff %s on %s: %v", staffID, date.Format("2006-01-02"), err)
+					break
+				}
+				if entry == nil {
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MatchFreedSlot assigns the free slots of a staff member on a date to the waitlist in sign-up order.
+// It returns the entry that got an offer, or nil when nobody matched
+func (s *WaitlistService) MatchFreedSlot(staffID uuid.UUID, date time.Time) (*model.WaitlistEntry, error) {
+	dateStr := date.Format("2006-01-02")
+
+	var entries []model.WaitlistEntry
+	if err := s.db.Preload("Customer").Preload("WaitlistMenus.Menu").
+		Where("status = ? AND desired_date = ? AND (staff_id IS NULL OR staff_id = ?)",
+			model.WaitlistStatusWaiting, dateStr, staffID).
+		Order("created_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		startTime, found, err := s.findSlot(entry, staffID, dateStr)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+
+		if err := s.offer(entry, staffID, date, startTime); err != nil {
+			utils.Log.Errorf("Failed to offer slot to waitlist entry %s: %v", entry.ID, err)
+			continue
+		}
+		return entry, nil
+	}
+
+	return nil, nil
+}
+
+// findSlot finds the first free start time within the entry's preferred time range
+func (s *WaitlistService) findSlot(entry *model.WaitlistEntry, staffID uuid.UUID, date string) (string, bool, error) {
+	var duration int
+	for _, wm := range entry.WaitlistMenus {
+		duration += wm.Menu.Duration
+	}
+	if duration == 0 {
+		return "", false, nil
+	}
+
+	slots, err := s.reservationService.GetAvailability(date, strconv.Itoa(duration), staffID.String(), "")
+	if err != nil {
+		return "", false, err
+	}
+
+	for _, slot := range slots {
+		times, _ := slot["available_times"].([]map[string]interface{})
+		for _, t := range times {
+			start, _ := t["start_time"].(string)
+			end, _ := t["end_time"].(string)
+			if entry.WindowStart != "" && start < entry.WindowStart {
+				continue
+			}
+			if entry.WindowEnd != "" && end > entry.WindowEnd {
+				continue
+			}
+			return start, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// offer holds the slot as a pending reservation and sends an offer with an acceptance deadline
+func (s *WaitlistService) offer(entry *model.WaitlistEntry, staffID uuid.UUID, date time.Time, startTime string) error {
+	menuIDs := make([]uuid.UUID, 0, len(entry.WaitlistMenus))
+	for _, wm := range entry.WaitlistMenus {
+		menuIDs = append(menuIDs, wm.MenuID)
+	}
+
+	reservation, err := s.reservationService.buildReservation(entry.CustomerID, staffID, date, startTime, menuIDs, nil, entry.Notes)
+	if err != nil {
+		return err
+	}
+	reservation.Status = model.ReservationStatusPending
+
+	held, err := s.reservationService.CreateReservation(reservation)
+	if err != nil {
+		return err
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(time.Duration(config.WaitlistOfferHold) * time.Minute)
+
+	entry.Status = model.WaitlistStatusOffered
+	entry.OfferToken = token
+	entry.OfferExpiresAt = &expiresAt
+	entry.ReservationID = &held.ID
+	if err := s.db.Model(&model.WaitlistEntry{}).Where("id = ?", entry.ID).Updates(map[string]interface{}{
+		"status":           entry.Status,
+		"offer_token":      entry.OfferToken,
+		"offer_expires_at": entry.OfferExpiresAt,
+		"reservation_id":   entry.ReservationID,
+	}).Error; err != nil {
+		return err
+	}
+
+	s.sendOfferNotification(entry, held)
+
+	return nil
+}
+
+// sendOfferNotification sends the offer by email or SMS.
+// The notification log keeps only the result and the message key, never the offer page link (token)
+func (s *WaitlistService) sendOfferNotification(entry *model.WaitlistEntry, reservation *model.Reservation) {
+	offerURL := fmt.Sprintf("%s/v1/waitlist/offers/%s", config.AppURL, entry.OfferToken)
+	locale := entry.Customer.Locale
+	subject := i18n.T(locale, "notification.waitlist_offer.subject", nil)
+	message := i18n.T(locale, "notification.waitlist_offer.body", i18n.Params{
+		"name":       entry.Customer.Name,
+		"date":       reservation.ReservationDate.Format("2006-01-02"),
+		"time":       reservation.StartTime.Format("15:04"),
+		"expires_at": entry.OfferExpiresAt.Format("2006-01-02 15:04"),
+		"link":       offerURL,
+	})
+
+	notificationType, recipient := notificationChannel(s.db, entry.Customer)
+
+	var err error
+	if notificationType == "email" {
+		err = s.emailService.SendEmail(recipient, subject, message)
+	} else {
+		err = s.smsService.SendSMS(recipient, message)
+	}
+
+	now := time.Now()
+	notification := &model.NotificationLog{
+		CustomerID: &entry.CustomerID,
+		Type:       notificationType,
+		Recipient:  recipient,
+		Subject:    subject,
+		Message:    "notification.waitlist_offer.body",
+		Status:     model.NotificationStatusSent,
+		SentAt:     &now,
+	}
+	if err != nil {
+		utils.Log.Errorf("Failed to send waitlist offer for entry %s: %v", entry.ID, err)
+		notification.Status = model.NotificationStatusFailed
+		notification.ErrorMessage = err.Error()
+		notification.SentAt = nil
+	}
+	if err := s.db.Create(notification).Error; err != nil {
+		utils.Log.Errorf("Failed to log waitlist offer notification: %v", err)
+	}
+}
+
+// ExpireOffers expires offers past their deadline and passes the held slots to the next in line
+func (s *WaitlistService) ExpireOffers() error {
+	var entries []model.WaitlistEntry
+	if err := s.db.Where("status = ? AND offer_expires_at < ?", model.WaitlistStatusOffered, time.Now()).
+		Order("offer_expires_at ASC").
+		Find(&entries).Error; err != nil {
+		utils.Log.Errorf("Failed to find expired waitlist offers: %v", err)
+		return err
+	}
+
+	for i := range entries {
+		if err := s.expireEntry(&entries[i]); err != nil {
+			utils.Log.Errorf("Failed to expire waitlist offer %s: %v", entries[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *WaitlistService) expireEntry(entry *model.WaitlistEntry) error {
+	if err := s.db.Model(&model.WaitlistEntry{}).Where("id = ?", entry.ID).
+		Update("status", model.WaitlistStatusExpired).Error; err != nil {
+		return err
+	}
+
+	if entry.ReservationID != nil {
+		return s.reservationService.CancelReservation(*entry.ReservationID)
+	}
+	return nil
+}
+
+// RunOfferLoop expires overdue offers and assigns free slots to the waitlist at a fixed interval until ctx is done
+func (s *WaitlistService) RunOfferLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	workerHeartbeats.Beat(WorkerWaitlistOffers, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.ExpireOffers()
+			_ = s.MatchWaitingEntries()
+			workerHeartbeats.Beat(WorkerWaitlistOffers, interval)
+		}
+	}
+}
+
+// generateRandomToken generates a hard to guess token sent in URLs
+func generateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// staleHeartbeatIntervals is how many intervals a worker may miss before it is reported as stopped
+const staleHeartbeatIntervals = 2
+
+// Names of the background workers started by the router
+const (
+	WorkerWaitlistOffers = "waitlist_offers"
+	WorkerNoShowReview   = "no_show_review"
+)
+
+// WorkerHeartbeats records when each background worker last ran, so that readiness
+// fails when a worker has stopped or is stuck
+type WorkerHeartbeats struct {
+	mu      sync.Mutex
+	workers map[string]workerHeartbeat
+}
+
+type workerHeartbeat struct {
+	lastRun  time.Time
+	interval time.Duration
+}
+
+func NewWorkerHeartbeats() *WorkerHeartbeats {
+	return &WorkerHeartbeats{workers: map[string]workerHeartbeat{}}
+}
+
+// workerHeartbeats is shared by the worker loops and the readiness check
+var workerHeartbeats = NewWorkerHeartbeats()
+
+// Beat records that the worker ran now. interval is how often it is expected to run
+func (h *WorkerHeartbeats) Beat(name string, interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workers[name] = workerHeartbeat{lastRun: time.Now(), interval: interval}
+}
+
+// Check returns an error naming the workers that have not run for staleHeartbeatIntervals intervals.
+// Workers that were never started, such as when there is no database, are not checked
+func (h *WorkerHeartbeats) Check(now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var stale []string
+	for name, beat := range h.workers {
+		if now.Sub(beat.lastRun) > staleHeartbeatIntervals*beat.interval {
+			stale = append(stale, fmt.Sprintf("%s (last run %s)", name, beat.lastRun.Format(time.RFC3339)))
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return fmt.Errorf("background workers stopped: %v", stale)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// logHook adds the trace and span IDs of ctx to logs written with utils.Log.WithContext(ctx)
+type logHook struct{}
+
+func (logHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (logHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if spanContext.HasTraceID() {
+		entry.Data["trace_id"] = spanContext.TraceID().String()
+	}
+	if spanContext.HasSpanID() {
+		entry.Data["span_id"] = spanContext.SpanID().String()
+	}
+	return nil
+}
@@ -1,26 +1,18 @@
 package utils
 
 import (
+	"app/src/apperror"
 	"app/src/response"
-	"app/src/validation"
-	"errors"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ErrorHandler turns errors returned by handlers and middleware into the common error response.
+// The cause of internal errors goes to the access log and is not returned to the client
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	if errorsMap := validation.CustomErrorMessages(err); len(errorsMap) > 0 {
-		return response.Error(c, fiber.StatusBadRequest, "Bad Request", errorsMap)
-	}
-
-	var fiberErr *fiber.Error
-	if errors.As(err, &fiberErr) {
-		return response.Error(c, fiberErr.Code, fiberErr.Message, nil)
-	}
-
-	return response.Error(c, fiber.StatusInternalServerError, "Internal Server Error", nil)
+	return response.Error(c, err)
 }
 
 func NotFoundHandler(c *fiber.Ctx) error {
-	return response.Error(c, fiber.StatusNotFound, "Endpoint Not Found", nil)
+	return response.Error(c, apperror.NotFound("error.endpoint_not_found"))
 }
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// encryptedFieldPrefix starts every encrypted value, followed by the key version as "v<number>:"
+const encryptedFieldPrefix = "enc:v"
+
+// fieldKeyring holds the master keys loaded from config (one per key version) and the blind index key
+type fieldKeyring struct {
+	masterKeys map[int][]byte
+	current    int
+	indexKey   []byte
+}
+
+var fieldKeys *fieldKeyring
+
+// InitFieldEncryption sets the keys used for field encryption.
+// masterKeys are AES-256 keys (32 bytes) by key version, and new values are encrypted with the current key.
+// Keys of older versions are kept after a rotation to decrypt existing values
+func InitFieldEncryption(masterKeys map[int][]byte, current int, indexKey []byte) error {
+	for version, key := range masterKeys {
+		if len(key) != 32 {
+			return fmt.Errorf("field encryption key version %d must be 32 bytes", version)
+		}
+	}
+	if _, ok := masterKeys[current]; !ok {
+		return fmt.Errorf("field encryption key version %d is not configured", current)
+	}
+	if len(indexKey) < 32 {
+		return errors.New("blind index key must be at least 32 bytes")
+	}
+
+	fieldKeys = &fieldKeyring{masterKeys: masterKeys, current: current, indexKey: indexKey}
+	return nil
+}
+
+// CurrentFieldKeyVersion returns the key version used to encrypt new values
+func CurrentFieldKeyVersion() int {
+	if fieldKeys == nil {
+		return 0
+	}
+	return fieldKeys.current
+}
+
+// EncryptedFieldPrefix returns the prefix of values encrypted with the given key version
+func EncryptedFieldPrefix(version int) string {
+	return encryptedFieldPrefix + strconv.Itoa(version) + ":"
+}
+
+// EncryptField encrypts a value with envelope encryption.
+// Each value is encrypted with AES-256-GCM under its own data key, and the data key is encrypted with the master key and stored with the value
+func EncryptField(plaintext string) (string, error) {
+	if fieldKeys == nil {
+		return "", errors.New("field encryption is not initialized")
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := sealAESGCM(fieldKeys.masterKeys[fieldKeys.current], dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := sealAESGCM(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptedFieldPrefix(fieldKeys.current) +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + "." +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField decrypts a value encrypted by EncryptField.
+// Plain values from before encryption was introduced are returned as is and re-encrypted by the data migration
+func DecryptField(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) {
+		return value, nil
+	}
+	if fieldKeys == nil {
+		return "", errors.New("field encryption is not initialized")
+	}
+
+	versionText, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedFieldPrefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted field")
+	}
+	version, err := strconv.Atoi(versionText)
+	if err != nil {
+		return "", errors.New("malformed encrypted field")
+	}
+	masterKey, ok := fieldKeys.masterKeys[version]
+	if !ok {
+		return "", fmt.Errorf("field encryption key version %d is not configured", version)
+	}
+
+	wrappedText, sealedText, ok := strings.Cut(payload, ".")
+	if !ok {
+		return "", errors.New("malformed encrypted field")
+	}
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(wrappedText)
+	if err != nil {
+		return "", errors.New("malformed encrypted field")
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(sealedText)
+	if err != nil {
+		return "", errors.New("malformed encrypted field")
+	}
+
+	dataKey, err := openAESGCM(masterKey, wrappedKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := openAESGCM(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash (HMAC-SHA256) used to find encrypted fields by exact match.
+// Callers normalize the value first. An empty string returns an empty string
+func BlindIndex(value string) string {
+	if value == "" || fieldKeys == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, fieldKeys.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sealAESGCM returns the ciphertext prefixed with the nonce
+func sealAESGCM(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func openAESGCM(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("malformed encrypted field")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt field")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phoneCandidatePattern matches digit runs that look like phone numbers. They are filtered by length and prefix before masking
+	phoneCandidatePattern = regexp.MustCompile(`\+?\d[\d\s\-]{8,14}\d`)
+)
+
+// redactedFields are fields whose values are never logged as is. Names cannot be recognized in text, so pass them to logs as fields
+var redactedFields = map[string]bool{
+	"name":          true,
+	"name_kana":     true,
+	"customer_name": true,
+	"phone":         true,
+	"email":         true,
+	"recipient":     true,
+	"destination":   true,
+}
+
+// RedactingFormatter removes phone numbers, email addresses and names from the message and fields before Formatter writes them
+type RedactingFormatter struct {
+	Formatter logrus.Formatter
+}
+
+func (f *RedactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		switch {
+		case redactedFields[strings.ToLower(key)]:
+			data[key] = redacted
+		case key == logrus.ErrorKey:
+			if err, ok := value.(error); ok {
+				data[key] = RedactPII(err.Error())
+			} else {
+				data[key] = RedactPII(fmt.Sprint(value))
+			}
+		default:
+			if text, ok := value.(string); ok {
+				data[key] = RedactPII(text)
+			} else {
+				data[key] = value
+			}
+		}
+	}
+
+	clean := &logrus.Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Caller:  entry.Caller,
+		Message: RedactPII(entry.Message),
+		Buffer:  entry.Buffer,
+		Context: entry.Context,
+	}
+	return f.Formatter.Format(clean)
+}
+
+// RedactPII masks email addresses and domestic and international phone numbers in a string
+func RedactPII(text string) string {
+	text = emailPattern.ReplaceAllString(text, redacted)
+	return phoneCandidatePattern.ReplaceAllStringFunc(text, func(candidate string) string {
+		digits := NationalPhoneDigits(candidate)
+		if len(digits) < 10 || len(digits) > 11 || digits[0] != '0' {
+			return candidate
+		}
+		return redacted
+	})
+}
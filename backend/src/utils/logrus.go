@@ -2,6 +2,7 @@ package utils
 
 import (
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -15,14 +16,33 @@ var Log *logrus.Logger
 func init() {
 	Log = logrus.New()
 
-	// Set logger to use the custom text formatter
-	Log.SetFormatter(&CustomFormatter{
-		TextFormatter: logrus.TextFormatter{
-			TimestampFormat: "15:04:05.000",
-			FullTimestamp:   true,
-			ForceColors:     true,
-		},
-	})
+	// Structured JSON by default, personal data is masked whichever format is used
+	SetLogFormat("json")
+	Log.AddHook(requestIDHook{})
 
 	Log.SetOutput(os.Stdout)
 }
+
+// SetLogFormat switches the log format. text is a colored format that is easier to read in development
+func SetLogFormat(format string) {
+	var formatter logrus.Formatter
+	if format == "text" {
+		formatter = &CustomFormatter{
+			TextFormatter: logrus.TextFormatter{
+				TimestampFormat: "15:04:05.000",
+				FullTimestamp:   true,
+				ForceColors:     true,
+			},
+		}
+	} else {
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime: "time",
+				logrus.FieldKeyMsg:  "message",
+			},
+		}
+	}
+
+	Log.SetFormatter(&RedactingFormatter{Formatter: formatter})
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeSearchText normalizes names and kana for search.
+// It applies NFKC to full-width alphanumerics and half-width kana, turns hiragana into katakana, lowercases letters and removes spaces
+func NormalizeSearchText(s string) string {
+	s = norm.NFKC.String(s)
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r >= 'ぁ' && r <= 'ゖ':
+			// Hiragana to katakana, so that readings typed either way match
+			b.WriteRune(r + ('ァ' - 'ぁ'))
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// NormalizePhoneDigits keeps only the digits of a phone number, converting full-width digits and dropping hyphens, spaces and parentheses
+func NormalizePhoneDigits(s string) string {
+	s = norm.NFKC.String(s)
+
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NameSimilarity returns the similarity of two normalized names from 0 to 1 (the Dice coefficient of character bigrams).
+// Single character names are 1 only when they match exactly
+func NameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	bigrams := func(s string) map[string]int {
+		runes := []rune(s)
+		grams := make(map[string]int, len(runes))
+		for i := 0; i+1 < len(runes); i++ {
+			grams[string(runes[i:i+2])]++
+		}
+		return grams
+	}
+
+	aGrams, bGrams := bigrams(a), bigrams(b)
+	total := 0
+	for _, n := range aGrams {
+		total += n
+	}
+	for _, n := range bGrams {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	shared := 0
+	for gram, n := range aGrams {
+		shared += min(n, bGrams[gram])
+	}
+	return float64(2*shared) / float64(total)
+}
+
+// NationalPhoneDigits formats a phone number as domestic digits. Numbers with a country code (+81...) get a leading 0 instead
+func NationalPhoneDigits(s string) string {
+	digits := NormalizePhoneDigits(s)
+	if strings.HasPrefix(digits, "81") && len(digits) >= 11 {
+		return "0" + digits[2:]
+	}
+	return digits
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule is a subset of the RFC 5545 RRULE (FREQ / INTERVAL / COUNT / UNTIL)
+type RRule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    *time.Time
+}
+
+const (
+	RRuleFreqDaily   = "DAILY"
+	RRuleFreqWeekly  = "WEEKLY"
+	RRuleFreqMonthly = "MONTHLY"
+)
+
+// ParseRRule parses a string such as "FREQ=WEEKLY;INTERVAL=5;COUNT=6".
+// The leading "RRULE:" is optional. COUNT or UNTIL is required
+func ParseRRule(rule string) (*RRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	r := &RRule{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, errors.New("invalid rrule part: " + part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := strings.ToUpper(value)
+			if freq != RRuleFreqDaily && freq != RRuleFreqWeekly && freq != RRuleFreqMonthly {
+				return nil, errors.New("unsupported rrule FREQ: " + value)
+			}
+			r.Freq = freq
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, errors.New("invalid rrule INTERVAL: " + value)
+			}
+			r.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, errors.New("invalid rrule COUNT: " + value)
+			}
+			r.Count = count
+		case "UNTIL":
+			until, err := parseRRuleDate(value)
+			if err != nil {
+				return nil, errors.New("invalid rrule UNTIL: " + value)
+			}
+			r.Until = &until
+		default:
+			return nil, errors.New("unsupported rrule part: " + key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("rrule FREQ is required")
+	}
+	if r.Count == 0 && r.Until == nil {
+		return nil, errors.New("rrule requires COUNT or UNTIL")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("rrule COUNT and UNTIL are mutually exclusive")
+	}
+
+	return r, nil
+}
+
+func parseRRuleDate(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	return time.Parse("20060102", value)
+}
+
+// Occurrences returns up to limit dates following the rule, starting with start.
+// With MONTHLY, months without the day (such as the 31st) are skipped, as in RFC 5545
+func (r *RRule) Occurrences(start time.Time, limit int) []time.Time {
+	var occurrences []time.Time
+
+loop:
+	for i := 0; len(occurrences) < limit; i++ {
+		var next time.Time
+		switch r.Freq {
+		case RRuleFreqDaily:
+			next = start.AddDate(0, 0, i*r.Interval)
+		case RRuleFreqWeekly:
+			next = start.AddDate(0, 0, 7*i*r.Interval)
+		case RRuleFreqMonthly:
+			next = start.AddDate(0, i*r.Interval, 0)
+			if next.Day() != start.Day() {
+				// Guard against looping forever on rules that never produce a valid day
+				if i > limit*12 {
+					break loop
+				}
+				continue
+			}
+		}
+
+		if r.Until != nil && next.After(*r.Until) {
+			break
+		}
+		occurrences = append(occurrences, next)
+		if r.Count > 0 && len(occurrences) >= r.Count {
+			break
+		}
+	}
+
+	return occurrences
+}
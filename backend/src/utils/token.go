@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GenerateRoleToken issues an HS256 token with a role claim
+func GenerateRoleToken(sub, role, tokenType, secret string, expires time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  sub,
+		"role": role,
+		"type": tokenType,
+		"iat":  time.Now().Unix(),
+		"exp":  expires.Unix(),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
@@ -2,24 +2,59 @@ package utils
 
 import (
 	"errors"
+	"slices"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 func VerifyToken(tokenStr, secret, tokenType string) (string, error) {
+	claims, err := parseClaims(tokenStr, secret)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenSubject(claims, tokenType)
+}
+
+// VerifyTokenRole verifies the token like VerifyToken and also returns its role claim. It fails unless the role is one of roles
+func VerifyTokenRole(tokenStr, secret, tokenType string, roles ...string) (string, string, error) {
+	claims, err := parseClaims(tokenStr, secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	jwtRole, _ := claims["role"].(string)
+	if !slices.Contains(roles, jwtRole) {
+		return "", "", errors.New("invalid token role")
+	}
+
+	sub, err := tokenSubject(claims, tokenType)
+	if err != nil {
+		return "", "", err
+	}
+	return sub, jwtRole, nil
+}
+
+func parseClaims(tokenStr, secret string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(_ *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
-
-	if err != nil || !token.Valid {
-		return "", err
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
 	}
 
+	return claims, nil
+}
+
+func tokenSubject(claims jwt.MapClaims, tokenType string) (string, error) {
 	jwtType, ok := claims["type"].(string)
 	if !ok || jwtType != tokenType {
 		return "", errors.New("invalid token type")
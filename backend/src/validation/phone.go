@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/unicode/norm"
+)
+
+// PhoneNumber is a normalized Japanese phone number
+type PhoneNumber struct {
+	E164    string `json:"e164"`    // +819012345678
+	Display string `json:"display"` // 090-1234-5678
+	Mobile  bool   `json:"mobile"`  // mobile phone (070, 080, 090)
+}
+
+// ParsePhone parses Japanese mobile, landline and other numbers.
+// It accepts full-width digits, hyphens, spaces, parentheses and a country code (+81)
+func ParsePhone(value string) (*PhoneNumber, error) {
+	value = norm.NFKC.String(strings.TrimSpace(value))
+
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == '-' || r == ' ' || r == '(' || r == ')' || r == '.' || r == 'ー' || r == '−':
+		default:
+			return nil, errors.New("invalid phone number")
+		}
+	}
+	digits := b.String()
+
+	// The national number always starts with a trunk prefix 0
+	switch {
+	case strings.HasPrefix(digits, "+81"):
+		digits = "0" + strings.TrimPrefix(strings.TrimPrefix(digits, "+81"), "0")
+	case strings.HasPrefix(digits, "+"):
+		return nil, errors.New("only Japanese phone numbers are supported")
+	case !strings.HasPrefix(digits, "0"):
+		return nil, errors.New("invalid phone number")
+	}
+
+	groups, mobile := phoneGroups(digits)
+	if groups == nil {
+		return nil, errors.New("invalid phone number")
+	}
+
+	parts := make([]string, 0, len(groups))
+	rest := digits
+	for _, size := range groups {
+		parts = append(parts, rest[:size])
+		rest = rest[size:]
+	}
+
+	return &PhoneNumber{
+		E164:    "+81" + digits[1:],
+		Display: strings.Join(parts, "-"),
+		Mobile:  mobile,
+	}, nil
+}
+
+// NormalizePhone formats a parsable phone number as E.164. Other values are returned as is
+func NormalizePhone(value string) string {
+	phone, err := ParsePhone(value)
+	if err != nil {
+		return value
+	}
+	return phone.E164
+}
+
+// DisplayPhone formats a phone number in domestic notation with hyphens. Other values are returned as is
+func DisplayPhone(value string) string {
+	phone, err := ParsePhone(value)
+	if err != nil {
+		return value
+	}
+	return phone.Display
+}
+
+// Phone validates that the value parses as a Japanese phone number (tag: phone)
+func Phone(field validator.FieldLevel) bool {
+	value, ok := field.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+	_, err := ParsePhone(value)
+	return err == nil
+}
+
+// phoneGroups returns the digit groups of a domestic number starting with 0 and whether it is a mobile number, or nil when it is not a valid number.
+// Area codes of landlines vary by region, so numbers other than 03 and 06 are split with a three digit area code
+func phoneGroups(digits string) ([]int, bool) {
+	if len(digits) < 10 || digits[1] == '0' {
+		return nil, false
+	}
+
+	switch digits[:3] {
+	case "070", "080", "090":
+		if len(digits) == 11 {
+			return []int{3, 4, 4}, true
+		}
+		return nil, false
+	case "020", "050", "060":
+		if len(digits) == 11 {
+			return []int{3, 4, 4}, false
+		}
+		return nil, false
+	}
+
+	switch {
+	case digits[:4] == "0120" || digits[:4] == "0570":
+		if len(digits) == 10 {
+			return []int{4, 3, 3}, false
+		}
+		return nil, false
+	case digits[:4] == "0800":
+		if len(digits) == 11 {
+			return []int{4, 3, 4}, false
+		}
+		return nil, false
+	case len(digits) != 10:
+		return nil, false
+	case digits[1] == '3' || digits[1] == '6':
+		return []int{2, 4, 4}, false
+	}
+	return []int{3, 3, 4}, false
+}
@@ -1,60 +1,52 @@
 package validation
 
 import (
+	"app/src/i18n"
 	"errors"
-	"fmt"
 
 	"github.com/go-playground/validator/v10"
 )
 
-var customMessages = map[string]string{
-	"required": "Field %s must be filled",
-	"email":    "Invalid email address for field %s",
-	"min":      "Field %s must have a minimum length of %s characters",
-	"max":      "Field %s must have a maximum length of %s characters",
-	"len":      "Field %s must be exactly %s characters long",
-	"number":   "Field %s must be a number",
-	"positive": "Field %s must be a positive number",
-	"alphanum": "Field %s must contain only alphanumeric characters",
-	"oneof":    "Invalid value for field %s",
-	"password": "Field %s must contain at least 1 letter and 1 number",
+// messageKeys are the error message keys of each tag. Other tags use validation.default
+var messageKeys = map[string]string{
+	"required": "validation.required",
+	"email":    "validation.email",
+	"min":      "validation.min",
+	"max":      "validation.max",
+	"len":      "validation.len",
+	"number":   "validation.number",
+	"positive": "validation.positive",
+	"alphanum": "validation.alphanum",
+	"oneof":    "validation.oneof",
+	"password": "validation.password",
+	"phone":    "validation.phone",
 }
 
-func CustomErrorMessages(err error) map[string]string {
+// CustomErrorMessages returns the error messages of each input field in the given language
+func CustomErrorMessages(err error, locale string) map[string]string {
 	var validationErrors validator.ValidationErrors
 	if errors.As(err, &validationErrors) {
-		return generateErrorMessages(validationErrors)
+		return generateErrorMessages(validationErrors, locale)
 	}
 	return nil
 }
 
-func generateErrorMessages(validationErrors validator.ValidationErrors) map[string]string {
+func generateErrorMessages(validationErrors validator.ValidationErrors, locale string) map[string]string {
 	errorsMap := make(map[string]string)
 	for _, err := range validationErrors {
-		fieldName := err.StructNamespace()
-		tag := err.Tag()
-
-		customMessage := customMessages[tag]
-		if customMessage != "" {
-			errorsMap[fieldName] = formatErrorMessage(customMessage, err, tag)
-		} else {
-			errorsMap[fieldName] = defaultErrorMessage(err)
+		key, ok := messageKeys[err.Tag()]
+		if !ok {
+			key = "validation.default"
 		}
+		errorsMap[err.StructNamespace()] = i18n.T(locale, key, i18n.Params{
+			"field": err.Field(),
+			"param": err.Param(),
+			"tag":   err.Tag(),
+		})
 	}
 	return errorsMap
 }
 
-func formatErrorMessage(customMessage string, err validator.FieldError, tag string) string {
-	if tag == "min" || tag == "max" || tag == "len" {
-		return fmt.Sprintf(customMessage, err.Field(), err.Param())
-	}
-	return fmt.Sprintf(customMessage, err.Field())
-}
-
-func defaultErrorMessage(err validator.FieldError) string {
-	return fmt.Sprintf("Field validation for '%s' failed on the '%s' tag", err.Field(), err.Tag())
-}
-
 func Validator() *validator.Validate {
 	validate := validator.New()
 
@@ -62,5 +54,9 @@ func Validator() *validator.Validate {
 		return nil
 	}
 
+	if err := validate.RegisterValidation("phone", Phone); err != nil {
+		return nil
+	}
+
 	return validate
 }
@@ -18,7 +18,11 @@ var Log = utils.Log
 
 func init() {
 	// TODO: You can modify host and database configuration for tests
-	DB = database.Connect("localhost", "testdb")
+	db, err := database.Connect("localhost", "testdb")
+	if err != nil {
+		Log.Fatalf("Failed to connect to test database: %v", err)
+	}
+	DB = db
 	router.Routes(App, DB)
 	App.Use(utils.NotFoundHandler)
 }
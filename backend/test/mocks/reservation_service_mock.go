@@ -2,6 +2,8 @@ package mocks
 
 import (
 	"app/src/model"
+	"app/src/service"
+	"context"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -12,10 +14,15 @@ type ReservationServiceMock struct {
 	mock.Mock
 }
 
+// WithContext はコンテキストを引き継いだサービスを返す。モックでは自身を返す
+func (m *ReservationServiceMock) WithContext(ctx context.Context) service.ReservationServiceInterface {
+	return m
+}
+
 // GetReservations は予約一覧を取得する
-func (m *ReservationServiceMock) GetReservations(page, limit int, status, staffID, customerID, dateFrom, dateTo string) ([]model.Reservation, int64, error) {
-	args := m.Called(page, limit, status, staffID, customerID, dateFrom, dateTo)
-	return args.Get(0).([]model.Reservation), args.Get(1).(int64), args.Error(2)
+func (m *ReservationServiceMock) GetReservations(options service.ListOptions, filter service.ReservationFilter) ([]model.Reservation, service.PageInfo, error) {
+	args := m.Called(options, filter)
+	return args.Get(0).([]model.Reservation), args.Get(1).(service.PageInfo), args.Error(2)
 }
 
 // GetReservationByID は予約をIDで取得する
@@ -52,8 +59,8 @@ func (m *ReservationServiceMock) CancelReservation(id uuid.UUID) error {
 }
 
 // CreateReservationFromRequest はリクエストデータから予約を作成する
-func (m *ReservationServiceMock) CreateReservationFromRequest(customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string) (*model.Reservation, error) {
-	args := m.Called(customerID, staffID, reservationDate, startTime, menuIDs, optionIDs, notes)
+func (m *ReservationServiceMock) CreateReservationFromRequest(customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string, overrideBlockedTime bool, staffAssignment string, acknowledgeRisk bool) (*model.Reservation, error) {
+	args := m.Called(customerID, staffID, reservationDate, startTime, menuIDs, optionIDs, notes, overrideBlockedTime, staffAssignment, acknowledgeRisk)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -61,8 +68,8 @@ func (m *ReservationServiceMock) CreateReservationFromRequest(customerID, staffI
 }
 
 // UpdateReservationFromRequest はリクエストデータから予約を更新する
-func (m *ReservationServiceMock) UpdateReservationFromRequest(id, customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string) (*model.Reservation, error) {
-	args := m.Called(id, customerID, staffID, reservationDate, startTime, menuIDs, optionIDs, notes)
+func (m *ReservationServiceMock) UpdateReservationFromRequest(id, customerID, staffID uuid.UUID, reservationDate, startTime string, menuIDs, optionIDs []uuid.UUID, notes string, overrideBlockedTime bool) (*model.Reservation, error) {
+	args := m.Called(id, customerID, staffID, reservationDate, startTime, menuIDs, optionIDs, notes, overrideBlockedTime)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -85,4 +92,28 @@ func (m *ReservationServiceMock) GetAvailability(date, durationStr, staffIDStr,
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]map[string]interface{}), args.Error(1)
-}
\ No newline at end of file
+}
+
+// GetRebookSuggestions は過去の予約から再予約の候補を取得する
+func (m *ReservationServiceMock) GetRebookSuggestions(id uuid.UUID, fromDate, windowStart, windowEnd string, limit int) (*service.RebookSuggestion, error) {
+	args := m.Called(id, fromDate, windowStart, windowEnd, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.RebookSuggestion), args.Error(1)
+}
+
+// GetNoShowReview は無断キャンセル確認待ちの予約一覧を取得する
+func (m *ReservationServiceMock) GetNoShowReview(page, limit int) ([]model.Reservation, int64, error) {
+	args := m.Called(page, limit)
+	return args.Get(0).([]model.Reservation), args.Get(1).(int64), args.Error(2)
+}
+
+// GetCustomerRisk は顧客の無断キャンセル・直前キャンセルのリスクを取得する
+func (m *ReservationServiceMock) GetCustomerRisk(customerID uuid.UUID) (*service.CustomerRisk, error) {
+	args := m.Called(customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CustomerRisk), args.Error(1)
+}
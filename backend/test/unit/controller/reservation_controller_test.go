@@ -1,12 +1,14 @@
 package controller_test
 
 import (
+	"app/src/config"
 	"app/src/controller"
 	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
 	"app/test/mocks"
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"testing"
 	"time"
@@ -32,7 +34,7 @@ func TestReservationControllerSuite(t *testing.T) {
 
 func (suite *ReservationControllerTestSuite) SetupTest() {
 	// Fiberアプリとモックサービスの初期化
-	suite.app = fiber.New()
+	suite.app = fiber.New(fiber.Config{ErrorHandler: utils.ErrorHandler})
 	suite.mockReservationService = new(mocks.ReservationServiceMock)
 	suite.controller = controller.NewReservationController(suite.mockReservationService)
 	
@@ -97,7 +99,7 @@ func (suite *ReservationControllerTestSuite) Test_予約作成API_エラーケ
 		assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
 	})
 	
-	suite.Run("存在しない顧客IDが指定された場合_400_顧客が見つからないエラーが返される", func() {
+	suite.Run("存在しない顧客IDが指定された場合_404_顧客が見つからないエラーが返される", func() {
 		// Given: 存在しない顧客IDを含むリクエスト
 		nonExistentCustomerID := uuid.New()
 		validRequest := map[string]interface{}{
@@ -114,24 +116,26 @@ func (suite *ReservationControllerTestSuite) Test_予約作成API_エラーケ
 			mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"),
 			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
-			mock.AnythingOfType("string")).
-			Return(nil, fmt.Errorf("customer not found"))
+			mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(nil, service.ErrCustomerNotFound)
 		
 		reqBody, _ := json.Marshal(validRequest)
 		
-		// When: 予約作成APIを呼び出し
+		// When: 英語を希望するクライアントから予約作成APIを呼び出し
 		req, _ := http.NewRequest("POST", "/reservations", bytes.NewBuffer(reqBody))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "en-US,en;q=0.9,ja;q=0.8")
 		
 		resp, err := suite.app.Test(req)
 		
-		// Then: 400エラーが返される
+		// Then: 404エラーが英語のメッセージで返される
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(suite.T(), http.StatusNotFound, resp.StatusCode)
 		
 		var response map[string]interface{}
 		json.NewDecoder(resp.Body).Decode(&response)
 		assert.False(suite.T(), response["success"].(bool))
+		assert.Equal(suite.T(), "NOT_FOUND", response["error"].(map[string]interface{})["code"])
 		assert.Contains(suite.T(), response["error"].(map[string]interface{})["message"], "customer not found")
 	})
 	
@@ -151,8 +155,8 @@ func (suite *ReservationControllerTestSuite) Test_予約作成API_エラーケ
 			mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"),
 			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
-			mock.AnythingOfType("string")).
-			Return(nil, fmt.Errorf("time slot is already booked"))
+			mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(nil, service.ErrTimeSlotBooked)
 		
 		reqBody, _ := json.Marshal(conflictRequest)
 		
@@ -199,7 +203,7 @@ func (suite *ReservationControllerTestSuite) Test_予約取得API_エラーケ
 		
 		// モックサービスの設定：予約が見つからないエラーを返す
 		suite.mockReservationService.On("GetReservationByID", nonExistentID).
-			Return(nil, fmt.Errorf("reservation not found"))
+			Return(nil, service.ErrReservationNotFound)
 		
 		// When: 予約取得APIを呼び出し
 		req, _ := http.NewRequest("GET", "/reservations/"+nonExistentID.String(), nil)
@@ -233,8 +237,8 @@ func (suite *ReservationControllerTestSuite) Test_予約更新API_エラーケ
 			nonExistentID, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"),
 			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
-			mock.AnythingOfType("string")).
-			Return(nil, fmt.Errorf("reservation not found"))
+			mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(nil, service.ErrReservationNotFound)
 		
 		reqBody, _ := json.Marshal(updateRequest)
 		
@@ -266,8 +270,8 @@ func (suite *ReservationControllerTestSuite) Test_予約更新API_エラーケ
 			cancelledReservationID, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"),
 			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
-			mock.AnythingOfType("string")).
-			Return(nil, fmt.Errorf("cannot update cancelled or completed reservations"))
+			mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(nil, service.ErrReservationClosed)
 		
 		reqBody, _ := json.Marshal(updateRequest)
 		
@@ -295,7 +299,7 @@ func (suite *ReservationControllerTestSuite) Test_予約キャンセルAPI_エ
 		
 		// モックサービスの設定：予約が見つからないエラーを返す
 		suite.mockReservationService.On("CancelReservation", nonExistentID).
-			Return(fmt.Errorf("reservation not found"))
+			Return(service.ErrReservationNotFound)
 		
 		// When: 予約キャンセルAPIを呼び出し
 		req, _ := http.NewRequest("PUT", "/reservations/"+nonExistentID.String()+"/cancel", nil)
@@ -317,7 +321,7 @@ func (suite *ReservationControllerTestSuite) Test_予約キャンセルAPI_エ
 		
 		// モックサービスの設定：既にキャンセル済みエラーを返す
 		suite.mockReservationService.On("CancelReservation", alreadyCancelledID).
-			Return(fmt.Errorf("reservation is already cancelled"))
+			Return(service.ErrAlreadyCancelled)
 		
 		// When: 予約キャンセルAPIを呼び出し
 		req, _ := http.NewRequest("PUT", "/reservations/"+alreadyCancelledID.String()+"/cancel", nil)
@@ -344,7 +348,7 @@ func (suite *ReservationControllerTestSuite) Test_ステータス更新API_エ
 		
 		// モックサービスの設定：無効な遷移エラーを返す
 		suite.mockReservationService.On("UpdateReservationStatus", reservationID, "invalid_status").
-			Return(nil, fmt.Errorf("invalid status transition"))
+			Return(nil, service.ErrInvalidStatusChange)
 		
 		reqBody, _ := json.Marshal(statusRequest)
 		
@@ -392,7 +396,7 @@ func (suite *ReservationControllerTestSuite) Test_空き時間検索API_エラ
 		
 		// モックサービスの設定：無効な日付エラーを返す
 		suite.mockReservationService.On("GetAvailability", invalidDate, duration, "", "").
-			Return(nil, fmt.Errorf("無効な日付形式です"))
+			Return(nil, service.ErrInvalidDate)
 		
 		// When: 空き時間検索APIを呼び出し
 		req, _ := http.NewRequest("GET", "/availability?date="+invalidDate+"&duration="+duration, nil)
@@ -437,8 +441,10 @@ func (suite *ReservationControllerTestSuite) Test_予約作成API_正常系() {
 			mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"),
 			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
 			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
-			mock.AnythingOfType("string")).
+			mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
 			Return(createdReservation, nil)
+		suite.mockReservationService.On("GetCustomerRisk", customerID).
+			Return(&service.CustomerRisk{CustomerID: customerID, Level: service.CustomerRiskLevelNone}, nil)
 		
 		reqBody, _ := json.Marshal(validRequest)
 		
@@ -457,4 +463,125 @@ func (suite *ReservationControllerTestSuite) Test_予約作成API_正常系() {
 		assert.True(suite.T(), response["success"].(bool))
 		assert.NotNil(suite.T(), response["data"].(map[string]interface{})["reservation"])
 	})
-}
\ No newline at end of file
+}
+
+func (suite *ReservationControllerTestSuite) Test_予約作成API_顧客リスク() {
+	customerID := uuid.New()
+	request := map[string]interface{}{
+		"customer_id":      customerID.String(),
+		"staff_id":         uuid.New().String(),
+		"reservation_date": time.Now().Add(24 * time.Hour).Format("2006-01-02"),
+		"start_time":       "10:00:00",
+		"menu_ids":         []string{uuid.New().String()},
+	}
+
+	suite.Run("リスクの高い顧客を確認なしで予約した場合_409_確認が必要なエラーが返される", func() {
+		suite.mockReservationService.On("CreateReservationFromRequest",
+			customerID, mock.AnythingOfType("uuid.UUID"),
+			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
+			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
+			mock.AnythingOfType("string"), false, mock.AnythingOfType("string"), false).
+			Return(nil, service.ErrRiskConfirmation).Once()
+
+		reqBody, _ := json.Marshal(request)
+		req, _ := http.NewRequest("POST", "/reservations", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := suite.app.Test(req)
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusConflict, resp.StatusCode)
+
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.Equal(suite.T(), "RISK_CONFIRMATION_REQUIRED", response["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("注意が必要な顧客を予約した場合_201_レスポンスに顧客リスクが含まれる", func() {
+		request["acknowledge_risk"] = true
+		suite.mockReservationService.On("CreateReservationFromRequest",
+			customerID, mock.AnythingOfType("uuid.UUID"),
+			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
+			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
+			mock.AnythingOfType("string"), false, mock.AnythingOfType("string"), true).
+			Return(&model.Reservation{ID: uuid.New(), CustomerID: customerID, Status: model.ReservationStatusConfirmed}, nil).Once()
+		suite.mockReservationService.On("GetCustomerRisk", customerID).
+			Return(&service.CustomerRisk{CustomerID: customerID, NoShowCount: 2, Score: 4, Level: service.CustomerRiskLevelHigh}, nil).Once()
+
+		reqBody, _ := json.Marshal(request)
+		req, _ := http.NewRequest("POST", "/reservations", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := suite.app.Test(req)
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusCreated, resp.StatusCode)
+
+		var response map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&response)
+		risk := response["data"].(map[string]interface{})["customer_risk"].(map[string]interface{})
+		assert.Equal(suite.T(), "high", risk["level"])
+		assert.Equal(suite.T(), float64(2), risk["no_show_count"])
+	})
+}
+
+func (suite *ReservationControllerTestSuite) Test_ブロック時間への予約_権限() {
+	request := func(method, path, role string) *http.Response {
+		body, _ := json.Marshal(map[string]interface{}{
+			"customer_id":           uuid.New().String(),
+			"staff_id":              uuid.New().String(),
+			"reservation_date":      time.Now().Add(24 * time.Hour).Format("2006-01-02"),
+			"start_time":            "10:00:00",
+			"menu_ids":              []string{uuid.New().String()},
+			"override_blocked_time": true,
+		})
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if role != "" {
+			token, err := utils.GenerateRoleToken(uuid.New().String(), role, config.TokenTypeAccess, config.JWTSecret, time.Now().Add(time.Hour))
+			suite.Require().NoError(err)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := suite.app.Test(req)
+		suite.Require().NoError(err)
+		return resp
+	}
+	config.JWTSecret = "test-secret"
+
+	suite.Run("管理者のトークンがない場合_403_予約は作成されない", func() {
+		resp := request("POST", "/reservations", "")
+
+		assert.Equal(suite.T(), http.StatusForbidden, resp.StatusCode)
+		suite.mockReservationService.AssertNotCalled(suite.T(), "CreateReservationFromRequest")
+	})
+
+	suite.Run("顧客のトークンでは更新でも指定できない", func() {
+		resp := request("PUT", "/reservations/"+uuid.New().String(), config.RoleCustomer)
+
+		assert.Equal(suite.T(), http.StatusForbidden, resp.StatusCode)
+		suite.mockReservationService.AssertNotCalled(suite.T(), "UpdateReservationFromRequest")
+	})
+
+	suite.Run("スタッフのトークンでは指定できない", func() {
+		resp := request("POST", "/reservations", config.RoleStaff)
+
+		assert.Equal(suite.T(), http.StatusForbidden, resp.StatusCode)
+		suite.mockReservationService.AssertNotCalled(suite.T(), "CreateReservationFromRequest")
+	})
+
+	suite.Run("管理者のトークンがある場合_ブロック時間の上書きをサービスへ渡す", func() {
+		created := &model.Reservation{ID: uuid.New(), CustomerID: uuid.New(), Status: model.ReservationStatusConfirmed}
+		suite.mockReservationService.On("CreateReservationFromRequest",
+			mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("uuid.UUID"),
+			mock.AnythingOfType("string"), mock.AnythingOfType("string"),
+			mock.AnythingOfType("[]uuid.UUID"), mock.AnythingOfType("[]uuid.UUID"),
+			mock.AnythingOfType("string"), true, mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(created, nil).Once()
+		suite.mockReservationService.On("GetCustomerRisk", created.CustomerID).
+			Return(&service.CustomerRisk{CustomerID: created.CustomerID, Level: service.CustomerRiskLevelNone}, nil).Once()
+
+		resp := request("POST", "/reservations", config.RoleAdmin)
+
+		assert.Equal(suite.T(), http.StatusCreated, resp.StatusCode)
+	})
+}
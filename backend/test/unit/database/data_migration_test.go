@@ -0,0 +1,90 @@
+package database_test
+
+import (
+	"app/src/database"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DataMigrationTestSuite は一度だけ実行するデータ移行の記録のテストスイート
+type DataMigrationTestSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	runs map[string]int
+}
+
+func TestDataMigrationSuite(t *testing.T) {
+	suite.Run(t, new(DataMigrationTestSuite))
+}
+
+func (suite *DataMigrationTestSuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	suite.Require().NoError(err)
+	sqlDB, err := db.DB()
+	suite.Require().NoError(err)
+	sqlDB.SetMaxOpenConns(1)
+	suite.db = db
+	suite.runs = map[string]int{}
+}
+
+func (suite *DataMigrationTestSuite) migration(id string, err error) database.DataMigration {
+	return database.DataMigration{ID: id, Run: func(tx *gorm.DB) error {
+		suite.runs[id]++
+		return err
+	}}
+}
+
+func (suite *DataMigrationTestSuite) Test_データ移行() {
+	suite.Run("適用済みの移行は次の起動では実行しない", func() {
+		migrations := []database.DataMigration{suite.migration("first", nil), suite.migration("second", nil)}
+
+		suite.Require().NoError(database.ApplyDataMigrations(suite.db, migrations))
+		suite.Require().NoError(database.ApplyDataMigrations(suite.db, migrations))
+
+		assert.Equal(suite.T(), map[string]int{"first": 1, "second": 1}, suite.runs)
+		pending, err := database.PendingDataMigrations(suite.db, migrations)
+		suite.Require().NoError(err)
+		assert.Empty(suite.T(), pending)
+	})
+
+	suite.Run("失敗した移行は記録せず_後続の移行も実行しない", func() {
+		suite.SetupTest()
+		migrations := []database.DataMigration{suite.migration("first", nil), suite.migration("broken", errors.New("boom")), suite.migration("third", nil)}
+
+		err := database.ApplyDataMigrations(suite.db, migrations)
+
+		assert.Error(suite.T(), err)
+		assert.Zero(suite.T(), suite.runs["third"])
+		pending, err := database.PendingDataMigrations(suite.db, migrations)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), []string{"broken", "third"}, pending)
+	})
+
+	suite.Run("一度も適用していなければすべて未適用として返す", func() {
+		suite.SetupTest()
+
+		pending, err := database.PendingDataMigrations(suite.db, []database.DataMigration{suite.migration("first", nil)})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), []string{"first"}, pending)
+	})
+}
+
+func (suite *DataMigrationTestSuite) Test_スキーマの記録() {
+	suite.Run("AutoMigrate のモデルのスキーマを最初の移行として記録する", func() {
+		migrations, err := database.DataMigrations()
+		suite.Require().NoError(err)
+		again, err := database.DataMigrations()
+		suite.Require().NoError(err)
+
+		assert.True(suite.T(), strings.HasPrefix(migrations[0].ID, "schema_"))
+		assert.Equal(suite.T(), migrations[0].ID, again[0].ID)
+	})
+}
@@ -0,0 +1,58 @@
+package i18n_test
+
+import (
+	"app/src/i18n"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// I18nTestSuite はメッセージカタログと言語の判定のテストスイート
+type I18nTestSuite struct {
+	suite.Suite
+}
+
+func TestI18nSuite(t *testing.T) {
+	suite.Run(t, new(I18nTestSuite))
+}
+
+func (suite *I18nTestSuite) Test_メッセージカタログ() {
+	suite.Run("日本語と英語で同じキーが定義されている", func() {
+		assert.Equal(suite.T(), i18n.Keys(i18n.Japanese), i18n.Keys(i18n.English))
+	})
+
+	suite.Run("埋め込む値が置き換えられる", func() {
+		message := i18n.T(i18n.English, "error.date_range_too_long", i18n.Params{"days": 31})
+		assert.Equal(suite.T(), "date range must be within 31 days", message)
+	})
+
+	suite.Run("対応していない言語の場合_既定の言語の文言を返す", func() {
+		assert.Equal(suite.T(), i18n.T(i18n.Japanese, "error.reservation_not_found", nil), i18n.T("fr", "error.reservation_not_found", nil))
+	})
+
+	suite.Run("存在しないキーの場合_キーをそのまま返す", func() {
+		assert.Equal(suite.T(), "error.unknown_key", i18n.T(i18n.English, "error.unknown_key", nil))
+	})
+}
+
+func (suite *I18nTestSuite) Test_Accept_Languageの判定() {
+	cases := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"指定がない場合は既定の言語", "", i18n.Japanese},
+		{"地域付きの指定は言語部分で判定する", "en-US", i18n.English},
+		{"優先度の高い言語を選ぶ", "ja;q=0.5,en;q=0.8", i18n.English},
+		{"対応していない言語は飛ばす", "fr-FR,en;q=0.7", i18n.English},
+		{"どれにも対応していない場合は既定の言語", "fr-FR,de;q=0.9", i18n.Japanese},
+		{"ワイルドカードは既定の言語", "*", i18n.Japanese},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.name, func() {
+			assert.Equal(suite.T(), tc.expected, i18n.Match(tc.acceptLanguage))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"app/src/apperror"
+	"app/src/metrics"
+	"app/src/middleware"
+	"app/src/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// MetricsTestSuite はリクエスト数と応答時間の記録のテストスイート
+type MetricsTestSuite struct {
+	suite.Suite
+	app *fiber.App
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
+
+func (suite *MetricsTestSuite) SetupTest() {
+	metrics.HTTPRequests.Reset()
+	metrics.HTTPRequestDuration.Reset()
+
+	suite.app = fiber.New(fiber.Config{ErrorHandler: utils.ErrorHandler})
+	suite.app.Use(middleware.Metrics())
+	suite.app.Get("/reservations/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return apperror.NotFound("error.reservation_not_found")
+		}
+		return c.SendStatus(http.StatusOK)
+	})
+}
+
+func (suite *MetricsTestSuite) request(method, path string) {
+	_, err := suite.app.Test(httptest.NewRequest(method, path, nil))
+	suite.Require().NoError(err)
+}
+
+func (suite *MetricsTestSuite) Test_リクエストの記録() {
+	suite.Run("パラメータを含まないルートの定義ごとに集計する", func() {
+		suite.request(http.MethodGet, "/reservations/1")
+		suite.request(http.MethodGet, "/reservations/2")
+
+		assert.Equal(suite.T(), 1, testutil.CollectAndCount(metrics.HTTPRequests))
+		assert.Equal(suite.T(), 2.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/reservations/:id", "200")))
+		assert.Equal(suite.T(), 1, testutil.CollectAndCount(metrics.HTTPRequestDuration))
+	})
+
+	suite.Run("ハンドラが返したエラーはレスポンスのステータスで記録する", func() {
+		suite.SetupTest()
+
+		suite.request(http.MethodGet, "/reservations/missing")
+
+		assert.Equal(suite.T(), 1.0, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/reservations/:id", "404")))
+	})
+
+	suite.Run("存在しないパスはパスごとに系列を増やさない", func() {
+		suite.SetupTest()
+
+		suite.request(http.MethodGet, "/unknown/1")
+		suite.request(http.MethodGet, "/unknown/2")
+
+		assert.Equal(suite.T(), 1, testutil.CollectAndCount(metrics.HTTPRequests))
+	})
+}
@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"app/src/config"
+	"app/src/middleware"
+	"app/src/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// StaffAuthTestSuite はスタッフ・管理者ロールの認証のテストスイート
+type StaffAuthTestSuite struct {
+	suite.Suite
+	app     *fiber.App
+	staffID uuid.UUID
+}
+
+func TestStaffAuthSuite(t *testing.T) {
+	suite.Run(t, new(StaffAuthTestSuite))
+}
+
+func (suite *StaffAuthTestSuite) SetupTest() {
+	config.JWTSecret = "test-secret"
+	suite.staffID = uuid.New()
+	suite.app = fiber.New(fiber.Config{ErrorHandler: utils.ErrorHandler})
+	suite.app.Get("/staff", middleware.StaffAuth(), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	suite.app.Get("/admin", middleware.StaffAuth(config.RoleAdmin), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	suite.app.Get("/whoami", middleware.StaffAuth(), func(c *fiber.Ctx) error {
+		return c.SendString(middleware.StaffID(c).String())
+	})
+}
+
+func (suite *StaffAuthTestSuite) request(path, role string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if role != "" {
+		token, err := utils.GenerateRoleToken(suite.staffID.String(), role, config.TokenTypeAccess, config.JWTSecret, time.Now().Add(time.Hour))
+		suite.Require().NoError(err)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	return resp.StatusCode
+}
+
+func (suite *StaffAuthTestSuite) Test_ロールの確認() {
+	cases := []struct {
+		name   string
+		path   string
+		role   string
+		status int
+	}{
+		{"トークンがない場合は401", "/staff", "", http.StatusUnauthorized},
+		{"顧客のトークンは401", "/staff", config.RoleCustomer, http.StatusUnauthorized},
+		{"スタッフのトークンは許可", "/staff", config.RoleStaff, http.StatusOK},
+		{"管理者のトークンは許可", "/staff", config.RoleAdmin, http.StatusOK},
+		{"管理者限定にスタッフのトークンは403", "/admin", config.RoleStaff, http.StatusForbidden},
+		{"管理者限定に管理者のトークンは許可", "/admin", config.RoleAdmin, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.name, func() {
+			assert.Equal(suite.T(), tc.status, suite.request(tc.path, tc.role))
+		})
+	}
+}
+
+func (suite *StaffAuthTestSuite) Test_スタッフID() {
+	suite.Run("検証済みのトークンのスタッフIDを返す", func() {
+		token, err := utils.GenerateRoleToken(suite.staffID.String(), config.RoleAdmin, config.TokenTypeAccess, config.JWTSecret, time.Now().Add(time.Hour))
+		suite.Require().NoError(err)
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		resp, err := suite.app.Test(req)
+
+		suite.Require().NoError(err)
+		body, err := io.ReadAll(resp.Body)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), suite.staffID.String(), string(body))
+	})
+
+	suite.Run("サブジェクトがIDでないトークンは401", func() {
+		token, err := utils.GenerateRoleToken("staff-1", config.RoleStaff, config.TokenTypeAccess, config.JWTSecret, time.Now().Add(time.Hour))
+		suite.Require().NoError(err)
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		resp, err := suite.app.Test(req)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+	})
+}
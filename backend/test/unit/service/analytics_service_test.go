@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// AnalyticsServiceTestSuite はスタッフの稼働率・空き時間・混雑状況の分析のテストスイート
+type AnalyticsServiceTestSuite struct {
+	suite.Suite
+	db               *gorm.DB
+	analyticsService *service.AnalyticsService
+
+	monday   time.Time
+	customer model.Customer
+	sato     model.Staff
+	suzuki   model.Staff
+}
+
+func TestAnalyticsServiceSuite(t *testing.T) {
+	suite.Run(t, new(AnalyticsServiceTestSuite))
+}
+
+func (suite *AnalyticsServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.analyticsService = service.NewAnalyticsService(suite.db)
+	suite.monday = time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+
+	suite.customer = model.Customer{Name: "山田花子", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+	suite.sato = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.suzuki = model.Staff{Name: "鈴木", Email: "suzuki@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.sato).Error)
+	suite.Require().NoError(suite.db.Create(&suite.suzuki).Error)
+	suite.Require().NoError(suite.db.Create(&model.Menu{Name: "カット", Duration: 60, Price: 5000, IsActive: true}).Error)
+	suite.Require().NoError(suite.db.Create(&model.Menu{Name: "カラー", Duration: 90, Price: 8000, IsActive: true}).Error)
+
+	// Sato works 10:00-18:00 with a lunch break every Monday, 420 minutes to sell
+	suite.shift(suite.sato, suite.monday, 10, 18)
+	monday := int(time.Monday)
+	suite.Require().NoError(suite.db.Create(&model.BlockedTime{
+		StaffID: suite.sato.ID, Reason: model.BlockedTimeReasonBreak, Weekday: &monday, StartTime: "12:00:00", EndTime: "13:00:00", IsActive: true,
+	}).Error)
+	suite.reserve(suite.sato, suite.at(suite.monday, 10, 0), 60, model.ReservationStatusCompleted)
+	suite.reserve(suite.sato, suite.at(suite.monday, 14, 0), 90, model.ReservationStatusCompleted)
+	suite.reserve(suite.sato, suite.at(suite.monday, 16, 0), 105, model.ReservationStatusNoShow)
+	suite.reserve(suite.sato, suite.at(suite.monday, 11, 0), 60, model.ReservationStatusCancelled)
+}
+
+func (suite *AnalyticsServiceTestSuite) at(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.Local)
+}
+
+func (suite *AnalyticsServiceTestSuite) shift(staff model.Staff, day time.Time, from, to int) {
+	suite.Require().NoError(suite.db.Create(&model.Shift{
+		StaffID:   staff.ID,
+		Date:      day,
+		StartTime: suite.at(day, from, 0),
+		EndTime:   suite.at(day, to, 0),
+		IsActive:  true,
+	}).Error)
+}
+
+func (suite *AnalyticsServiceTestSuite) reserve(staff model.Staff, start time.Time, minutes int, status model.ReservationStatus) {
+	suite.Require().NoError(suite.db.Create(&model.Reservation{
+		CustomerID:      suite.customer.ID,
+		StaffID:         staff.ID,
+		ReservationDate: start,
+		StartTime:       start,
+		EndTime:         start.Add(time.Duration(minutes) * time.Minute),
+		TotalDuration:   minutes,
+		Status:          status,
+	}).Error)
+}
+
+func (suite *AnalyticsServiceTestSuite) heatmapCell(report *service.UtilizationReport, weekday, hour int) service.HeatmapCell {
+	for _, cell := range report.Heatmap {
+		if cell.Weekday == weekday && cell.Hour == hour {
+			return cell
+		}
+	}
+	suite.FailNow("heatmap cell not found")
+	return service.HeatmapCell{}
+}
+
+func (suite *AnalyticsServiceTestSuite) Test_稼働率() {
+	suite.Run("ブロック時間を除いた稼働可能な時間に対する予約時間の割合を返す", func() {
+		report, err := suite.analyticsService.GetUtilization(service.UtilizationFilter{DateFrom: "2026-03-02", DateTo: "2026-03-08"})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), 60, report.MinMenuDuration)
+		assert.Equal(suite.T(), 420, report.Overall.AvailableMinutes)
+		assert.Equal(suite.T(), 255, report.Overall.BookedMinutes)
+		assert.Equal(suite.T(), 0.607, report.Overall.Utilization)
+		suite.Require().Len(report.Staff, 1)
+		assert.Equal(suite.T(), "佐藤", report.Staff[0].StaffName)
+		suite.Require().Len(report.Periods, 1)
+		assert.Equal(suite.T(), "2026-03-02", report.Periods[0].PeriodStart)
+	})
+
+	suite.Run("週ごとにまとめ_スタッフで絞り込む", func() {
+		suite.shift(suite.suzuki, suite.monday.AddDate(0, 0, 2), 10, 14)
+		suite.shift(suite.suzuki, suite.monday.AddDate(0, 0, 8), 10, 14)
+		suite.reserve(suite.suzuki, suite.at(suite.monday.AddDate(0, 0, 2), 10, 0), 120, model.ReservationStatusConfirmed)
+
+		report, err := suite.analyticsService.GetUtilization(service.UtilizationFilter{
+			DateFrom: "2026-03-02", DateTo: "2026-03-15", StaffID: &suite.suzuki.ID, Granularity: service.AnalyticsGranularityWeek,
+		})
+
+		suite.Require().NoError(err)
+		suite.Require().Len(report.Staff, 1)
+		assert.Equal(suite.T(), suite.suzuki.ID, report.Staff[0].StaffID)
+		suite.Require().Len(report.Periods, 2)
+		assert.Equal(suite.T(), "2026-03-02", report.Periods[0].PeriodStart)
+		assert.Equal(suite.T(), 0.5, report.Periods[0].Utilization)
+		assert.Equal(suite.T(), "2026-03-09", report.Periods[1].PeriodStart)
+		assert.Equal(suite.T(), 0.0, report.Periods[1].Utilization)
+	})
+
+	suite.Run("不正な集計単位や長すぎる期間はエラーにする", func() {
+		_, err := suite.analyticsService.GetUtilization(service.UtilizationFilter{Granularity: "month"})
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.invalid_granularity")))
+
+		_, err = suite.analyticsService.GetUtilization(service.UtilizationFilter{DateFrom: "2025-01-01", DateTo: "2026-03-02"})
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.date_range_too_long")))
+	})
+}
+
+func (suite *AnalyticsServiceTestSuite) Test_空き時間() {
+	suite.Run("予約もブロック時間もない時間を数え_最も短いメニューより短いものは売れない時間とする", func() {
+		report, err := suite.analyticsService.GetUtilization(service.UtilizationFilter{DateFrom: "2026-03-02", DateTo: "2026-03-02"})
+
+		suite.Require().NoError(err)
+		gaps := report.Staff[0].IdleGaps
+		// 11:00-12:00, 13:00-14:00, 15:30-16:00 and 17:45-18:00
+		assert.Equal(suite.T(), 4, gaps.Count)
+		assert.Equal(suite.T(), 165, gaps.TotalMinutes)
+		assert.Equal(suite.T(), 60, gaps.LongestMinutes)
+		assert.Equal(suite.T(), 2, gaps.UnsellableCount)
+		assert.Equal(suite.T(), 45, gaps.UnsellableMinutes)
+
+		counts := make([]int, len(gaps.Histogram))
+		for i, bucket := range gaps.Histogram {
+			counts[i] = bucket.Count
+		}
+		assert.Equal(suite.T(), []int{0, 1, 1, 2, 0}, counts)
+		assert.Nil(suite.T(), gaps.Histogram[len(gaps.Histogram)-1].MaxMinutes)
+	})
+}
+
+func (suite *AnalyticsServiceTestSuite) Test_混雑状況() {
+	suite.Run("曜日と時間帯ごとに稼働可能な時間・予約時間・開始した予約の件数を返す", func() {
+		report, err := suite.analyticsService.GetUtilization(service.UtilizationFilter{DateFrom: "2026-03-02", DateTo: "2026-03-02"})
+
+		suite.Require().NoError(err)
+		ten := suite.heatmapCell(report, int(time.Monday), 10)
+		assert.Equal(suite.T(), 60, ten.AvailableMinutes)
+		assert.Equal(suite.T(), 60, ten.BookedMinutes)
+		assert.Equal(suite.T(), 1, ten.Reservations)
+		assert.Equal(suite.T(), 1.0, ten.Utilization)
+
+		fifteen := suite.heatmapCell(report, int(time.Monday), 15)
+		assert.Equal(suite.T(), 30, fifteen.BookedMinutes)
+		assert.Equal(suite.T(), 0, fifteen.Reservations)
+
+		seventeen := suite.heatmapCell(report, int(time.Monday), 17)
+		assert.Equal(suite.T(), 45, seventeen.BookedMinutes)
+		assert.Equal(suite.T(), 0.75, seventeen.Utilization)
+
+		for _, cell := range report.Heatmap {
+			assert.NotEqual(suite.T(), 12, cell.Hour, "the lunch break is neither available nor booked")
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// BlockedTimeServiceTestSuite はブロック時間の登録のテストスイート
+type BlockedTimeServiceTestSuite struct {
+	suite.Suite
+	blockedTimeService *service.BlockedTimeService
+}
+
+func TestBlockedTimeServiceSuite(t *testing.T) {
+	suite.Run(t, new(BlockedTimeServiceTestSuite))
+}
+
+func (suite *BlockedTimeServiceTestSuite) SetupTest() {
+	suite.blockedTimeService = service.NewBlockedTimeService(newTestDB(suite.T()))
+}
+
+func (suite *BlockedTimeServiceTestSuite) Test_ブロック時間登録_エラーケース() {
+	date := time.Now().AddDate(0, 0, 1)
+	weekday := 1
+	cases := []struct {
+		name    string
+		date    *time.Time
+		weekday *int
+	}{
+		{"日付と曜日の両方がない場合", nil, nil},
+		{"日付と曜日の両方を指定した場合", &date, &weekday},
+	}
+	for _, tc := range cases {
+		suite.Run(tc.name+"_ブロック時間のエラーキーで拒否される", func() {
+			_, err := suite.blockedTimeService.CreateBlockedTime(&model.BlockedTime{
+				StaffID:   uuid.New(),
+				Reason:    model.BlockedTimeReasonBreak,
+				Date:      tc.date,
+				Weekday:   tc.weekday,
+				StartTime: "12:00:00",
+				EndTime:   "13:00:00",
+			})
+
+			assert.True(suite.T(), errors.Is(err, apperror.Validation("error.blocked_time_date_or_weekday")))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// CalendarServiceTestSuite は購読カレンダーの予定の文言のテストスイート
+type CalendarServiceTestSuite struct {
+	suite.Suite
+	db              *gorm.DB
+	calendarService *service.CalendarService
+
+	customer model.Customer
+	staff    model.Staff
+}
+
+func TestCalendarServiceSuite(t *testing.T) {
+	suite.Run(t, new(CalendarServiceTestSuite))
+}
+
+func (suite *CalendarServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.calendarService = service.NewCalendarService(suite.db, &fakeEmailService{})
+
+	suite.customer = model.Customer{Name: "山田花子", Locale: "en", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+	suite.staff = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.staff).Error)
+	menu := model.Menu{Name: "カット", Duration: 60, Price: 5000, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&menu).Error)
+
+	start := time.Now().AddDate(0, 0, 3).Truncate(time.Hour)
+	reservation := model.Reservation{
+		CustomerID:      suite.customer.ID,
+		StaffID:         suite.staff.ID,
+		ReservationDate: start,
+		StartTime:       start,
+		EndTime:         start.Add(time.Hour),
+		TotalDuration:   60,
+		TotalPrice:      5000,
+		Status:          model.ReservationStatusConfirmed,
+	}
+	suite.Require().NoError(suite.db.Create(&reservation).Error)
+	suite.Require().NoError(suite.db.Create(&model.ReservationMenu{ReservationID: reservation.ID, MenuID: menu.ID, Quantity: 1, UnitPrice: 5000, TotalPrice: 5000}).Error)
+}
+
+func (suite *CalendarServiceTestSuite) feed(ownerType model.CalendarFeedOwner, ownerID uuid.UUID) string {
+	token, err := suite.calendarService.IssueFeed(ownerType, ownerID)
+	suite.Require().NoError(err)
+
+	calendar, err := suite.calendarService.FeedCalendar(token)
+	suite.Require().NoError(err)
+	return calendar
+}
+
+func (suite *CalendarServiceTestSuite) Test_予定の言語() {
+	suite.Run("顧客のカレンダーは顧客の言語で出力する", func() {
+		calendar := suite.feed(model.CalendarFeedOwnerCustomer, suite.customer.ID)
+
+		assert.Contains(suite.T(), calendar, "Salon reservations")
+		assert.Contains(suite.T(), calendar, "カット (with 佐藤)")
+		assert.Contains(suite.T(), calendar, "Price: ¥5000")
+		assert.NotContains(suite.T(), calendar, "料金")
+	})
+
+	suite.Run("スタッフのカレンダーはサロンの既定の言語で出力する", func() {
+		suite.SetupTest()
+
+		calendar := suite.feed(model.CalendarFeedOwnerStaff, suite.staff.ID)
+
+		assert.Contains(suite.T(), calendar, "佐藤 の予約")
+		assert.Contains(suite.T(), calendar, "カット 山田花子様")
+		assert.Contains(suite.T(), calendar, "料金: 5000円")
+	})
+}
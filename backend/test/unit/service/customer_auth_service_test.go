@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// capturingLoginSender は送ったログインコードを記録するだけの送信方法
+type capturingLoginSender struct {
+	code      string
+	magicLink string
+}
+
+func (c *capturingLoginSender) SendLoginCode(channel model.LoginChannel, destination, code, magicLink, locale string) error {
+	c.code = code
+	c.magicLink = magicLink
+	return nil
+}
+
+// CustomerAuthServiceTestSuite は顧客のパスワードレスログインのテストスイート
+type CustomerAuthServiceTestSuite struct {
+	suite.Suite
+	db          *gorm.DB
+	sender      *capturingLoginSender
+	authService *service.CustomerAuthService
+}
+
+func TestCustomerAuthServiceSuite(t *testing.T) {
+	suite.Run(t, new(CustomerAuthServiceTestSuite))
+}
+
+func (suite *CustomerAuthServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+
+	config.LoginCodeExp = 10
+	config.LoginCodeMaxAttempts = 5
+	config.LoginCodeMaxRequests = 5
+	suite.sender = &capturingLoginSender{}
+	suite.authService = service.NewCustomerAuthService(suite.db, suite.sender)
+}
+
+func (suite *CustomerAuthServiceTestSuite) wrongCode() string {
+	if suite.sender.code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func (suite *CustomerAuthServiceTestSuite) Test_コードの試行回数() {
+	suite.Run("同時に誤ったコードを送っても上限を超えて試行できない", func() {
+		_, err := suite.authService.RequestLogin("sms", "090-1234-5678", i18n.Japanese)
+		suite.Require().NoError(err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, _, _ = suite.authService.VerifyCode("sms", "090-1234-5678", suite.wrongCode(), "")
+			}()
+		}
+		wg.Wait()
+
+		var challenge model.LoginChallenge
+		suite.Require().NoError(suite.db.First(&challenge).Error)
+		assert.Equal(suite.T(), config.LoginCodeMaxAttempts, challenge.Attempts)
+	})
+
+	suite.Run("上限に達した後は正しいコードでもログインできない", func() {
+		suite.SetupTest()
+		_, err := suite.authService.RequestLogin("sms", "090-1234-5678", i18n.Japanese)
+		suite.Require().NoError(err)
+
+		for i := 0; i < config.LoginCodeMaxAttempts; i++ {
+			_, _, _, err := suite.authService.VerifyCode("sms", "090-1234-5678", suite.wrongCode(), "")
+			suite.Require().Error(err)
+		}
+
+		_, _, _, err = suite.authService.VerifyCode("sms", "090-1234-5678", suite.sender.code, "")
+		assert.True(suite.T(), errors.Is(err, apperror.Unauthorized("error.invalid_login_code")))
+	})
+}
+
+func (suite *CustomerAuthServiceTestSuite) Test_マジックリンク() {
+	suite.Run("リンクの確認だけではログインせず_確認後のログインは一度だけできる", func() {
+		_, err := suite.authService.RequestLogin("email", "hanako@example.com", i18n.Japanese)
+		suite.Require().NoError(err)
+		token := suite.sender.magicLink[strings.LastIndex(suite.sender.magicLink, "/")+1:]
+
+		for i := 0; i < 2; i++ {
+			_, err := suite.authService.CheckMagicLink(token)
+			suite.Require().NoError(err)
+		}
+		var customers int64
+		suite.Require().NoError(suite.db.Model(&model.Customer{}).Count(&customers).Error)
+		assert.Zero(suite.T(), customers)
+
+		_, tokens, isNew, err := suite.authService.VerifyMagicLink(token)
+		suite.Require().NoError(err)
+		assert.True(suite.T(), isNew)
+		assert.NotEmpty(suite.T(), tokens.AccessToken)
+
+		_, err = suite.authService.CheckMagicLink(token)
+		assert.True(suite.T(), errors.Is(err, apperror.Unauthorized("error.invalid_login_link")))
+		_, _, _, err = suite.authService.VerifyMagicLink(token)
+		assert.Error(suite.T(), err)
+	})
+}
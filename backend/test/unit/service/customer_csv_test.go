@@ -0,0 +1,209 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/text/encoding/japanese"
+	"gorm.io/gorm"
+)
+
+// CustomerCSVTestSuite は顧客 CSV の取り込みのテストスイート
+type CustomerCSVTestSuite struct {
+	suite.Suite
+	db              *gorm.DB
+	customerService *service.CustomerService
+}
+
+func TestCustomerCSVSuite(t *testing.T) {
+	suite.Run(t, new(CustomerCSVTestSuite))
+}
+
+func (suite *CustomerCSVTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.customerService = service.NewCustomerService(suite.db)
+}
+
+func (suite *CustomerCSVTestSuite) customers() []model.Customer {
+	var customers []model.Customer
+	suite.Require().NoError(suite.db.Order("created_at ASC").Find(&customers).Error)
+	return customers
+}
+
+func (suite *CustomerCSVTestSuite) Test_文字コードの判定() {
+	suite.Run("Shift_JIS の CSV を判定して取り込む", func() {
+		data, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("氏名,電話番号\n山田花子,090-1234-5678\n"))
+		suite.Require().NoError(err)
+
+		report, err := suite.customerService.ImportCustomersCSV(data, service.CustomerImportOptions{})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), service.CSVEncodingShiftJIS, report.Encoding)
+		assert.Equal(suite.T(), 1, report.Created)
+		customers := suite.customers()
+		suite.Require().Len(customers, 1)
+		assert.Equal(suite.T(), "山田花子", customers[0].Name)
+		assert.Equal(suite.T(), "+819012345678", customers[0].Phone)
+	})
+
+	suite.Run("BOM 付きの UTF-8 は BOM を除いて見出しを読む", func() {
+		suite.SetupTest()
+
+		report, err := suite.customerService.ImportCustomersCSV([]byte("\ufeff名前,電話\n山田花子,090-1234-5678\n"), service.CustomerImportOptions{})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), service.CSVEncodingUTF8, report.Encoding)
+		assert.Equal(suite.T(), "名前", report.Columns["name"])
+		assert.Equal(suite.T(), 1, report.Created)
+	})
+
+	suite.Run("UTF-8 を指定して UTF-8 として読めない場合はエラーにする", func() {
+		suite.SetupTest()
+		data, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("氏名,電話番号\n山田花子,090-1234-5678\n"))
+		suite.Require().NoError(err)
+
+		_, err = suite.customerService.ImportCustomersCSV(data, service.CustomerImportOptions{Encoding: service.CSVEncodingUTF8})
+
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.csv_invalid_utf8")))
+	})
+}
+
+func (suite *CustomerCSVTestSuite) Test_列の対応付け() {
+	suite.Run("指定した見出しの列から項目を読む", func() {
+		data := []byte("お客様名,連絡先,生年月日,性別\n山田花子,090-1234-5678,1990/4/1,女性\n")
+
+		report, err := suite.customerService.ImportCustomersCSV(data, service.CustomerImportOptions{
+			Mapping: map[string]string{"name": "お客様名", "phone": "連絡先"},
+		})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "連絡先", report.Columns["phone"])
+		customers := suite.customers()
+		suite.Require().Len(customers, 1)
+		assert.Equal(suite.T(), "山田花子", customers[0].Name)
+		assert.Equal(suite.T(), "female", customers[0].Gender)
+		suite.Require().NotNil(customers[0].Birthday)
+		assert.Equal(suite.T(), "1990-04-01", customers[0].Birthday.Format("2006-01-02"))
+	})
+
+	suite.Run("対応付けた見出しがない場合と氏名の列がない場合はエラーにする", func() {
+		suite.SetupTest()
+
+		_, err := suite.customerService.ImportCustomersCSV([]byte("氏名,電話番号\n"), service.CustomerImportOptions{
+			Mapping: map[string]string{"phone": "携帯"},
+		})
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.mapped_column_not_found")))
+
+		_, err = suite.customerService.ImportCustomersCSV([]byte("電話番号\n090-1234-5678\n"), service.CustomerImportOptions{})
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.name_column_required")))
+	})
+}
+
+func (suite *CustomerCSVTestSuite) Test_重複した顧客の扱い() {
+	const data = "氏名,電話番号,メールアドレス,備考\n山田 花子,090-1234-5678,hanako@example.com,新しいメモ\n"
+
+	existing := func() model.Customer {
+		customer := model.Customer{Name: "山田花子", Phone: "+819012345678", Notes: "既存のメモ", IsActive: true}
+		suite.Require().NoError(suite.db.Create(&customer).Error)
+		return customer
+	}
+
+	suite.Run("skip は既存の顧客を変更しない", func() {
+		customer := existing()
+
+		report, err := suite.customerService.ImportCustomersCSV([]byte(data), service.CustomerImportOptions{Duplicate: service.CustomerImportSkip})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), 1, report.Skipped)
+		assert.Equal(suite.T(), customer.ID, *report.Rows[0].CustomerID)
+		customers := suite.customers()
+		suite.Require().Len(customers, 1)
+		assert.Equal(suite.T(), "山田花子", customers[0].Name)
+		assert.Empty(suite.T(), customers[0].Email)
+	})
+
+	suite.Run("update は CSV に値がある項目で上書きする", func() {
+		suite.SetupTest()
+		existing()
+
+		report, err := suite.customerService.ImportCustomersCSV([]byte(data), service.CustomerImportOptions{Duplicate: service.CustomerImportUpdate})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), 1, report.Updated)
+		customers := suite.customers()
+		suite.Require().Len(customers, 1)
+		assert.Equal(suite.T(), "山田 花子", customers[0].Name)
+		assert.Equal(suite.T(), "hanako@example.com", customers[0].Email)
+		assert.Equal(suite.T(), "新しいメモ", customers[0].Notes)
+	})
+
+	suite.Run("merge は空いている項目だけを埋め_メモは追記する", func() {
+		suite.SetupTest()
+		existing()
+
+		report, err := suite.customerService.ImportCustomersCSV([]byte(data), service.CustomerImportOptions{Duplicate: service.CustomerImportMerge})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), 1, report.Merged)
+		customers := suite.customers()
+		suite.Require().Len(customers, 1)
+		assert.Equal(suite.T(), "山田花子", customers[0].Name)
+		assert.Equal(suite.T(), "hanako@example.com", customers[0].Email)
+		assert.Equal(suite.T(), "既存のメモ\n新しいメモ", customers[0].Notes)
+	})
+
+	suite.Run("未知の扱いはエラーにする", func() {
+		suite.SetupTest()
+
+		_, err := suite.customerService.ImportCustomersCSV([]byte(data), service.CustomerImportOptions{Duplicate: "replace"})
+
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.invalid_duplicate_handling")))
+	})
+}
+
+func (suite *CustomerCSVTestSuite) Test_行ごとのエラーとドライラン() {
+	const data = "氏名,電話番号,生年月日\n山田花子,090-1234-5678,\n佐藤一郎,,1985-13-40\n\n鈴木次郎,080-1111-2222,1980-01-01\n"
+
+	suite.Run("誤りのある行だけを報告し_正しい行は登録する", func() {
+		report, err := suite.customerService.ImportCustomersCSV([]byte(data), service.CustomerImportOptions{Locale: "ja"})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), 3, report.Total)
+		assert.Equal(suite.T(), 2, report.Created)
+		assert.Equal(suite.T(), 1, report.Failed)
+		suite.Require().Len(report.Rows, 3)
+		assert.Equal(suite.T(), 3, report.Rows[1].Row)
+		assert.Equal(suite.T(), service.CustomerImportActionError, report.Rows[1].Action)
+		assert.Len(suite.T(), report.Rows[1].Errors, 2)
+		assert.Equal(suite.T(), 5, report.Rows[2].Row)
+		assert.Len(suite.T(), suite.customers(), 2)
+	})
+
+	suite.Run("ドライランは同じ結果を返し_何も保存しない", func() {
+		suite.SetupTest()
+
+		report, err := suite.customerService.ImportCustomersCSV([]byte(data), service.CustomerImportOptions{DryRun: true})
+
+		suite.Require().NoError(err)
+		assert.True(suite.T(), report.DryRun)
+		assert.Equal(suite.T(), 2, report.Created)
+		assert.Equal(suite.T(), 1, report.Failed)
+		assert.Empty(suite.T(), suite.customers())
+	})
+
+	suite.Run("同じ CSV 内で重複した電話番号は2行目を既存の顧客として扱う", func() {
+		suite.SetupTest()
+
+		report, err := suite.customerService.ImportCustomersCSV([]byte("氏名,電話番号\n山田花子,090-1234-5678\n山田 花子,09012345678\n"), service.CustomerImportOptions{})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), 1, report.Created)
+		assert.Equal(suite.T(), 1, report.Skipped)
+		assert.Len(suite.T(), suite.customers(), 1)
+	})
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// CustomerMergeTestSuite は重複顧客の検出と統合のテストスイート
+type CustomerMergeTestSuite struct {
+	suite.Suite
+	db              *gorm.DB
+	customerService *service.CustomerService
+}
+
+func TestCustomerMergeSuite(t *testing.T) {
+	suite.Run(t, new(CustomerMergeTestSuite))
+}
+
+func (suite *CustomerMergeTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.customerService = service.NewCustomerService(suite.db)
+}
+
+func (suite *CustomerMergeTestSuite) create(customer model.Customer) model.Customer {
+	customer.IsActive = true
+	suite.Require().NoError(suite.db.Create(&customer).Error)
+	return customer
+}
+
+func (suite *CustomerMergeTestSuite) Test_重複候補の検出() {
+	suite.Run("メールアドレスの一致と名前の類似を理由に候補を返す", func() {
+		older := suite.create(model.Customer{Name: "山田花子", NameKana: "ヤマダハナコ", Email: "hanako@example.com"})
+		// The email blind index is unique, so the older row lost its email before the duplicate was registered
+		suite.Require().NoError(suite.db.Model(&model.Customer{}).Where("id = ?", older.ID).
+			Updates(map[string]interface{}{"email": "", "email_index": ""}).Error)
+		newer := suite.create(model.Customer{Name: "山田 花子", NameKana: "ヤマダハナコ", Email: "hanako@example.com"})
+		suite.create(model.Customer{Name: "佐藤一郎", NameKana: "サトウイチロウ"})
+
+		candidates, err := suite.customerService.FindDuplicateCandidates(0)
+
+		suite.Require().NoError(err)
+		suite.Require().Len(candidates, 1)
+		assert.Equal(suite.T(), older.ID, candidates[0].Customers[0].ID)
+		assert.Equal(suite.T(), newer.ID, candidates[0].Customers[1].ID)
+		assert.Equal(suite.T(), []string{service.DuplicateReasonName}, candidates[0].Reasons)
+		assert.InDelta(suite.T(), 1.0, candidates[0].NameSimilarity, 0.001)
+	})
+
+	suite.Run("先頭の文字が違う名前や無効な顧客は候補にしない", func() {
+		suite.SetupTest()
+		suite.create(model.Customer{Name: "鈴木次郎"})
+		suite.create(model.Customer{Name: "高橋次郎"})
+		inactive := suite.create(model.Customer{Name: "鈴木次郎"})
+		suite.Require().NoError(suite.db.Model(&model.Customer{}).Where("id = ?", inactive.ID).Update("is_active", false).Error)
+
+		candidates, err := suite.customerService.FindDuplicateCandidates(0)
+
+		suite.Require().NoError(err)
+		assert.Empty(suite.T(), candidates)
+	})
+}
+
+func (suite *CustomerMergeTestSuite) Test_顧客の統合() {
+	suite.Run("監査ログには顧客IDと補った項目名だけを残す", func() {
+		birthday := time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC)
+		survivor := suite.create(model.Customer{Name: "山田花子", Phone: "+819012345678"})
+		duplicate := suite.create(model.Customer{Name: "山田 花子", Email: "hanako@example.com", Birthday: &birthday, Notes: "カラー剤にかぶれやすい"})
+
+		result, err := suite.customerService.MergeCustomers(survivor.ID, duplicate.ID, service.AuditActor{})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "hanako@example.com", result.Customer.Email)
+
+		var logs []model.AuditLog
+		suite.Require().NoError(suite.db.Where("action = ?", service.AuditActionMerge).Find(&logs).Error)
+		suite.Require().Len(logs, 1)
+		for _, values := range []string{logs[0].OldValues, logs[0].NewValues} {
+			assert.NotContains(suite.T(), values, "hanako@example.com")
+			assert.NotContains(suite.T(), values, "+819012345678")
+			assert.NotContains(suite.T(), values, "山田")
+			assert.NotContains(suite.T(), values, "1990")
+			assert.NotContains(suite.T(), values, "カラー剤")
+		}
+
+		var newValues struct {
+			CustomerID       string   `json:"customer_id"`
+			MergedCustomerID string   `json:"merged_customer_id"`
+			ChangedFields    []string `json:"changed_fields"`
+		}
+		suite.Require().NoError(json.Unmarshal([]byte(logs[0].NewValues), &newValues))
+		assert.Equal(suite.T(), survivor.ID.String(), newValues.CustomerID)
+		assert.Equal(suite.T(), duplicate.ID.String(), newValues.MergedCustomerID)
+		assert.Equal(suite.T(), []string{"email", "birthday", "notes"}, newValues.ChangedFields)
+	})
+}
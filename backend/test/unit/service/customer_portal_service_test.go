@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// CustomerPortalServiceTestSuite は顧客本人によるプロフィール更新のテストスイート
+type CustomerPortalServiceTestSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	portalService *service.CustomerPortalService
+	customer      model.Customer
+}
+
+func TestCustomerPortalServiceSuite(t *testing.T) {
+	suite.Run(t, new(CustomerPortalServiceTestSuite))
+}
+
+func (suite *CustomerPortalServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.portalService = service.NewCustomerPortalService(suite.db, service.NewReservationService(suite.db))
+
+	suite.customer = model.Customer{Name: "山田花子", Phone: "+819012345678", Email: "hanako@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+}
+
+func (suite *CustomerPortalServiceTestSuite) storedEmail() string {
+	var customer model.Customer
+	suite.Require().NoError(suite.db.Where("id = ?", suite.customer.ID).First(&customer).Error)
+	return customer.Email
+}
+
+func (suite *CustomerPortalServiceTestSuite) Test_プロフィール更新() {
+	suite.Run("メールアドレスは本人から変更できない", func() {
+		_, err := suite.portalService.UpdateProfile(suite.customer.ID, "", "", "attacker@example.com", nil, "", "")
+
+		assert.True(suite.T(), errors.Is(err, service.ErrEmailNotChangeable))
+		assert.Equal(suite.T(), "hanako@example.com", suite.storedEmail())
+	})
+
+	suite.Run("メールアドレスのない顧客も本人からは登録できない", func() {
+		suite.SetupTest()
+		suite.Require().NoError(suite.db.Model(&model.Customer{}).Where("id = ?", suite.customer.ID).
+			Updates(map[string]interface{}{"email": "", "email_index": ""}).Error)
+
+		_, err := suite.portalService.UpdateProfile(suite.customer.ID, "", "", "new@example.com", nil, "", "")
+
+		assert.True(suite.T(), errors.Is(err, service.ErrEmailNotChangeable))
+		assert.Empty(suite.T(), suite.storedEmail())
+	})
+
+	suite.Run("現在と同じメールアドレスを送っても他の項目は更新される", func() {
+		suite.SetupTest()
+
+		updated, err := suite.portalService.UpdateProfile(suite.customer.ID, "山田 花子", "", " Hanako@Example.com", nil, "", "en")
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "山田 花子", updated.Name)
+		assert.Equal(suite.T(), "en", updated.Locale)
+		assert.Equal(suite.T(), "hanako@example.com", suite.storedEmail())
+	})
+}
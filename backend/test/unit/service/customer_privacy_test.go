@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// CustomerPrivacyTestSuite は個人情報の開示と消去のテストスイート
+type CustomerPrivacyTestSuite struct {
+	suite.Suite
+	db              *gorm.DB
+	customerService *service.CustomerService
+
+	customer    model.Customer
+	reservation model.Reservation
+}
+
+func TestCustomerPrivacySuite(t *testing.T) {
+	suite.Run(t, new(CustomerPrivacyTestSuite))
+}
+
+func (suite *CustomerPrivacyTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.customerService = service.NewCustomerService(suite.db)
+
+	birthday := time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC)
+	suite.customer = model.Customer{Name: "山田花子", NameKana: "ヤマダハナコ", Phone: "090-1234-5678", Email: "hanako@example.com",
+		Birthday: &birthday, Notes: "カラー剤にかぶれやすい", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+
+	staff := model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&staff).Error)
+	start := time.Date(2026, 1, 10, 10, 0, 0, 0, time.Local)
+	suite.reservation = model.Reservation{
+		CustomerID:      suite.customer.ID,
+		StaffID:         staff.ID,
+		ReservationDate: start,
+		StartTime:       start,
+		EndTime:         start.Add(time.Hour),
+		TotalDuration:   60,
+		TotalPrice:      5000,
+		Status:          model.ReservationStatusCompleted,
+		Notes:           "前回より短めに",
+	}
+	suite.Require().NoError(suite.db.Create(&suite.reservation).Error)
+
+	// A notification sent before the customer ID was recorded is found by its recipient
+	suite.Require().NoError(suite.db.Create(&model.NotificationLog{
+		Type: "email", Recipient: "hanako@example.com", Subject: "ログインコード", Message: "notification.login_code.email", Status: model.NotificationStatusSent,
+	}).Error)
+	suite.Require().NoError(suite.db.Create(&model.NotificationLog{
+		Type: "email", Recipient: "other@example.com", Message: "notification.login_code.email", Status: model.NotificationStatusSent,
+	}).Error)
+	suite.Require().NoError(suite.db.Create(&model.LoginChallenge{
+		Channel: model.LoginChannelSMS, Destination: "+819012345678", CodeHash: "hash", ExpiresAt: time.Now().Add(time.Minute),
+	}).Error)
+	suite.Require().NoError(suite.db.Create(&model.NotificationPreference{CustomerID: suite.customer.ID}).Error)
+}
+
+func (suite *CustomerPrivacyTestSuite) auditLogs(action string) []model.AuditLog {
+	var logs []model.AuditLog
+	suite.Require().NoError(suite.db.Where("record_id = ? AND action = ?", suite.customer.ID, action).Find(&logs).Error)
+	return logs
+}
+
+func (suite *CustomerPrivacyTestSuite) Test_個人情報の開示() {
+	suite.Run("顧客の予約・通知・ログイン履歴をまとめて返し_開示を監査ログに残す", func() {
+		export, err := suite.customerService.ExportCustomerData(suite.customer.ID, service.AuditActor{})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "hanako@example.com", export.Customer.Email)
+		suite.Require().Len(export.Reservations, 1)
+		assert.Equal(suite.T(), suite.reservation.ID, export.Reservations[0].ID)
+		suite.Require().Len(export.Notifications, 1)
+		assert.Equal(suite.T(), "hanako@example.com", export.Notifications[0].Recipient)
+		assert.Len(suite.T(), export.LoginHistory, 1)
+		assert.NotNil(suite.T(), export.NotificationPreference)
+		assert.Len(suite.T(), suite.auditLogs(service.AuditActionExport), 1)
+	})
+
+	suite.Run("存在しない顧客は見つからないエラーを返す", func() {
+		suite.SetupTest()
+
+		_, err := suite.customerService.ExportCustomerData(uuid.New(), service.AuditActor{})
+
+		assert.True(suite.T(), errors.Is(err, service.ErrCustomerNotFound))
+		assert.Empty(suite.T(), suite.auditLogs(service.AuditActionExport))
+	})
+}
+
+func (suite *CustomerPrivacyTestSuite) Test_個人情報の消去() {
+	suite.Run("プロフィール・予約のメモ・通知の宛先を消し_予約と金額は残す", func() {
+		suite.Require().NoError(suite.customerService.EraseCustomer(suite.customer.ID, service.AuditActor{}))
+
+		var customer model.Customer
+		suite.Require().NoError(suite.db.Where("id = ?", suite.customer.ID).First(&customer).Error)
+		assert.Equal(suite.T(), "削除済みのお客様", customer.Name)
+		assert.Empty(suite.T(), customer.Phone)
+		assert.Empty(suite.T(), customer.Email)
+		assert.Empty(suite.T(), customer.EmailIndex)
+		assert.Nil(suite.T(), customer.Birthday)
+		assert.Empty(suite.T(), customer.Notes)
+		assert.False(suite.T(), customer.IsActive)
+		assert.NotNil(suite.T(), customer.ErasedAt)
+
+		var reservation model.Reservation
+		suite.Require().NoError(suite.db.Where("id = ?", suite.reservation.ID).First(&reservation).Error)
+		assert.Empty(suite.T(), reservation.Notes)
+		assert.Equal(suite.T(), 5000, reservation.TotalPrice)
+
+		var notifications []model.NotificationLog
+		suite.Require().NoError(suite.db.Where("customer_id = ?", suite.customer.ID).Find(&notifications).Error)
+		suite.Require().Len(notifications, 1)
+		assert.Empty(suite.T(), notifications[0].Recipient)
+		assert.Empty(suite.T(), notifications[0].RecipientIndex)
+		assert.Empty(suite.T(), notifications[0].Message)
+
+		var remaining int64
+		suite.Require().NoError(suite.db.Model(&model.NotificationLog{}).Where("customer_id IS NULL").Count(&remaining).Error)
+		assert.Equal(suite.T(), int64(1), remaining)
+		suite.Require().NoError(suite.db.Model(&model.LoginChallenge{}).Count(&remaining).Error)
+		assert.Zero(suite.T(), remaining)
+		suite.Require().NoError(suite.db.Model(&model.NotificationPreference{}).Count(&remaining).Error)
+		assert.Zero(suite.T(), remaining)
+
+		logs := suite.auditLogs(service.AuditActionErase)
+		suite.Require().Len(logs, 1)
+		assert.NotContains(suite.T(), logs[0].NewValues, "hanako@example.com")
+		assert.NotContains(suite.T(), logs[0].NewValues, "山田")
+	})
+
+	suite.Run("表示名は顧客の言語で保存する", func() {
+		suite.SetupTest()
+		suite.Require().NoError(suite.db.Model(&suite.customer).Update("locale", "en").Error)
+
+		suite.Require().NoError(suite.customerService.EraseCustomer(suite.customer.ID, service.AuditActor{}))
+
+		var customer model.Customer
+		suite.Require().NoError(suite.db.Where("id = ?", suite.customer.ID).First(&customer).Error)
+		assert.Equal(suite.T(), "Erased customer", customer.Name)
+	})
+
+	suite.Run("消去済みの顧客はもう一度消去できない", func() {
+		suite.SetupTest()
+		suite.Require().NoError(suite.customerService.EraseCustomer(suite.customer.ID, service.AuditActor{}))
+
+		err := suite.customerService.EraseCustomer(suite.customer.ID, service.AuditActor{})
+
+		assert.True(suite.T(), errors.Is(err, apperror.Conflict("error.customer_already_erased")))
+		assert.Len(suite.T(), suite.auditLogs(service.AuditActionErase), 1)
+	})
+}
@@ -0,0 +1,147 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// CustomerProfileTestSuite は顧客の利用状況の集計と予約履歴のテストスイート
+type CustomerProfileTestSuite struct {
+	suite.Suite
+	db              *gorm.DB
+	customerService *service.CustomerService
+
+	customer model.Customer
+	sato     model.Staff
+	suzuki   model.Staff
+	cut      model.Menu
+	color    model.Menu
+}
+
+func TestCustomerProfileSuite(t *testing.T) {
+	suite.Run(t, new(CustomerProfileTestSuite))
+}
+
+func (suite *CustomerProfileTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.customerService = service.NewCustomerService(suite.db)
+
+	suite.customer = model.Customer{Name: "山田花子", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+	suite.sato = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.suzuki = model.Staff{Name: "鈴木", Email: "suzuki@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.sato).Error)
+	suite.Require().NoError(suite.db.Create(&suite.suzuki).Error)
+	suite.cut = model.Menu{Name: "カット", Duration: 60, Price: 5000, IsActive: true}
+	suite.color = model.Menu{Name: "カラー", Duration: 90, Price: 8000, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.cut).Error)
+	suite.Require().NoError(suite.db.Create(&suite.color).Error)
+}
+
+// visit は指定日の10時からの予約をメニューの料金で作る
+func (suite *CustomerProfileTestSuite) visit(date time.Time, staff model.Staff, status model.ReservationStatus, menus ...model.Menu) model.Reservation {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 10, 0, 0, 0, time.Local)
+	reservation := model.Reservation{
+		CustomerID:      suite.customer.ID,
+		StaffID:         staff.ID,
+		ReservationDate: date,
+		StartTime:       start,
+		EndTime:         start.Add(time.Hour),
+		TotalDuration:   60,
+		Status:          status,
+	}
+	for _, menu := range menus {
+		reservation.TotalPrice += menu.Price
+		reservation.ReservationMenus = append(reservation.ReservationMenus, model.ReservationMenu{
+			MenuID: menu.ID, Quantity: 1, UnitPrice: menu.Price, TotalPrice: menu.Price,
+		})
+	}
+	suite.Require().NoError(suite.db.Create(&reservation).Error)
+	return reservation
+}
+
+func (suite *CustomerProfileTestSuite) Test_利用状況の集計() {
+	suite.Run("来店済みの予約だけを来店回数と累計利用金額に数える", func() {
+		day := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+		suite.visit(day, suite.sato, model.ReservationStatusCompleted, suite.cut)
+		suite.visit(day.AddDate(0, 0, 30), suite.sato, model.ReservationStatusCompleted, suite.cut, suite.color)
+		suite.visit(day.AddDate(0, 0, 60), suite.suzuki, model.ReservationStatusCompleted, suite.cut)
+		suite.visit(day.AddDate(0, 0, 70), suite.sato, model.ReservationStatusCancelled, suite.color)
+		suite.visit(day.AddDate(0, 0, 80), suite.sato, model.ReservationStatusNoShow, suite.color)
+		suite.visit(day.AddDate(0, 0, 90), suite.suzuki, model.ReservationStatusConfirmed, suite.color)
+
+		statistics, err := suite.customerService.GetCustomerStatistics(suite.customer.ID)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), int64(3), statistics.VisitCount)
+		assert.Equal(suite.T(), int64(23000), statistics.LifetimeSpend)
+		assert.Equal(suite.T(), int64(1), statistics.CancellationCount)
+		assert.Equal(suite.T(), int64(1), statistics.NoShowCount)
+		suite.Require().NotNil(statistics.FirstVisit)
+		suite.Require().NotNil(statistics.LastVisit)
+		assert.Equal(suite.T(), "2026-01-10", statistics.FirstVisit.Format("2006-01-02"))
+		assert.Equal(suite.T(), "2026-03-11", statistics.LastVisit.Format("2006-01-02"))
+		suite.Require().NotNil(statistics.AverageIntervalDays)
+		assert.InDelta(suite.T(), 30.0, *statistics.AverageIntervalDays, 0.1)
+
+		suite.Require().Len(statistics.FavoriteMenus, 2)
+		assert.Equal(suite.T(), "カット", statistics.FavoriteMenus[0].Name)
+		assert.Equal(suite.T(), int64(3), statistics.FavoriteMenus[0].Count)
+		assert.Equal(suite.T(), "カラー", statistics.FavoriteMenus[1].Name)
+		assert.Equal(suite.T(), int64(1), statistics.FavoriteMenus[1].Count)
+		suite.Require().NotNil(statistics.FavoriteStaff)
+		assert.Equal(suite.T(), suite.sato.ID, statistics.FavoriteStaff.ID)
+		assert.Equal(suite.T(), int64(2), statistics.FavoriteStaff.Count)
+	})
+
+	suite.Run("1回だけの来店では来店間隔を返さない", func() {
+		suite.SetupTest()
+		suite.visit(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), suite.sato, model.ReservationStatusCompleted, suite.cut)
+
+		statistics, err := suite.customerService.GetCustomerStatistics(suite.customer.ID)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), int64(1), statistics.VisitCount)
+		assert.Nil(suite.T(), statistics.AverageIntervalDays)
+	})
+
+	suite.Run("来店のない顧客は空の集計を返す", func() {
+		suite.SetupTest()
+		suite.visit(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), suite.sato, model.ReservationStatusCancelled, suite.cut)
+
+		statistics, err := suite.customerService.GetCustomerStatistics(suite.customer.ID)
+
+		suite.Require().NoError(err)
+		assert.Zero(suite.T(), statistics.VisitCount)
+		assert.Equal(suite.T(), int64(1), statistics.CancellationCount)
+		assert.Nil(suite.T(), statistics.FirstVisit)
+		assert.Nil(suite.T(), statistics.FavoriteStaff)
+		assert.Empty(suite.T(), statistics.FavoriteMenus)
+	})
+}
+
+func (suite *CustomerProfileTestSuite) Test_予約履歴() {
+	suite.Run("新しい順にページ単位で返し_総件数を返す", func() {
+		day := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+		var created []model.Reservation
+		for i := 0; i < 5; i++ {
+			created = append(created, suite.visit(day.AddDate(0, 0, i*7), suite.sato, model.ReservationStatusCompleted, suite.cut))
+		}
+
+		profile, total, err := suite.customerService.GetCustomerProfile(suite.customer.ID, 2, 2)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), int64(5), total)
+		assert.Equal(suite.T(), suite.customer.ID, profile.Customer.ID)
+		assert.Equal(suite.T(), int64(5), profile.Statistics.VisitCount)
+		suite.Require().Len(profile.Reservations, 2)
+		assert.Equal(suite.T(), created[2].ID, profile.Reservations[0].ID)
+		assert.Equal(suite.T(), created[1].ID, profile.Reservations[1].ID)
+	})
+}
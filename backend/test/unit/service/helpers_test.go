@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testModels はテスト用のデータベースに作成するモデル。database.Connect の AutoMigrate と同じ
+var testModels = []interface{}{
+	&model.Customer{},
+	&model.Staff{},
+	&model.Menu{},
+	&model.Option{},
+	&model.Label{},
+	&model.Shift{},
+	&model.BlockedTime{},
+	&model.Reservation{},
+	&model.ReservationMenu{},
+	&model.ReservationOption{},
+	&model.AuditLog{},
+	&model.NotificationLog{},
+	&model.NotificationPreference{},
+	&model.LoginChallenge{},
+	&model.WaitlistEntry{},
+	&model.WaitlistMenu{},
+	&model.ReservationSeries{},
+	&model.ReservationSeriesMenu{},
+	&model.ReservationSeriesOption{},
+	&model.CalendarFeed{},
+}
+
+// newTestDB はすべてのモデルのテーブルを作成したインメモリの SQLite を返す。
+// gen_random_uuid() の既定値は SQLite にないため外す（ID は各モデルの BeforeCreate で採番される）
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal("Failed to connect to test database:", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	var dateColumns [][2]string
+	for _, m := range testModels {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range statement.Schema.Fields {
+			if field.DefaultValue == "gen_random_uuid()" {
+				field.DefaultValue = ""
+				field.HasDefaultValue = false
+				field.DefaultValueInterface = nil
+			}
+			if field.IndirectFieldType == reflect.TypeOf(time.Time{}) && (field.DBName == "date" || strings.HasSuffix(field.DBName, "_date")) {
+				dateColumns = append(dateColumns, [2]string{statement.Schema.Table, field.DBName})
+			}
+		}
+	}
+	if err := db.AutoMigrate(testModels...); err != nil {
+		t.Fatal("Failed to migrate test database:", err)
+	}
+
+	// SQLite stores time.Time with the time of day, so keep only the day like a PostgreSQL date
+	// and let the `date = '2006-01-02'` queries match
+	for _, column := range dateColumns {
+		table, name := column[0], column[1]
+		for i, event := range []string{"INSERT", "UPDATE OF " + name} {
+			trigger := fmt.Sprintf(`CREATE TRIGGER "%s_%s_day_%d" AFTER %s ON "%s" BEGIN UPDATE "%s" SET "%s" = substr("%s", 1, 10) WHERE rowid = NEW.rowid; END`,
+				table, name, i, event, table, table, name, name)
+			if err := db.Exec(trigger).Error; err != nil {
+				t.Fatal("Failed to create date trigger:", err)
+			}
+		}
+	}
+	return db
+}
+
+// sentMessage はテスト用の送信サービスが受け取ったメッセージ
+type sentMessage struct {
+	to      string
+	subject string
+	body    string
+}
+
+// fakeEmailService は送信したメールを記録するだけのメール送信サービス。err を設定すると送信に失敗する
+type fakeEmailService struct {
+	mu   sync.Mutex
+	sent []sentMessage
+	err  error
+}
+
+func (f *fakeEmailService) SendEmail(to, subject, body string) error {
+	return f.SendEmailWithAttachments(to, subject, body)
+}
+
+func (f *fakeEmailService) SendEmailWithAttachments(to, subject, body string, attachments ...service.EmailAttachment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, sentMessage{to: to, subject: subject, body: body})
+	return nil
+}
+
+func (f *fakeEmailService) SendResetPasswordEmail(to, token string) error {
+	return nil
+}
+
+func (f *fakeEmailService) SendVerificationEmail(to, token string) error {
+	return nil
+}
+
+func (f *fakeEmailService) messages() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.sent...)
+}
+
+// fakeSMSService は送信した SMS を記録するだけの SMS 送信サービス。err を設定すると送信に失敗する
+type fakeSMSService struct {
+	mu   sync.Mutex
+	sent []sentMessage
+	err  error
+}
+
+func (f *fakeSMSService) SendSMS(to, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, sentMessage{to: to, body: body})
+	return nil
+}
+
+func (f *fakeSMSService) messages() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.sent...)
+}
@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"app/src/config"
+	"app/src/i18n"
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// LoginSenderTestSuite はログインコードの送信のテストスイート
+type LoginSenderTestSuite struct {
+	suite.Suite
+	db           *gorm.DB
+	emailService *fakeEmailService
+	smsService   *fakeSMSService
+	sender       service.LoginSender
+}
+
+func TestLoginSenderSuite(t *testing.T) {
+	suite.Run(t, new(LoginSenderTestSuite))
+}
+
+func (suite *LoginSenderTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+
+	config.LoginSender = "notification"
+	suite.emailService = &fakeEmailService{}
+	suite.smsService = &fakeSMSService{}
+	suite.sender = service.NewLoginSender(suite.db, suite.emailService, suite.smsService)
+}
+
+func (suite *LoginSenderTestSuite) TearDownTest() {
+	config.LoginSender = "stub"
+}
+
+func (suite *LoginSenderTestSuite) notificationLogs() []model.NotificationLog {
+	var logs []model.NotificationLog
+	suite.Require().NoError(suite.db.Find(&logs).Error)
+	return logs
+}
+
+func (suite *LoginSenderTestSuite) Test_SMSでの送信() {
+	suite.Run("コードをSMSで送り_通知ログにはコードを残さない", func() {
+		err := suite.sender.SendLoginCode(model.LoginChannelSMS, "+819012345678", "482913", "", i18n.Japanese)
+		suite.Require().NoError(err)
+
+		sent := suite.smsService.messages()
+		suite.Require().Len(sent, 1)
+		assert.Equal(suite.T(), "+819012345678", sent[0].to)
+		assert.Contains(suite.T(), sent[0].body, "482913")
+
+		logs := suite.notificationLogs()
+		suite.Require().Len(logs, 1)
+		assert.Equal(suite.T(), model.NotificationStatusSent, logs[0].Status)
+		assert.Equal(suite.T(), "notification.login_code.sms", logs[0].Message)
+		assert.NotContains(suite.T(), logs[0].Message, "482913")
+	})
+
+	suite.Run("送信に失敗した場合_エラーを返し失敗として記録する", func() {
+		suite.SetupTest()
+		suite.smsService.err = errors.New("provider unavailable")
+
+		err := suite.sender.SendLoginCode(model.LoginChannelSMS, "+819012345678", "482913", "", i18n.Japanese)
+		assert.Error(suite.T(), err)
+
+		logs := suite.notificationLogs()
+		suite.Require().Len(logs, 1)
+		assert.Equal(suite.T(), model.NotificationStatusFailed, logs[0].Status)
+		assert.Nil(suite.T(), logs[0].SentAt)
+	})
+}
+
+func (suite *LoginSenderTestSuite) Test_メールでの送信() {
+	suite.Run("コードとリンクをメールで送り_通知ログには残さない", func() {
+		link := "https://salon.example.com/v1/auth/customer/magic/secret-link-token"
+		err := suite.sender.SendLoginCode(model.LoginChannelEmail, "hanako@example.com", "482913", link, i18n.English)
+		suite.Require().NoError(err)
+
+		sent := suite.emailService.messages()
+		suite.Require().Len(sent, 1)
+		assert.Contains(suite.T(), sent[0].body, "482913")
+		assert.Contains(suite.T(), sent[0].body, link)
+		assert.Empty(suite.T(), suite.smsService.messages())
+
+		logs := suite.notificationLogs()
+		suite.Require().Len(logs, 1)
+		assert.Equal(suite.T(), "email", logs[0].Type)
+		assert.NotContains(suite.T(), logs[0].Message, "482913")
+		assert.NotContains(suite.T(), logs[0].Message, "secret-link-token")
+	})
+}
+
+func (suite *LoginSenderTestSuite) Test_開発用の送信() {
+	suite.Run("送信せずにコードとリンクをログに出す", func() {
+		config.LoginSender = "stub"
+		sender := service.NewLoginSender(suite.db, suite.emailService, suite.smsService)
+		var output bytes.Buffer
+		original := utils.Log.Out
+		utils.Log.SetOutput(&output)
+		defer utils.Log.SetOutput(original)
+
+		link := "http://localhost:3000/v1/auth/customer/magic/secret-link-token"
+		err := sender.SendLoginCode(model.LoginChannelEmail, "hanako@example.com", "482913", link, i18n.Japanese)
+		suite.Require().NoError(err)
+
+		var entry map[string]interface{}
+		suite.Require().NoError(json.Unmarshal(output.Bytes(), &entry))
+		assert.Equal(suite.T(), "482913", entry["code"])
+		assert.Equal(suite.T(), link, entry["magic_link"])
+		assert.NotContains(suite.T(), output.String(), "hanako@example.com")
+		assert.Empty(suite.T(), suite.emailService.messages())
+		assert.Empty(suite.T(), suite.notificationLogs())
+	})
+}
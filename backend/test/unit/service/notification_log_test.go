@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// NotificationLogTestSuite は通知ログの宛先・本文の暗号化のテストスイート
+type NotificationLogTestSuite struct {
+	suite.Suite
+	db *gorm.DB
+}
+
+func TestNotificationLogSuite(t *testing.T) {
+	suite.Run(t, new(NotificationLogTestSuite))
+}
+
+func (suite *NotificationLogTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+}
+
+func (suite *NotificationLogTestSuite) Test_暗号化() {
+	suite.Run("宛先・本文・エラーは暗号化して保存し_読み込み時に復号する", func() {
+		log := model.NotificationLog{
+			Type:         "email",
+			Recipient:    "Hanako@Example.com",
+			Subject:      "ご予約確定のお知らせ",
+			Message:      "山田 様 ご予約が確定しました",
+			Status:       model.NotificationStatusFailed,
+			ErrorMessage: "550 mailbox Hanako@Example.com unavailable",
+		}
+		suite.Require().NoError(suite.db.Create(&log).Error)
+
+		var stored struct {
+			Recipient    string
+			Message      string
+			ErrorMessage string
+		}
+		suite.Require().NoError(suite.db.Raw("SELECT recipient, message, error_message FROM notification_logs WHERE id = ?", log.ID).Scan(&stored).Error)
+		for _, value := range []string{stored.Recipient, stored.Message, stored.ErrorMessage} {
+			assert.NotContains(suite.T(), value, "Hanako")
+			assert.NotContains(suite.T(), value, "山田")
+		}
+
+		summaries, _, err := service.NewNotificationLogService(suite.db).GetNotificationLogs(service.ListOptions{}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		suite.Require().Len(summaries, 1)
+		assert.Equal(suite.T(), "Hanako@Example.com", summaries[0].Recipient)
+		assert.Equal(suite.T(), "550 mailbox Hanako@Example.com unavailable", summaries[0].ErrorMessage)
+	})
+
+	suite.Run("宛先のブラインドインデックスは顧客の連絡先のインデックスと一致する", func() {
+		suite.SetupTest()
+		customer := model.Customer{Name: "山田花子", Phone: "090-1234-5678", Email: "hanako@example.com", IsActive: true}
+		suite.Require().NoError(suite.db.Create(&customer).Error)
+
+		sms := model.NotificationLog{Type: "sms", Recipient: "+819012345678", Message: "notification.login_code.sms", Status: model.NotificationStatusSent}
+		email := model.NotificationLog{Type: "email", Recipient: "HANAKO@example.com ", Message: "notification.login_code.email", Status: model.NotificationStatusSent}
+		suite.Require().NoError(suite.db.Create(&sms).Error)
+		suite.Require().NoError(suite.db.Create(&email).Error)
+
+		assert.Equal(suite.T(), customer.PhoneIndex, sms.RecipientIndex)
+		assert.Equal(suite.T(), customer.EmailIndex, email.RecipientIndex)
+	})
+
+	suite.Run("顧客IDのない通知は統合時に宛先のインデックスで付け替える", func() {
+		suite.SetupTest()
+		survivor := model.Customer{Name: "山田花子", IsActive: true}
+		duplicate := model.Customer{Name: "山田 花子", Phone: "+819012345678", IsActive: true}
+		suite.Require().NoError(suite.db.Create(&survivor).Error)
+		suite.Require().NoError(suite.db.Create(&duplicate).Error)
+		log := model.NotificationLog{Type: "sms", Recipient: "+819012345678", Message: "notification.login_code.sms", Status: model.NotificationStatusSent}
+		suite.Require().NoError(suite.db.Create(&log).Error)
+
+		result, err := service.NewCustomerService(suite.db).MergeCustomers(survivor.ID, duplicate.ID, service.AuditActor{})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), int64(1), result.Moved["notification_logs"])
+		var moved model.NotificationLog
+		suite.Require().NoError(suite.db.Where("id = ?", log.ID).First(&moved).Error)
+		suite.Require().NotNil(moved.CustomerID)
+		assert.Equal(suite.T(), survivor.ID, *moved.CustomerID)
+		assert.Equal(suite.T(), log.RecipientIndex, moved.RecipientIndex)
+	})
+}
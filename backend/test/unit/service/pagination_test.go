@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// PaginationTestSuite は一覧取得のページングと並び順のテストスイート。通知履歴の一覧で確認する
+type PaginationTestSuite struct {
+	suite.Suite
+	db                     *gorm.DB
+	notificationLogService *service.NotificationLogService
+	logs                   []model.NotificationLog
+}
+
+func TestPaginationSuite(t *testing.T) {
+	suite.Run(t, new(PaginationTestSuite))
+}
+
+func (suite *PaginationTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+
+	// 作成日時が同じ行を含め、ページの境目で取りこぼしや重複がないことを確かめる
+	base := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	suite.logs = nil
+	for i := 0; i < 7; i++ {
+		log := model.NotificationLog{
+			ID:        uuid.New(),
+			Type:      "email",
+			Recipient: "customer@example.com",
+			Message:   "message",
+			Status:    model.NotificationStatusSent,
+			CreatedAt: base.Add(time.Duration(i/2) * time.Minute),
+		}
+		suite.Require().NoError(suite.db.Create(&log).Error)
+		suite.logs = append(suite.logs, log)
+	}
+	suite.notificationLogService = service.NewNotificationLogService(suite.db)
+}
+
+// collect はカーソルをたどって全ページの ID を集める
+func (suite *PaginationTestSuite) collect(options service.ListOptions) []uuid.UUID {
+	var ids []uuid.UUID
+	for pages := 0; pages < 10; pages++ {
+		logs, page, err := suite.notificationLogService.GetNotificationLogs(options, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		for _, log := range logs {
+			ids = append(ids, log.ID)
+		}
+		if !page.HasNext {
+			suite.Empty(page.NextCursor)
+			return ids
+		}
+		options.Cursor = page.NextCursor
+	}
+	suite.FailNow("pagination did not finish")
+	return nil
+}
+
+func (suite *PaginationTestSuite) Test_カーソル方式() {
+	suite.Run("カーソルをたどると全件を重複なく新しい順に取得できる", func() {
+		ids := suite.collect(service.ListOptions{Limit: 3})
+
+		suite.Require().Len(ids, len(suite.logs))
+		seen := map[uuid.UUID]bool{}
+		var previous *model.NotificationLog
+		for _, id := range ids {
+			assert.False(suite.T(), seen[id])
+			seen[id] = true
+
+			var log model.NotificationLog
+			suite.Require().NoError(suite.db.First(&log, "id = ?", id).Error)
+			if previous != nil {
+				assert.False(suite.T(), log.CreatedAt.After(previous.CreatedAt))
+			}
+			previous = &log
+		}
+	})
+
+	suite.Run("昇順でもたどれる", func() {
+		ids := suite.collect(service.ListOptions{Limit: 2, Sort: "created_at"})
+		suite.Require().Len(ids, len(suite.logs))
+
+		var first model.NotificationLog
+		suite.Require().NoError(suite.db.First(&first, "id = ?", ids[0]).Error)
+		assert.True(suite.T(), first.CreatedAt.Equal(suite.logs[0].CreatedAt))
+	})
+
+	suite.Run("カーソル方式では総件数を数えない", func() {
+		_, first, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Limit: 3}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+
+		_, page, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Limit: 3, Cursor: first.NextCursor}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		assert.Nil(suite.T(), page.Total)
+		assert.Zero(suite.T(), page.Page)
+		assert.Equal(suite.T(), "-created_at", page.Sort)
+	})
+
+	suite.Run("カーソルと異なる並び順を指定した場合_エラーになる", func() {
+		_, first, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Limit: 3}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+
+		_, _, err = suite.notificationLogService.GetNotificationLogs(service.ListOptions{Limit: 3, Cursor: first.NextCursor, Sort: "created_at"}, service.NotificationLogFilter{})
+		assert.True(suite.T(), errors.Is(err, service.ErrInvalidCursor))
+	})
+
+	suite.Run("壊れたカーソルの場合_エラーになる", func() {
+		_, _, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Cursor: "not-a-cursor"}, service.NotificationLogFilter{})
+		assert.True(suite.T(), errors.Is(err, service.ErrInvalidCursor))
+	})
+}
+
+func (suite *PaginationTestSuite) Test_オフセット方式() {
+	suite.Run("ページ番号で取得し_指定すれば総件数を返す", func() {
+		logs, page, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Page: 3, Limit: 3, WithTotal: true}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		assert.Len(suite.T(), logs, 1)
+		assert.Equal(suite.T(), 3, page.Page)
+		assert.False(suite.T(), page.HasNext)
+		suite.Require().NotNil(page.Total)
+		assert.Equal(suite.T(), int64(len(suite.logs)), *page.Total)
+	})
+
+	suite.Run("次のページがある場合_カーソルでも続きを取得できる", func() {
+		first, page, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Page: 1, Limit: 3}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		suite.Require().True(page.HasNext)
+
+		byCursor, _, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Limit: 3, Cursor: page.NextCursor}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		byOffset, _, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Page: 2, Limit: 3}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+
+		assert.Len(suite.T(), first, 3)
+		assert.Equal(suite.T(), ids(byOffset), ids(byCursor))
+	})
+}
+
+func (suite *PaginationTestSuite) Test_並び順の指定() {
+	suite.Run("指定できない項目の場合_エラーになる", func() {
+		_, _, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{Sort: "recipient"}, service.NotificationLogFilter{})
+		assert.True(suite.T(), errors.Is(err, service.ErrInvalidSort))
+	})
+}
+
+func (suite *PaginationTestSuite) Test_通知履歴の項目() {
+	suite.Run("一覧には本文を含めない", func() {
+		suite.Require().NoError(suite.db.Model(&model.NotificationLog{}).Where("1 = 1").Update("message", "secret body").Error)
+
+		logs, _, err := suite.notificationLogService.GetNotificationLogs(service.ListOptions{}, service.NotificationLogFilter{})
+		suite.Require().NoError(err)
+		body, err := json.Marshal(logs)
+		suite.Require().NoError(err)
+		assert.NotContains(suite.T(), string(body), "secret body")
+	})
+}
+
+func ids(logs []service.NotificationLogSummary) []uuid.UUID {
+	result := make([]uuid.UUID, len(logs))
+	for i, log := range logs {
+		result[i] = log.ID
+	}
+	return result
+}
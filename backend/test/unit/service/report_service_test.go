@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/service"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ReportServiceTestSuite は売上・予約レポートの集計のテストスイート
+type ReportServiceTestSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	reportService *service.ReportService
+
+	customer  model.Customer
+	sato      model.Staff
+	suzuki    model.Staff
+	cut       model.Menu
+	color     model.Menu
+	treatment model.Option
+}
+
+func TestReportServiceSuite(t *testing.T) {
+	suite.Run(t, new(ReportServiceTestSuite))
+}
+
+func (suite *ReportServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.reportService = service.NewReportService(suite.db)
+
+	suite.customer = model.Customer{Name: "山田花子", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+	suite.sato = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.suzuki = model.Staff{Name: "鈴木", Email: "suzuki@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.sato).Error)
+	suite.Require().NoError(suite.db.Create(&suite.suzuki).Error)
+	suite.cut = model.Menu{Name: "カット", Duration: 60, Price: 5000, Category: "カット", IsActive: true}
+	suite.color = model.Menu{Name: "カラー", Duration: 90, Price: 8000, Category: "カラー", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.cut).Error)
+	suite.Require().NoError(suite.db.Create(&suite.color).Error)
+	suite.treatment = model.Option{Name: "トリートメント", Duration: 15, Price: 2000, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.treatment).Error)
+
+	// 2026-03-02 is a Monday
+	suite.reserve("2026-03-02", suite.sato, model.ReservationStatusCompleted, []model.Menu{suite.cut}, nil)
+	suite.reserve("2026-03-04", suite.sato, model.ReservationStatusCompleted, []model.Menu{suite.cut, suite.color}, []model.Option{suite.treatment})
+	suite.reserve("2026-03-04", suite.suzuki, model.ReservationStatusCancelled, []model.Menu{suite.color}, nil)
+	suite.reserve("2026-03-10", suite.suzuki, model.ReservationStatusCompleted, []model.Menu{suite.color}, nil)
+	suite.reserve("2026-03-10", suite.sato, model.ReservationStatusNoShow, []model.Menu{suite.cut}, nil)
+	// Outside of the range used by the tests
+	suite.reserve("2026-03-16", suite.sato, model.ReservationStatusCompleted, []model.Menu{suite.cut}, nil)
+}
+
+func (suite *ReportServiceTestSuite) reserve(date string, staff model.Staff, status model.ReservationStatus, menus []model.Menu, options []model.Option) {
+	day, err := time.Parse("2006-01-02", date)
+	suite.Require().NoError(err)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 10, 0, 0, 0, time.Local)
+	reservation := model.Reservation{
+		CustomerID:      suite.customer.ID,
+		StaffID:         staff.ID,
+		ReservationDate: day,
+		StartTime:       start,
+		EndTime:         start.Add(time.Hour),
+		TotalDuration:   60,
+		Status:          status,
+	}
+	for _, menu := range menus {
+		reservation.TotalPrice += menu.Price
+		reservation.ReservationMenus = append(reservation.ReservationMenus, model.ReservationMenu{
+			MenuID: menu.ID, Quantity: 1, UnitPrice: menu.Price, TotalPrice: menu.Price,
+		})
+	}
+	for _, option := range options {
+		reservation.TotalPrice += option.Price
+		reservation.ReservationOptions = append(reservation.ReservationOptions, model.ReservationOption{
+			OptionID: option.ID, Quantity: 1, UnitPrice: option.Price, TotalPrice: option.Price,
+		})
+	}
+	suite.Require().NoError(suite.db.Create(&reservation).Error)
+}
+
+func (suite *ReportServiceTestSuite) Test_売上レポート() {
+	suite.Run("完了した予約だけを売上に数え_状態ごとの件数を返す", func() {
+		report, err := suite.reportService.GetSalesReport(service.ReportFilter{DateFrom: "2026-03-02", DateTo: "2026-03-15"})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), int64(28000), report.Summary.Sales)
+		assert.Equal(suite.T(), int64(3), report.Summary.CompletedCount)
+		assert.Equal(suite.T(), int64(9333), report.Summary.AverageTicket)
+		assert.Equal(suite.T(), int64(5), report.Summary.Reservations)
+		assert.Equal(suite.T(), int64(1), report.Summary.StatusCounts["cancelled"])
+		assert.Equal(suite.T(), int64(1), report.Summary.StatusCounts["no_show"])
+		assert.Equal(suite.T(), int64(0), report.Summary.StatusCounts["pending"])
+
+		suite.Require().Len(report.Periods, 3)
+		assert.Equal(suite.T(), "2026-03-02", report.Periods[0].PeriodStart)
+		assert.Equal(suite.T(), "2026-03-04", report.Periods[1].PeriodStart)
+		assert.Equal(suite.T(), int64(15000), report.Periods[1].Sales)
+		assert.Equal(suite.T(), int64(2), report.Periods[1].Reservations)
+	})
+
+	suite.Run("週次は月曜始まりでまとめる", func() {
+		report, err := suite.reportService.GetSalesReport(service.ReportFilter{Period: service.ReportPeriodWeekly, DateFrom: "2026-03-02", DateTo: "2026-03-15"})
+
+		suite.Require().NoError(err)
+		suite.Require().Len(report.Periods, 2)
+		assert.Equal(suite.T(), "2026-03-02", report.Periods[0].PeriodStart)
+		assert.Equal(suite.T(), int64(20000), report.Periods[0].Sales)
+		assert.Equal(suite.T(), "2026-03-09", report.Periods[1].PeriodStart)
+		assert.Equal(suite.T(), int64(8000), report.Periods[1].Sales)
+	})
+
+	suite.Run("メニュー・オプション・カテゴリごとの売上を多い順に返す", func() {
+		report, err := suite.reportService.GetSalesReport(service.ReportFilter{DateFrom: "2026-03-02", DateTo: "2026-03-15"})
+
+		suite.Require().NoError(err)
+		suite.Require().Len(report.Menus, 2)
+		assert.Equal(suite.T(), "カラー", report.Menus[0].Name)
+		assert.Equal(suite.T(), int64(2), report.Menus[0].Quantity)
+		assert.Equal(suite.T(), int64(16000), report.Menus[0].Revenue)
+		assert.Equal(suite.T(), int64(10000), report.Menus[1].Revenue)
+		suite.Require().Len(report.Options, 1)
+		assert.Equal(suite.T(), int64(2000), report.Options[0].Revenue)
+		suite.Require().Len(report.Categories, 3)
+		assert.Equal(suite.T(), "カラー", report.Categories[0].Name)
+		assert.Equal(suite.T(), "未分類", report.Categories[2].Name)
+		assert.Equal(suite.T(), int64(2000), report.Categories[2].Revenue)
+	})
+
+	suite.Run("スタッフで絞り込む", func() {
+		report, err := suite.reportService.GetSalesReport(service.ReportFilter{DateFrom: "2026-03-02", DateTo: "2026-03-15", StaffID: &suite.suzuki.ID})
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), int64(8000), report.Summary.Sales)
+		assert.Equal(suite.T(), int64(2), report.Summary.Reservations)
+	})
+
+	suite.Run("不正な期間の種類や逆順の日付はエラーにする", func() {
+		_, err := suite.reportService.GetSalesReport(service.ReportFilter{Period: "yearly"})
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.invalid_period")))
+
+		_, err = suite.reportService.GetSalesReport(service.ReportFilter{DateFrom: "2026-03-15", DateTo: "2026-03-02"})
+		assert.True(suite.T(), errors.Is(err, service.ErrDateRangeReversed))
+
+		_, err = suite.reportService.GetSalesReport(service.ReportFilter{DateFrom: "2020-01-01", DateTo: "2026-03-02"})
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.date_range_too_long")))
+	})
+}
+
+func (suite *ReportServiceTestSuite) Test_CSV出力() {
+	suite.Run("BOM 付きで見出しと期間ごとの行を書き出す", func() {
+		report, err := suite.reportService.GetSalesReport(service.ReportFilter{Period: service.ReportPeriodWeekly, DateFrom: "2026-03-02", DateTo: "2026-03-15"})
+		suite.Require().NoError(err)
+
+		var buf bytes.Buffer
+		suite.Require().NoError(service.WriteSalesReportCSV(&buf, report, service.ReportSectionPeriods))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		suite.Require().Len(lines, 3)
+		assert.Equal(suite.T(), "\ufeff期間開始,売上,完了件数,平均客単価,予約件数,仮予約,確定,完了,キャンセル,無断キャンセル", lines[0])
+		assert.Equal(suite.T(), "2026-03-02,20000,2,10000,3,0,0,2,1,0", lines[1])
+	})
+
+	suite.Run("未知の内訳はエラーにする", func() {
+		err := service.WriteSalesReportCSV(&bytes.Buffer{}, &service.SalesReport{}, "staff")
+
+		assert.True(suite.T(), errors.Is(err, apperror.Validation("error.invalid_section")))
+	})
+}
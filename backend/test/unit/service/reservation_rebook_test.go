@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ReservationRebookTestSuite は前回と同じ内容での再予約の候補のテストスイート
+type ReservationRebookTestSuite struct {
+	suite.Suite
+	db                 *gorm.DB
+	reservationService *service.ReservationService
+
+	date     time.Time
+	customer model.Customer
+	sato     model.Staff
+	cut      model.Menu
+	color    model.Menu
+	spa      model.Option
+	source   model.Reservation
+}
+
+func TestReservationRebookSuite(t *testing.T) {
+	suite.Run(t, new(ReservationRebookTestSuite))
+}
+
+func (suite *ReservationRebookTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	suite.reservationService = service.NewReservationService(suite.db)
+	suite.date = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 3)
+
+	suite.customer = model.Customer{Name: "山田花子", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+	suite.sato = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.sato).Error)
+	suite.cut = model.Menu{Name: "カット", Duration: 60, Price: 5000, Category: "カット", IsActive: true}
+	suite.color = model.Menu{Name: "カラー", Duration: 90, Price: 8000, Category: "カラー", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.cut).Error)
+	suite.Require().NoError(suite.db.Create(&suite.color).Error)
+	suite.spa = model.Option{Name: "ヘッドスパ", Duration: 15, Price: 2000, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.spa).Error)
+
+	// The previous visit a month ago: cut at 4800 yen, colour and a head spa
+	previous := time.Now().AddDate(0, -1, 0)
+	start := time.Date(previous.Year(), previous.Month(), previous.Day(), 10, 0, 0, 0, time.Local)
+	suite.source = model.Reservation{
+		CustomerID:      suite.customer.ID,
+		StaffID:         suite.sato.ID,
+		ReservationDate: start,
+		StartTime:       start,
+		EndTime:         start.Add(165 * time.Minute),
+		TotalDuration:   165,
+		TotalPrice:      14800,
+		Status:          model.ReservationStatusCompleted,
+		ReservationMenus: []model.ReservationMenu{
+			{MenuID: suite.cut.ID, Quantity: 1, UnitPrice: 4800, TotalPrice: 4800},
+			{MenuID: suite.color.ID, Quantity: 1, UnitPrice: 8000, TotalPrice: 8000},
+		},
+		ReservationOptions: []model.ReservationOption{
+			{OptionID: suite.spa.ID, Quantity: 1, UnitPrice: 2000, TotalPrice: 2000},
+		},
+	}
+	suite.Require().NoError(suite.db.Create(&suite.source).Error)
+
+	// Colour is no longer offered, so the rebooking is a 60 minute cut
+	suite.Require().NoError(suite.db.Model(&model.Menu{}).Where("id = ?", suite.color.ID).Update("is_active", false).Error)
+}
+
+// at は候補を探す日の指定時刻を予約と同じタイムゾーンで返す
+func (suite *ReservationRebookTestSuite) at(hour, minute int) time.Time {
+	return time.Date(suite.date.Year(), suite.date.Month(), suite.date.Day(), hour, minute, 0, 0, time.Local)
+}
+
+// workFrom10To13 は候補を探す日に10:00-13:00のシフトを作り、10:00-11:00を予約で埋める
+func (suite *ReservationRebookTestSuite) workFrom10To13(staff model.Staff) {
+	suite.Require().NoError(suite.db.Create(&model.Shift{
+		StaffID: staff.ID, Date: suite.date, StartTime: suite.at(10, 0), EndTime: suite.at(13, 0), IsActive: true,
+	}).Error)
+	suite.Require().NoError(suite.db.Create(&model.Reservation{
+		CustomerID: suite.customer.ID, StaffID: staff.ID, ReservationDate: suite.date,
+		StartTime: suite.at(10, 0), EndTime: suite.at(11, 0), TotalDuration: 60, TotalPrice: 5000,
+		Status: model.ReservationStatusConfirmed,
+	}).Error)
+}
+
+func (suite *ReservationRebookTestSuite) startTimes(slots []service.RebookSlot) []string {
+	var times []string
+	for _, slot := range slots {
+		times = append(times, slot.StartTime)
+	}
+	return times
+}
+
+func (suite *ReservationRebookTestSuite) Test_再予約の候補() {
+	suite.Run("提供終了と価格改定を示し_現在の内容で同じスタッフの直近の空き枠を返す", func() {
+		suite.workFrom10To13(suite.sato)
+
+		suggestion, err := suite.reservationService.GetRebookSuggestions(suite.source.ID, suite.date.Format("2006-01-02"), "", "", 0)
+
+		suite.Require().NoError(err)
+		assert.True(suite.T(), suggestion.StaffAvailable)
+		suite.Require().Len(suggestion.Menus, 2)
+		assert.True(suite.T(), suggestion.Menus[0].Repriced)
+		assert.Equal(suite.T(), 4800, suggestion.Menus[0].PreviousPrice)
+		assert.Equal(suite.T(), 5000, suggestion.Menus[0].CurrentPrice)
+		assert.True(suite.T(), suggestion.Menus[1].Deactivated)
+		assert.Equal(suite.T(), "カラー", suggestion.Menus[1].Name)
+		assert.Equal(suite.T(), []uuid.UUID{suite.cut.ID}, suggestion.MenuIDs)
+		assert.Equal(suite.T(), []uuid.UUID{suite.spa.ID}, suggestion.OptionIDs)
+		assert.Equal(suite.T(), 60, suggestion.TotalDuration)
+		assert.Equal(suite.T(), 7000, suggestion.TotalPrice)
+
+		// 10:00-11:00 is booked and followed by a 15 minute buffer
+		assert.Equal(suite.T(), []string{"11:15:00", "11:30:00", "11:45:00"}, suite.startTimes(suggestion.Slots))
+		assert.Equal(suite.T(), suite.sato.ID, suggestion.Slots[0].StaffID)
+	})
+
+	suite.Run("希望の時間帯に収まる枠だけを返す", func() {
+		suite.SetupTest()
+		suite.workFrom10To13(suite.sato)
+
+		suggestion, err := suite.reservationService.GetRebookSuggestions(suite.source.ID, suite.date.Format("2006-01-02"), "11:30:00", "13:00:00", 5)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), []string{"11:30:00", "11:45:00", "12:00:00"}, suite.startTimes(suggestion.Slots))
+	})
+
+	suite.Run("担当スタッフが退職した場合はメニューを担当できるスタッフの枠を返す", func() {
+		suite.SetupTest()
+		suite.Require().NoError(suite.db.Model(&model.Staff{}).Where("id = ?", suite.sato.ID).Update("is_active", false).Error)
+		colorist := model.Staff{Name: "鈴木", Email: "suzuki@example.com", Specialties: "カラー", IsActive: true}
+		stylist := model.Staff{Name: "高橋", Email: "takahashi@example.com", Specialties: "カット、カラー", IsActive: true}
+		suite.Require().NoError(suite.db.Create(&colorist).Error)
+		suite.Require().NoError(suite.db.Create(&stylist).Error)
+		suite.workFrom10To13(colorist)
+		suite.workFrom10To13(stylist)
+
+		suggestion, err := suite.reservationService.GetRebookSuggestions(suite.source.ID, suite.date.Format("2006-01-02"), "", "", 0)
+
+		suite.Require().NoError(err)
+		assert.False(suite.T(), suggestion.StaffAvailable)
+		suite.Require().NotEmpty(suggestion.Slots)
+		for _, slot := range suggestion.Slots {
+			assert.Equal(suite.T(), stylist.ID, slot.StaffID)
+		}
+	})
+
+	suite.Run("提供中のメニューが残っていない場合はエラーにする", func() {
+		suite.SetupTest()
+		suite.Require().NoError(suite.db.Model(&model.Menu{}).Where("id = ?", suite.cut.ID).Update("is_active", false).Error)
+
+		_, err := suite.reservationService.GetRebookSuggestions(suite.source.ID, "", "", "", 0)
+
+		assert.True(suite.T(), errors.Is(err, apperror.PolicyViolation("error.rebook_menus_unavailable")))
+	})
+
+	suite.Run("今日以前の日付や逆順の時間帯はエラーにする", func() {
+		suite.SetupTest()
+
+		_, err := suite.reservationService.GetRebookSuggestions(suite.source.ID, time.Now().Format("2006-01-02"), "", "", 0)
+		assert.True(suite.T(), errors.Is(err, service.ErrBookingTooSoon))
+
+		_, err = suite.reservationService.GetRebookSuggestions(suite.source.ID, "", "15:00:00", "11:00:00", 0)
+		assert.True(suite.T(), errors.Is(err, service.ErrEndBeforeStart))
+	})
+}
@@ -0,0 +1,206 @@
+package service_test
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ReservationSeriesServiceTestSuite は繰り返し予約の作成のテストスイート
+type ReservationSeriesServiceTestSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	seriesService *service.ReservationSeriesService
+	confirmations *confirmationRecorder
+
+	start    time.Time
+	customer model.Customer
+	staff    model.Staff
+	menu     model.Menu
+}
+
+func TestReservationSeriesServiceSuite(t *testing.T) {
+	suite.Run(t, new(ReservationSeriesServiceTestSuite))
+}
+
+func (suite *ReservationSeriesServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	reservationService := service.NewReservationService(suite.db)
+	suite.confirmations = &confirmationRecorder{}
+	reservationService.AddConfirmationHandler(suite.confirmations)
+	suite.seriesService = service.NewReservationSeriesService(suite.db, reservationService)
+
+	suite.start = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 3)
+
+	suite.customer = model.Customer{Name: "山田花子", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.customer).Error)
+	suite.staff = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.staff).Error)
+	suite.menu = model.Menu{Name: "カット", Duration: 60, Price: 5000, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.menu).Error)
+
+	for i := 0; i < 3; i++ {
+		suite.addShift(suite.start.AddDate(0, 0, i))
+	}
+}
+
+// addShift は指定日の10:00-18:00のシフトを予約と同じタイムゾーンで作る
+func (suite *ReservationSeriesServiceTestSuite) addShift(date time.Time) {
+	at := func(hour int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, time.Local)
+	}
+	suite.Require().NoError(suite.db.Create(&model.Shift{
+		StaffID:   suite.staff.ID,
+		Date:      date,
+		StartTime: at(10),
+		EndTime:   at(18),
+		IsActive:  true,
+	}).Error)
+}
+
+// confirmationRecorder は予約確定の通知を記録する
+type confirmationRecorder struct {
+	reservations int
+	series       [][]*model.Reservation
+}
+
+func (r *confirmationRecorder) OnReservationConfirmed(reservation *model.Reservation) {
+	r.reservations++
+}
+
+func (r *confirmationRecorder) OnSeriesConfirmed(reservations []*model.Reservation) {
+	r.series = append(r.series, reservations)
+}
+
+func (suite *ReservationSeriesServiceTestSuite) create(rrule string) (*model.ReservationSeries, service.SeriesConflicts, error) {
+	return suite.createAcknowledging(rrule, false)
+}
+
+func (suite *ReservationSeriesServiceTestSuite) createAcknowledging(rrule string, acknowledgeRisk bool) (*model.ReservationSeries, service.SeriesConflicts, error) {
+	return suite.seriesService.CreateSeries(suite.customer.ID, suite.staff.ID, suite.start.Format("2006-01-02"), "10:00:00",
+		rrule, []uuid.UUID{suite.menu.ID}, nil, "", false, acknowledgeRisk)
+}
+
+func (suite *ReservationSeriesServiceTestSuite) count(m interface{}) int64 {
+	var count int64
+	suite.Require().NoError(suite.db.Model(m).Count(&count).Error)
+	return count
+}
+
+func (suite *ReservationSeriesServiceTestSuite) Test_シリーズの作成() {
+	suite.Run("全日程を確定済みの予約として作成する", func() {
+		series, conflicts, err := suite.create("FREQ=DAILY;COUNT=3")
+
+		suite.Require().NoError(err)
+		assert.Empty(suite.T(), conflicts)
+		suite.Require().Len(series.Reservations, 3)
+		for _, reservation := range series.Reservations {
+			assert.Equal(suite.T(), model.ReservationStatusConfirmed, reservation.Status)
+		}
+	})
+
+	suite.Run("上限を超える回数は切り詰めずにエラーにする", func() {
+		suite.SetupTest()
+
+		_, _, err := suite.create("FREQ=DAILY;COUNT=27")
+
+		assert.True(suite.T(), errors.Is(err, service.ErrSeriesTooManyOccurrences))
+		assert.Zero(suite.T(), suite.count(&model.ReservationSeries{}))
+		assert.Zero(suite.T(), suite.count(&model.Reservation{}))
+	})
+
+	suite.Run("予約できる期間を超える日程があればエラーにする", func() {
+		suite.SetupTest()
+
+		// 3 days ahead plus 13 weeks ends beyond the 90 day booking window
+		_, _, err := suite.create("FREQ=WEEKLY;COUNT=14")
+
+		assert.True(suite.T(), errors.Is(err, service.ErrSeriesTooFar))
+		assert.Zero(suite.T(), suite.count(&model.ReservationSeries{}))
+	})
+
+	suite.Run("途中の予約の保存に失敗した場合はシリーズも作成しない", func() {
+		suite.SetupTest()
+		saved := 0
+		suite.Require().NoError(suite.db.Callback().Create().Before("gorm:create").Register("test:fail_second_reservation", func(db *gorm.DB) {
+			if db.Statement.Table != "reservations" {
+				return
+			}
+			saved++
+			if saved == 2 {
+				db.AddError(errors.New("disk full"))
+			}
+		}))
+
+		_, _, err := suite.create("FREQ=DAILY;COUNT=3")
+
+		suite.Require().EqualError(err, "disk full")
+		assert.Zero(suite.T(), suite.count(&model.ReservationSeries{}))
+		assert.Zero(suite.T(), suite.count(&model.Reservation{}))
+		assert.Zero(suite.T(), suite.count(&model.ReservationMenu{}))
+	})
+}
+
+func (suite *ReservationSeriesServiceTestSuite) Test_シリーズの確定通知() {
+	suite.Run("シリーズ全体で1回だけ通知する", func() {
+		_, _, err := suite.create("FREQ=DAILY;COUNT=3")
+
+		suite.Require().NoError(err)
+		assert.Zero(suite.T(), suite.confirmations.reservations)
+		suite.Require().Len(suite.confirmations.series, 1)
+		suite.Require().Len(suite.confirmations.series[0], 3)
+		assert.Equal(suite.T(), suite.customer.ID, suite.confirmations.series[0][0].Customer.ID)
+	})
+}
+
+func (suite *ReservationSeriesServiceTestSuite) Test_リスクの高い顧客() {
+	defer func(warn, confirm int) {
+		config.RiskWarnScore, config.RiskConfirmScore = warn, confirm
+	}(config.RiskWarnScore, config.RiskConfirmScore)
+	config.RiskWarnScore, config.RiskConfirmScore = 2, 4
+
+	addNoShows := func() {
+		past := time.Now().AddDate(0, 0, -7)
+		for i := 0; i < 2; i++ {
+			suite.Require().NoError(suite.db.Create(&model.Reservation{
+				CustomerID:      suite.customer.ID,
+				StaffID:         suite.staff.ID,
+				ReservationDate: past,
+				StartTime:       past.Add(time.Duration(i) * time.Hour),
+				EndTime:         past.Add(time.Duration(i)*time.Hour + 30*time.Minute),
+				Status:          model.ReservationStatusNoShow,
+			}).Error)
+		}
+	}
+
+	suite.Run("確認なしではシリーズを作成しない", func() {
+		suite.SetupTest()
+		addNoShows()
+
+		_, _, err := suite.create("FREQ=DAILY;COUNT=3")
+
+		assert.True(suite.T(), errors.Is(err, service.ErrRiskConfirmation))
+		assert.Zero(suite.T(), suite.count(&model.ReservationSeries{}))
+	})
+
+	suite.Run("スタッフが確認すれば作成できる", func() {
+		suite.SetupTest()
+		addNoShows()
+
+		series, _, err := suite.createAcknowledging("FREQ=DAILY;COUNT=3", true)
+
+		suite.Require().NoError(err)
+		assert.Len(suite.T(), series.Reservations, 3)
+		risk, err := suite.seriesService.GetCustomerRisk(suite.customer.ID)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), service.CustomerRiskLevelHigh, risk.Level)
+	})
+}
@@ -1,9 +1,12 @@
 package service_test
 
 import (
+	"app/src/apperror"
+	"app/src/i18n"
 	"app/src/model"
 	"app/src/service"
 	"app/test/mocks"
+	"errors"
 	"testing"
 	"time"
 
@@ -44,6 +47,15 @@ func (suite *ReservationServiceTestSuite) SetupTest() {
 	suite.reservationService = service.NewReservationService(suite.mockDB)
 }
 
+// japaneseMessage はエラーをクライアントに返す日本語の文言にする
+func japaneseMessage(err error) string {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Message(i18n.Japanese)
+	}
+	return err.Error()
+}
+
 func (suite *ReservationServiceTestSuite) TearDownTest() {
 	// テスト後のクリーンアップ
 	if suite.mockReservationRepo != nil {
@@ -170,11 +182,14 @@ func (suite *ReservationServiceTestSuite) Test_予約作成_エラーケース()
 			[]uuid.UUID{uuid.New()}, // ダミーメニューID
 			[]uuid.UUID{},
 			"テスト予約",
+			false,
+			"",
+			false,
 		)
 		
 		// Then: 過去日時エラーが返される
 		assert.Error(suite.T(), err)
-		assert.Contains(suite.T(), err.Error(), "翌日以降")
+		assert.Contains(suite.T(), japaneseMessage(err), "翌日以降")
 		assert.Nil(suite.T(), result)
 	})
 	
@@ -191,11 +206,14 @@ func (suite *ReservationServiceTestSuite) Test_予約作成_エラーケース()
 			[]uuid.UUID{uuid.New()}, // ダミーメニューID
 			[]uuid.UUID{},
 			"テスト予約",
+			false,
+			"",
+			false,
 		)
 		
 		// Then: 期間制限エラーが返される
 		assert.Error(suite.T(), err)
-		assert.Contains(suite.T(), err.Error(), "90日以内")
+		assert.Contains(suite.T(), japaneseMessage(err), "90日以内")
 		assert.Nil(suite.T(), result)
 	})
 }
@@ -228,6 +246,7 @@ func (suite *ReservationServiceTestSuite) Test_予約更新_エラーケース()
 			[]uuid.UUID{uuid.New()},
 			[]uuid.UUID{},
 			"更新テスト",
+			false,
 		)
 		
 		// Then: 更新不可エラー（実際のサービスロジックで処理される）
@@ -249,6 +268,7 @@ func (suite *ReservationServiceTestSuite) Test_予約更新_エラーケース()
 			[]uuid.UUID{uuid.New()},
 			[]uuid.UUID{},
 			"更新テスト",
+			false,
 		)
 		
 		// Then: 更新不可エラー（実際のサービスロジックで処理される）
@@ -380,7 +400,7 @@ func (suite *ReservationServiceTestSuite) Test_空き時間検索_エラーケ
 		
 		// Then: 日付フォーマットエラーが返される
 		assert.Error(suite.T(), err)
-		assert.Contains(suite.T(), err.Error(), "無効な日付形式")
+		assert.Contains(suite.T(), japaneseMessage(err), "無効な日付形式")
 		assert.Nil(suite.T(), result)
 	})
 	
@@ -394,7 +414,7 @@ func (suite *ReservationServiceTestSuite) Test_空き時間検索_エラーケ
 		
 		// Then: 時間フォーマットエラーが返される
 		assert.Error(suite.T(), err)
-		assert.Contains(suite.T(), err.Error(), "無効な時間形式")
+		assert.Contains(suite.T(), japaneseMessage(err), "無効な時間形式")
 		assert.Nil(suite.T(), result)
 	})
 	
@@ -409,7 +429,7 @@ func (suite *ReservationServiceTestSuite) Test_空き時間検索_エラーケ
 		
 		// Then: 無効なスタッフIDエラーが返される
 		assert.Error(suite.T(), err)
-		assert.Contains(suite.T(), err.Error(), "無効なスタッフID")
+		assert.Contains(suite.T(), japaneseMessage(err), "無効なスタッフID")
 		assert.Nil(suite.T(), result)
 	})
 }
\ No newline at end of file
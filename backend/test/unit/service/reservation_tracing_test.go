@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"app/src/model"
+	"app/src/service"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// ReservationTracingTestSuite は予約サービスの操作と SQL のスパンのテストスイート
+type ReservationTracingTestSuite struct {
+	suite.Suite
+	db                 *gorm.DB
+	recorder           *tracetest.SpanRecorder
+	reservationService *service.ReservationService
+	reservation        model.Reservation
+}
+
+func TestReservationTracingSuite(t *testing.T) {
+	suite.Run(t, new(ReservationTracingTestSuite))
+}
+
+func (suite *ReservationTracingTestSuite) SetupTest() {
+	suite.recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(suite.recorder)))
+
+	suite.db = newTestDB(suite.T())
+	customer := model.Customer{Name: "山田花子", Phone: "+819012345678", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&customer).Error)
+	staff := model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&staff).Error)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	suite.reservation = model.Reservation{
+		CustomerID: customer.ID, StaffID: staff.ID, ReservationDate: start, StartTime: start, EndTime: start.Add(time.Hour),
+		TotalDuration: 60, TotalPrice: 5000, Status: model.ReservationStatusConfirmed,
+	}
+	suite.Require().NoError(suite.db.Create(&suite.reservation).Error)
+
+	// Same options as database.Connect, after the fixtures so that only the traced calls produce SQL spans
+	suite.Require().NoError(suite.db.Use(otelgorm.NewPlugin(otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())))
+	suite.reservationService = service.NewReservationService(suite.db)
+}
+
+func (suite *ReservationTracingTestSuite) TearDownTest() {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+}
+
+func (suite *ReservationTracingTestSuite) Test_スパン() {
+	suite.Run("操作のスパンはリクエストのスパンの子になり_SQL のスパンは操作のスパンの子になる", func() {
+		ctx, request := otel.Tracer("test").Start(context.Background(), "GET /v1/reservations/:id")
+
+		_, err := suite.reservationService.WithContext(ctx).GetReservationByID(suite.reservation.ID)
+		request.End()
+
+		suite.Require().NoError(err)
+		var operation sdktrace.ReadOnlySpan
+		for _, span := range suite.recorder.Ended() {
+			if span.Name() == "ReservationService.GetReservationByID" {
+				operation = span
+			}
+		}
+		suite.Require().NotNil(operation)
+		assert.Equal(suite.T(), request.SpanContext().SpanID(), operation.Parent().SpanID())
+		assert.Contains(suite.T(), operation.Attributes(), attribute.String("reservation.id", suite.reservation.ID.String()))
+
+		var statements int
+		for _, span := range suite.recorder.Ended() {
+			if span.Parent().SpanID() != operation.SpanContext().SpanID() {
+				continue
+			}
+			for _, attr := range span.Attributes() {
+				if attr.Key == "db.statement" || attr.Key == "db.query.text" {
+					statements++
+					statement := attr.Value.AsString()
+					assert.False(suite.T(), strings.Contains(statement, suite.reservation.ID.String()), "bound values must not be recorded: %s", statement)
+				}
+			}
+		}
+		assert.NotZero(suite.T(), statements)
+	})
+
+	suite.Run("コンテキストを渡さない呼び出しも新しいトレースとして記録する", func() {
+		suite.SetupTest()
+
+		_, err := suite.reservationService.GetReservationByID(suite.reservation.ID)
+
+		suite.Require().NoError(err)
+		var found bool
+		for _, span := range suite.recorder.Ended() {
+			if span.Name() == "ReservationService.GetReservationByID" {
+				found = true
+				assert.False(suite.T(), span.Parent().IsValid())
+			}
+		}
+		assert.True(suite.T(), found)
+	})
+}
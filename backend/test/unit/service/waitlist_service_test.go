@@ -0,0 +1,284 @@
+package service_test
+
+import (
+	"app/src/apperror"
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// WaitlistServiceTestSuite はキャンセル待ちの照合・案内・承諾・失効のテストスイート
+type WaitlistServiceTestSuite struct {
+	suite.Suite
+	db                 *gorm.DB
+	emailService       *fakeEmailService
+	smsService         *fakeSMSService
+	reservationService *service.ReservationService
+	waitlistService    *service.WaitlistService
+
+	date  time.Time
+	staff model.Staff
+	menu  model.Menu
+	shift model.Shift
+}
+
+func TestWaitlistServiceSuite(t *testing.T) {
+	suite.Run(t, new(WaitlistServiceTestSuite))
+}
+
+func (suite *WaitlistServiceTestSuite) SetupTest() {
+	suite.db = newTestDB(suite.T())
+	config.WaitlistOfferHold = 30
+	config.AppURL = "https://salon.example.com"
+
+	suite.emailService = &fakeEmailService{}
+	suite.smsService = &fakeSMSService{}
+	suite.reservationService = service.NewReservationService(suite.db)
+	suite.waitlistService = service.NewWaitlistService(suite.db, suite.reservationService, suite.emailService, suite.smsService)
+	suite.reservationService.AddSlotReleaseHandler(suite.waitlistService)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	suite.date = today.AddDate(0, 0, 3)
+
+	suite.staff = model.Staff{Name: "佐藤", Email: "sato@example.com", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.staff).Error)
+	suite.menu = model.Menu{Name: "カット", Duration: 60, Price: 5000, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&suite.menu).Error)
+
+	// 10:00-11:00 only fits one 60 minute reservation
+	suite.shift = model.Shift{
+		StaffID:   suite.staff.ID,
+		Date:      suite.date,
+		StartTime: suite.at(10, 0),
+		EndTime:   suite.at(11, 0),
+		IsActive:  true,
+	}
+	suite.Require().NoError(suite.db.Create(&suite.shift).Error)
+}
+
+// at は営業日の指定時刻を予約と同じタイムゾーンで返す
+func (suite *WaitlistServiceTestSuite) at(hour, minute int) time.Time {
+	return time.Date(suite.date.Year(), suite.date.Month(), suite.date.Day(), hour, minute, 0, 0, time.Local)
+}
+
+func (suite *WaitlistServiceTestSuite) createCustomer(name, email, phone string) model.Customer {
+	customer := model.Customer{Name: name, Email: email, Phone: phone, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&customer).Error)
+	return customer
+}
+
+// book はシフトの枠を埋める確定済みの予約を作る
+func (suite *WaitlistServiceTestSuite) book(customer model.Customer) *model.Reservation {
+	reservation, err := suite.reservationService.CreateReservation(&model.Reservation{
+		CustomerID:      customer.ID,
+		StaffID:         suite.staff.ID,
+		ReservationDate: suite.date,
+		StartTime:       suite.at(10, 0),
+		EndTime:         suite.at(11, 0),
+		TotalDuration:   60,
+		TotalPrice:      5000,
+		Status:          model.ReservationStatusConfirmed,
+	})
+	suite.Require().NoError(err)
+	return reservation
+}
+
+func (suite *WaitlistServiceTestSuite) join(customer model.Customer, windowStart, windowEnd string) *model.WaitlistEntry {
+	entry, err := suite.waitlistService.CreateEntry(customer.ID, nil, suite.date.Format("2006-01-02"), windowStart, windowEnd, []uuid.UUID{suite.menu.ID}, "")
+	suite.Require().NoError(err)
+	return entry
+}
+
+func (suite *WaitlistServiceTestSuite) reload(entry *model.WaitlistEntry) *model.WaitlistEntry {
+	var reloaded model.WaitlistEntry
+	suite.Require().NoError(suite.db.Where("id = ?", entry.ID).First(&reloaded).Error)
+	return &reloaded
+}
+
+func (suite *WaitlistServiceTestSuite) reservationStatus(id uuid.UUID) model.ReservationStatus {
+	var reservation model.Reservation
+	suite.Require().NoError(suite.db.Where("id = ?", id).First(&reservation).Error)
+	return reservation.Status
+}
+
+func (suite *WaitlistServiceTestSuite) Test_空き枠の照合() {
+	suite.Run("キャンセルで空いた枠は登録順に最初のエントリへ仮予約で案内される", func() {
+		booked := suite.book(suite.createCustomer("予約者", "booked@example.com", ""))
+		first := suite.join(suite.createCustomer("一番目", "first@example.com", ""), "", "")
+		second := suite.join(suite.createCustomer("二番目", "second@example.com", ""), "", "")
+
+		suite.Require().NoError(suite.reservationService.CancelReservation(booked.ID))
+
+		offered := suite.reload(first)
+		assert.Equal(suite.T(), model.WaitlistStatusOffered, offered.Status)
+		suite.Require().NotNil(offered.ReservationID)
+		assert.Equal(suite.T(), model.ReservationStatusPending, suite.reservationStatus(*offered.ReservationID))
+		assert.Equal(suite.T(), model.WaitlistStatusWaiting, suite.reload(second).Status)
+	})
+
+	suite.Run("希望時間帯に収まらない枠は案内しない", func() {
+		suite.SetupTest()
+		entry := suite.join(suite.createCustomer("午後希望", "afternoon@example.com", ""), "14:00:00", "18:00:00")
+
+		matched, err := suite.waitlistService.MatchFreedSlot(suite.staff.ID, suite.date)
+
+		suite.Require().NoError(err)
+		assert.Nil(suite.T(), matched)
+		assert.Equal(suite.T(), model.WaitlistStatusWaiting, suite.reload(entry).Status)
+	})
+
+	suite.Run("シフトの延長で空いた枠も定期の照合で案内される", func() {
+		suite.SetupTest()
+		suite.book(suite.createCustomer("予約者", "booked@example.com", ""))
+		entry := suite.join(suite.createCustomer("待機者", "waiting@example.com", ""), "", "")
+
+		suite.Require().NoError(suite.waitlistService.MatchWaitingEntries())
+		assert.Equal(suite.T(), model.WaitlistStatusWaiting, suite.reload(entry).Status)
+
+		suite.Require().NoError(suite.db.Model(&model.Shift{}).Where("id = ?", suite.shift.ID).
+			Update("end_time", suite.at(12, 30)).Error)
+		suite.Require().NoError(suite.waitlistService.MatchWaitingEntries())
+
+		assert.Equal(suite.T(), model.WaitlistStatusOffered, suite.reload(entry).Status)
+	})
+}
+
+func (suite *WaitlistServiceTestSuite) Test_案内の送信() {
+	suite.Run("メールで確認ページのリンクを送り_通知ログにはトークンを残さない", func() {
+		entry := suite.join(suite.createCustomer("山田", "yamada@example.com", ""), "", "")
+
+		matched, err := suite.waitlistService.MatchFreedSlot(suite.staff.ID, suite.date)
+		suite.Require().NoError(err)
+		suite.Require().NotNil(matched)
+
+		offered := suite.reload(entry)
+		sent := suite.emailService.messages()
+		suite.Require().Len(sent, 1)
+		assert.Equal(suite.T(), "yamada@example.com", sent[0].to)
+		assert.Contains(suite.T(), sent[0].body, "https://salon.example.com/v1/waitlist/offers/"+offered.OfferToken)
+		assert.NotContains(suite.T(), sent[0].body, "/accept")
+
+		var logs []model.NotificationLog
+		suite.Require().NoError(suite.db.Find(&logs).Error)
+		suite.Require().Len(logs, 1)
+		assert.Equal(suite.T(), model.NotificationStatusSent, logs[0].Status)
+		assert.Equal(suite.T(), "notification.waitlist_offer.body", logs[0].Message)
+		assert.NotContains(suite.T(), logs[0].Message, offered.OfferToken)
+	})
+
+	suite.Run("メールアドレスがない顧客にはSMSで送る", func() {
+		suite.SetupTest()
+		suite.join(suite.createCustomer("鈴木", "", "+819012345678"), "", "")
+
+		_, err := suite.waitlistService.MatchFreedSlot(suite.staff.ID, suite.date)
+		suite.Require().NoError(err)
+
+		sent := suite.smsService.messages()
+		suite.Require().Len(sent, 1)
+		assert.Equal(suite.T(), "+819012345678", sent[0].to)
+		assert.Empty(suite.T(), suite.emailService.messages())
+	})
+
+	suite.Run("送信に失敗した場合_案内は保持したまま通知ログに失敗を残す", func() {
+		suite.SetupTest()
+		suite.emailService.err = errors.New("smtp unavailable")
+		entry := suite.join(suite.createCustomer("高橋", "takahashi@example.com", ""), "", "")
+
+		_, err := suite.waitlistService.MatchFreedSlot(suite.staff.ID, suite.date)
+		suite.Require().NoError(err)
+
+		assert.Equal(suite.T(), model.WaitlistStatusOffered, suite.reload(entry).Status)
+		var logs []model.NotificationLog
+		suite.Require().NoError(suite.db.Find(&logs).Error)
+		suite.Require().Len(logs, 1)
+		assert.Equal(suite.T(), model.NotificationStatusFailed, logs[0].Status)
+		assert.Equal(suite.T(), "smtp unavailable", logs[0].ErrorMessage)
+	})
+}
+
+func (suite *WaitlistServiceTestSuite) offered() *model.WaitlistEntry {
+	entry := suite.join(suite.createCustomer("田中", "tanaka@example.com", ""), "", "")
+	_, err := suite.waitlistService.MatchFreedSlot(suite.staff.ID, suite.date)
+	suite.Require().NoError(err)
+	return suite.reload(entry)
+}
+
+func (suite *WaitlistServiceTestSuite) Test_案内の承諾() {
+	suite.Run("確認ページの取得では状態を変更しない", func() {
+		entry := suite.offered()
+
+		shown, err := suite.waitlistService.GetOffer(entry.OfferToken)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), entry.ID, shown.ID)
+		assert.Equal(suite.T(), model.WaitlistStatusOffered, suite.reload(entry).Status)
+		assert.Equal(suite.T(), model.ReservationStatusPending, suite.reservationStatus(*entry.ReservationID))
+	})
+
+	suite.Run("承諾すると仮予約が確定する", func() {
+		suite.SetupTest()
+		entry := suite.offered()
+
+		reservation, err := suite.waitlistService.AcceptOffer(entry.OfferToken)
+
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), model.ReservationStatusConfirmed, reservation.Status)
+		assert.Equal(suite.T(), model.WaitlistStatusAccepted, suite.reload(entry).Status)
+	})
+
+	suite.Run("存在しないトークンはNotFound", func() {
+		suite.SetupTest()
+
+		_, err := suite.waitlistService.AcceptOffer("unknown")
+
+		assert.True(suite.T(), errors.Is(err, apperror.NotFound("error.offer_not_found")))
+	})
+
+	suite.Run("期限切れの案内はGoneになり_仮予約を解放して次の順番へ回す", func() {
+		suite.SetupTest()
+		entry := suite.offered()
+		next := suite.join(suite.createCustomer("次の人", "next@example.com", ""), "", "")
+		suite.Require().NoError(suite.db.Model(&model.WaitlistEntry{}).Where("id = ?", entry.ID).
+			Update("offer_expires_at", time.Now().Add(-time.Minute)).Error)
+
+		_, err := suite.waitlistService.GetOffer(entry.OfferToken)
+		assert.True(suite.T(), errors.Is(err, apperror.Gone("error.offer_expired").WithCode("OFFER_EXPIRED")))
+
+		_, err = suite.waitlistService.AcceptOffer(entry.OfferToken)
+		assert.True(suite.T(), errors.Is(err, apperror.Gone("error.offer_expired").WithCode("OFFER_EXPIRED")))
+
+		assert.Equal(suite.T(), model.WaitlistStatusExpired, suite.reload(entry).Status)
+		assert.Equal(suite.T(), model.ReservationStatusCancelled, suite.reservationStatus(*entry.ReservationID))
+		assert.Equal(suite.T(), model.WaitlistStatusOffered, suite.reload(next).Status)
+	})
+}
+
+func (suite *WaitlistServiceTestSuite) Test_案内の失効() {
+	suite.Run("期限を過ぎた案内だけを失効させ_仮予約を取り消す", func() {
+		entry := suite.offered()
+		suite.Require().NoError(suite.db.Model(&model.WaitlistEntry{}).Where("id = ?", entry.ID).
+			Update("offer_expires_at", time.Now().Add(-time.Minute)).Error)
+
+		suite.Require().NoError(suite.waitlistService.ExpireOffers())
+
+		assert.Equal(suite.T(), model.WaitlistStatusExpired, suite.reload(entry).Status)
+		assert.Equal(suite.T(), model.ReservationStatusCancelled, suite.reservationStatus(*entry.ReservationID))
+	})
+
+	suite.Run("期限内の案内は失効させない", func() {
+		suite.SetupTest()
+		entry := suite.offered()
+
+		suite.Require().NoError(suite.waitlistService.ExpireOffers())
+
+		assert.Equal(suite.T(), model.WaitlistStatusOffered, suite.reload(entry).Status)
+	})
+}
@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"app/src/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// WorkerHeartbeatTestSuite はバックグラウンドのジョブの稼働確認のテストスイート
+type WorkerHeartbeatTestSuite struct {
+	suite.Suite
+	heartbeats *service.WorkerHeartbeats
+}
+
+func TestWorkerHeartbeatSuite(t *testing.T) {
+	suite.Run(t, new(WorkerHeartbeatTestSuite))
+}
+
+func (suite *WorkerHeartbeatTestSuite) SetupTest() {
+	suite.heartbeats = service.NewWorkerHeartbeats()
+}
+
+func (suite *WorkerHeartbeatTestSuite) Test_稼働確認() {
+	suite.Run("起動していないジョブは確認しない", func() {
+		assert.NoError(suite.T(), suite.heartbeats.Check(time.Now()))
+	})
+
+	suite.Run("間隔の2倍以内に実行されていれば正常", func() {
+		suite.heartbeats.Beat(service.WorkerWaitlistOffers, time.Minute)
+
+		assert.NoError(suite.T(), suite.heartbeats.Check(time.Now().Add(2*time.Minute-time.Second)))
+	})
+
+	suite.Run("間隔の2倍を過ぎても実行されていないジョブを返す", func() {
+		suite.SetupTest()
+		suite.heartbeats.Beat(service.WorkerWaitlistOffers, time.Minute)
+		suite.heartbeats.Beat(service.WorkerNoShowReview, 5*time.Minute)
+
+		err := suite.heartbeats.Check(time.Now().Add(3 * time.Minute))
+
+		suite.Require().Error(err)
+		assert.Contains(suite.T(), err.Error(), service.WorkerWaitlistOffers)
+		assert.NotContains(suite.T(), err.Error(), service.WorkerNoShowReview)
+	})
+}
@@ -0,0 +1,93 @@
+package tracing_test
+
+import (
+	"app/src/config"
+	"app/src/tracing"
+	"app/src/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TracingTestSuite はトレースの初期化とログへのトレースIDの付与のテストスイート
+type TracingTestSuite struct {
+	suite.Suite
+	original io.Writer
+	output   *bytes.Buffer
+}
+
+func TestTracingSuite(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
+
+func (suite *TracingTestSuite) SetupSuite() {
+	config.TracingExporter = "none"
+	shutdown, err := tracing.Init(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().NoError(shutdown(context.Background()))
+}
+
+func (suite *TracingTestSuite) SetupTest() {
+	suite.original = utils.Log.Out
+	suite.output = &bytes.Buffer{}
+	utils.Log.SetOutput(suite.output)
+}
+
+func (suite *TracingTestSuite) TearDownTest() {
+	utils.Log.SetOutput(suite.original)
+}
+
+// entry は最後に出力したログの項目を返す
+func (suite *TracingTestSuite) entry() map[string]interface{} {
+	var fields map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(suite.output.Bytes(), &fields))
+	return fields
+}
+
+func (suite *TracingTestSuite) Test_ログのトレースID() {
+	suite.Run("コンテキストのトレースID・スパンIDをログに付ける", func() {
+		provider := sdktrace.NewTracerProvider()
+		ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+		defer span.End()
+
+		utils.Log.WithContext(ctx).Info("reservation created")
+
+		entry := suite.entry()
+		assert.Equal(suite.T(), span.SpanContext().TraceID().String(), entry["trace_id"])
+		assert.Equal(suite.T(), span.SpanContext().SpanID().String(), entry["span_id"])
+		assert.Equal(suite.T(), span.SpanContext().TraceID().String(), tracing.TraceID(ctx))
+	})
+
+	suite.Run("トレース中でなければ何も付けない", func() {
+		suite.output.Reset()
+
+		utils.Log.WithContext(context.Background()).Info("reservation created")
+
+		entry := suite.entry()
+		assert.NotContains(suite.T(), entry, "trace_id")
+		assert.NotContains(suite.T(), entry, "span_id")
+		assert.Empty(suite.T(), tracing.TraceID(context.Background()))
+	})
+}
+
+func (suite *TracingTestSuite) Test_初期化() {
+	suite.Run("未知の送信先はエラーにする", func() {
+		defer func() { config.TracingExporter = "none" }()
+		config.TracingExporter = "zipkin"
+
+		_, err := tracing.Init(context.Background())
+
+		assert.EqualError(suite.T(), err, "unknown tracing exporter: zipkin")
+	})
+
+	suite.Run("送信先が none でもトレースの伝播は設定する", func() {
+		assert.Contains(suite.T(), otel.GetTextMapPropagator().Fields(), "traceparent")
+	})
+}
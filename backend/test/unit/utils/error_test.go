@@ -0,0 +1,164 @@
+package utils_test
+
+import (
+	"app/src/apperror"
+	"app/src/i18n"
+	"app/src/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ErrorHandlerTestSuite はエラーから共通のエラーレスポンスへの変換のテストスイート
+type ErrorHandlerTestSuite struct {
+	suite.Suite
+}
+
+func TestErrorHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ErrorHandlerTestSuite))
+}
+
+type errorResponse struct {
+	Success bool `json:"success"`
+	Error   struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+	} `json:"error"`
+	Meta struct {
+		Timestamp string `json:"timestamp"`
+	} `json:"meta"`
+}
+
+// request はハンドラが返したエラーを ErrorHandler に通した結果を返す
+func (suite *ErrorHandlerTestSuite) request(err error) (int, errorResponse) {
+	return suite.requestWithLanguage(err, "")
+}
+
+func (suite *ErrorHandlerTestSuite) requestWithLanguage(err error, acceptLanguage string) (int, errorResponse) {
+	app := fiber.New(fiber.Config{ErrorHandler: utils.ErrorHandler})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return err
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptLanguage != "" {
+		req.Header.Set(fiber.HeaderAcceptLanguage, acceptLanguage)
+	}
+	resp, testErr := app.Test(req)
+	suite.Require().NoError(testErr)
+
+	var body errorResponse
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func (suite *ErrorHandlerTestSuite) Test_ドメインエラーの変換() {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"入力値の誤りは400", apperror.Validation("error.invalid_date"), http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"認証の失敗は401", apperror.Unauthorized("error.authentication_required"), http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"対象が存在しない場合は404", apperror.NotFound("error.reservation_not_found"), http.StatusNotFound, "NOT_FOUND"},
+		{"重複は409", apperror.Conflict("error.time_slot_booked"), http.StatusConflict, "CONFLICT"},
+		{"期限切れは410", apperror.Gone("error.offer_expired"), http.StatusGone, "GONE"},
+		{"業務ルール違反は422", apperror.PolicyViolation("error.invalid_status_transition"), http.StatusUnprocessableEntity, "BUSINESS_RULE_ERROR"},
+		{"回数制限は429", apperror.TooManyRequests("error.too_many_login_requests"), http.StatusTooManyRequests, "TOO_MANY_REQUESTS"},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.name, func() {
+			status, body := suite.request(tc.err)
+			assert.Equal(suite.T(), tc.status, status)
+			assert.False(suite.T(), body.Success)
+			assert.Equal(suite.T(), tc.code, body.Error.Code)
+			assert.Equal(suite.T(), tc.err.(*apperror.Error).Message(i18n.Japanese), body.Error.Message)
+			assert.NotEmpty(suite.T(), body.Meta.Timestamp)
+		})
+	}
+
+	suite.Run("エラーコードを指定した場合_既定のコードより優先される", func() {
+		status, body := suite.request(apperror.Conflict("error.risk_confirmation_required").WithCode("RISK_CONFIRMATION_REQUIRED"))
+		assert.Equal(suite.T(), http.StatusConflict, status)
+		assert.Equal(suite.T(), "RISK_CONFIRMATION_REQUIRED", body.Error.Code)
+	})
+
+	suite.Run("ラップされたエラーも種類で変換される", func() {
+		status, body := suite.request(fmt.Errorf("cancel reservation: %w", apperror.NotFound("error.reservation_not_found")))
+		assert.Equal(suite.T(), http.StatusNotFound, status)
+		assert.Equal(suite.T(), "NOT_FOUND", body.Error.Code)
+	})
+
+	suite.Run("詳細を付けた場合_detailsに含まれる", func() {
+		base := apperror.Validation("error.invalid_input")
+		err := base.WithDetails([]apperror.FieldError{{Field: "email", Message: "invalid"}})
+		_, body := suite.request(err)
+		suite.Require().Len(body.Error.Details, 1)
+		assert.Equal(suite.T(), "email", body.Error.Details[0].Field)
+		assert.True(suite.T(), errors.Is(err, base))
+	})
+}
+
+func (suite *ErrorHandlerTestSuite) Test_種類のないエラーの変換() {
+	suite.Run("内部のエラーは500で_内容は返さない", func() {
+		status, body := suite.request(errors.New("pq: connection refused"))
+		assert.Equal(suite.T(), http.StatusInternalServerError, status)
+		assert.Equal(suite.T(), "INTERNAL_ERROR", body.Error.Code)
+		assert.NotContains(suite.T(), body.Error.Message, "pq:")
+	})
+
+	suite.Run("レコードが見つからない場合は404", func() {
+		status, body := suite.request(gorm.ErrRecordNotFound)
+		assert.Equal(suite.T(), http.StatusNotFound, status)
+		assert.Equal(suite.T(), "NOT_FOUND", body.Error.Code)
+	})
+
+	suite.Run("Fiberのエラーはステータスをそのまま返す", func() {
+		status, body := suite.request(fiber.ErrMethodNotAllowed)
+		assert.Equal(suite.T(), http.StatusMethodNotAllowed, status)
+		assert.Equal(suite.T(), "METHOD_NOT_ALLOWED", body.Error.Code)
+	})
+}
+
+func (suite *ErrorHandlerTestSuite) Test_言語の切り替え() {
+	suite.Run("Accept-Languageが英語の場合_英語のメッセージが返される", func() {
+		_, body := suite.requestWithLanguage(apperror.NotFound("error.reservation_not_found"), "en-US,en;q=0.9")
+		assert.Equal(suite.T(), "reservation not found", body.Error.Message)
+		assert.Equal(suite.T(), "NOT_FOUND", body.Error.Code)
+	})
+
+	suite.Run("Accept-Languageがない場合_既定の日本語のメッセージが返される", func() {
+		_, body := suite.request(apperror.NotFound("error.reservation_not_found"))
+		assert.Equal(suite.T(), "予約が見つかりません", body.Error.Message)
+	})
+
+	suite.Run("対応していない言語の場合_既定の日本語のメッセージが返される", func() {
+		_, body := suite.requestWithLanguage(apperror.NotFound("error.reservation_not_found"), "fr-FR,fr;q=0.9")
+		assert.Equal(suite.T(), "予約が見つかりません", body.Error.Message)
+	})
+
+	suite.Run("埋め込む値がメッセージに含まれる", func() {
+		err := apperror.Validation("error.date_range_too_long").WithParams(i18n.Params{"days": 366})
+		_, body := suite.requestWithLanguage(err, "en")
+		assert.Equal(suite.T(), "date range must be within 366 days", body.Error.Message)
+	})
+
+	suite.Run("内部のエラーも言語に合わせて返される", func() {
+		_, body := suite.requestWithLanguage(errors.New("pq: connection refused"), "en")
+		assert.Equal(suite.T(), "An internal server error occurred", body.Error.Message)
+	})
+}
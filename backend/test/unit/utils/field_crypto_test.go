@@ -0,0 +1,114 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// FieldCryptoTestSuite は個人情報の項目暗号化のテストスイート
+type FieldCryptoTestSuite struct {
+	suite.Suite
+	oldKey   []byte
+	newKey   []byte
+	indexKey []byte
+}
+
+func TestFieldCryptoSuite(t *testing.T) {
+	suite.Run(t, new(FieldCryptoTestSuite))
+}
+
+func (suite *FieldCryptoTestSuite) SetupTest() {
+	suite.oldKey = bytes.Repeat([]byte{1}, 32)
+	suite.newKey = bytes.Repeat([]byte{2}, 32)
+	suite.indexKey = bytes.Repeat([]byte{3}, 32)
+	suite.Require().NoError(utils.InitFieldEncryption(map[int][]byte{1: suite.oldKey}, 1, suite.indexKey))
+}
+
+// エラーケース優先実装（TDDガイドラインに従い）
+func (suite *FieldCryptoTestSuite) Test_鍵の設定_エラーケース() {
+	suite.Run("鍵が32バイトでない場合_エラーが返される", func() {
+		err := utils.InitFieldEncryption(map[int][]byte{1: []byte("short")}, 1, suite.indexKey)
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("現在のバージョンの鍵が無い場合_エラーが返される", func() {
+		err := utils.InitFieldEncryption(map[int][]byte{1: suite.oldKey}, 2, suite.indexKey)
+		assert.Error(suite.T(), err)
+	})
+}
+
+func (suite *FieldCryptoTestSuite) Test_暗号化と復号() {
+	suite.Run("暗号化した値を復号すると元の値に戻る", func() {
+		encrypted, err := utils.EncryptField("090-1234-5678")
+		suite.Require().NoError(err)
+		assert.True(suite.T(), strings.HasPrefix(encrypted, utils.EncryptedFieldPrefix(1)))
+		assert.NotContains(suite.T(), encrypted, "1234")
+
+		decrypted, err := utils.DecryptField(encrypted)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "090-1234-5678", decrypted)
+	})
+
+	suite.Run("同じ値でも暗号文は毎回異なる", func() {
+		first, _ := utils.EncryptField("tanaka@example.com")
+		second, _ := utils.EncryptField("tanaka@example.com")
+		assert.NotEqual(suite.T(), first, second)
+	})
+
+	suite.Run("暗号化前の平文の値はそのまま返される", func() {
+		decrypted, err := utils.DecryptField("090-1234-5678")
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "090-1234-5678", decrypted)
+	})
+
+	suite.Run("改ざんされた暗号文の場合_エラーが返される", func() {
+		encrypted, _ := utils.EncryptField("secret")
+		_, err := utils.DecryptField(encrypted[:len(encrypted)-2] + "AA")
+		assert.Error(suite.T(), err)
+	})
+}
+
+func (suite *FieldCryptoTestSuite) Test_鍵のローテーション() {
+	suite.Run("新しい鍵に切り替えても古い鍵の値を復号できる", func() {
+		encrypted, err := utils.EncryptField("山田太郎のメモ")
+		suite.Require().NoError(err)
+
+		suite.Require().NoError(utils.InitFieldEncryption(map[int][]byte{1: suite.oldKey, 2: suite.newKey}, 2, suite.indexKey))
+		decrypted, err := utils.DecryptField(encrypted)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "山田太郎のメモ", decrypted)
+
+		reencrypted, _ := utils.EncryptField(decrypted)
+		assert.True(suite.T(), strings.HasPrefix(reencrypted, utils.EncryptedFieldPrefix(2)))
+	})
+
+	suite.Run("古い鍵を外した場合_古い値の復号はエラーになる", func() {
+		suite.Require().NoError(utils.InitFieldEncryption(map[int][]byte{1: suite.oldKey}, 1, suite.indexKey))
+		encrypted, _ := utils.EncryptField("secret")
+		suite.Require().NoError(utils.InitFieldEncryption(map[int][]byte{2: suite.newKey}, 2, suite.indexKey))
+		_, err := utils.DecryptField(encrypted)
+		assert.Error(suite.T(), err)
+	})
+}
+
+func (suite *FieldCryptoTestSuite) Test_ブラインドインデックス() {
+	suite.Run("同じ値には同じインデックスが返される", func() {
+		assert.Equal(suite.T(), utils.BlindIndex("09012345678"), utils.BlindIndex("09012345678"))
+		assert.NotEqual(suite.T(), utils.BlindIndex("09012345678"), utils.BlindIndex("09012345679"))
+	})
+
+	suite.Run("空文字の場合_空文字が返される", func() {
+		assert.Equal(suite.T(), "", utils.BlindIndex(""))
+	})
+
+	suite.Run("鍵のローテーションではインデックスは変わらない", func() {
+		before := utils.BlindIndex("09012345678")
+		suite.Require().NoError(utils.InitFieldEncryption(map[int][]byte{1: suite.oldKey, 2: suite.newKey}, 2, suite.indexKey))
+		assert.Equal(suite.T(), before, utils.BlindIndex("09012345678"))
+	})
+}
@@ -0,0 +1,74 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ICalTestSuite は iCalendar 出力のテストスイート
+type ICalTestSuite struct {
+	suite.Suite
+}
+
+func TestICalSuite(t *testing.T) {
+	suite.Run(t, new(ICalTestSuite))
+}
+
+func (suite *ICalTestSuite) Test_カレンダー出力() {
+	jst := time.FixedZone("JST", 9*60*60)
+	start := time.Date(2025, 4, 1, 10, 0, 0, 0, jst)
+
+	suite.Run("予定がUTCの日時で出力される", func() {
+		calendar := utils.BuildCalendar("佐藤 の予約", []utils.CalendarEvent{{
+			UID:     "reservation-1",
+			Summary: "カット",
+			Start:   start,
+			End:     start.Add(time.Hour),
+		}})
+
+		assert.True(suite.T(), strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		assert.True(suite.T(), strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+		assert.Contains(suite.T(), calendar, "X-WR-CALNAME:佐藤 の予約\r\n")
+		assert.Contains(suite.T(), calendar, "DTSTART:20250401T010000Z\r\n")
+		assert.Contains(suite.T(), calendar, "DTEND:20250401T020000Z\r\n")
+		assert.Contains(suite.T(), calendar, "STATUS:CONFIRMED\r\n")
+	})
+
+	suite.Run("キャンセルされた予定はCANCELLEDで出力される", func() {
+		calendar := utils.BuildCalendar("", []utils.CalendarEvent{{
+			UID: "reservation-1", Summary: "カット", Start: start, End: start.Add(time.Hour), Cancelled: true, Sequence: 3,
+		}})
+
+		assert.Contains(suite.T(), calendar, "STATUS:CANCELLED\r\n")
+		assert.Contains(suite.T(), calendar, "SEQUENCE:3\r\n")
+		assert.NotContains(suite.T(), calendar, "X-WR-CALNAME")
+	})
+
+	suite.Run("区切り文字と改行がエスケープされる", func() {
+		calendar := utils.BuildCalendar("", []utils.CalendarEvent{{
+			UID: "reservation-1", Summary: "カット, カラー; トリートメント", Description: "メニュー: カット\n料金: 5000円",
+			Start: start, End: start.Add(time.Hour),
+		}})
+
+		assert.Contains(suite.T(), calendar, `SUMMARY:カット\, カラー\; トリートメント`)
+		assert.Contains(suite.T(), calendar, `DESCRIPTION:メニュー: カット\n料金: 5000円`)
+	})
+
+	suite.Run("長い行は文字の途中で切らずに75オクテットで折り返される", func() {
+		calendar := utils.BuildCalendar("", []utils.CalendarEvent{{
+			UID: "reservation-1", Summary: strings.Repeat("カラー", 20), Start: start, End: start.Add(time.Hour),
+		}})
+
+		for _, line := range strings.Split(strings.TrimSuffix(calendar, "\r\n"), "\r\n") {
+			assert.LessOrEqual(suite.T(), len(line), 75, line)
+			assert.True(suite.T(), strings.ToValidUTF8(line, "?") == line, line)
+		}
+		unfolded := strings.ReplaceAll(calendar, "\r\n ", "")
+		assert.Contains(suite.T(), unfolded, "SUMMARY:"+strings.Repeat("カラー", 20)+"\r\n")
+	})
+}
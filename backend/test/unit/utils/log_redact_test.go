@@ -0,0 +1,67 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// LogRedactTestSuite はログの個人情報マスクのテストスイート
+type LogRedactTestSuite struct {
+	suite.Suite
+}
+
+func TestLogRedactSuite(t *testing.T) {
+	suite.Run(t, new(LogRedactTestSuite))
+}
+
+func (suite *LogRedactTestSuite) Test_文字列のマスク() {
+	suite.Run("メールアドレスと電話番号が伏せ字になる", func() {
+		assert.Equal(suite.T(), "send to [REDACTED] failed", utils.RedactPII("send to yamada@example.com failed"))
+		assert.Equal(suite.T(), "sms to [REDACTED]", utils.RedactPII("sms to 090-1234-5678"))
+		assert.Equal(suite.T(), "sms to [REDACTED]", utils.RedactPII("sms to +819012345678"))
+		assert.Equal(suite.T(), "tel [REDACTED]", utils.RedactPII("tel 03 1234 5678"))
+	})
+
+	suite.Run("日付やIDなど電話番号でない数字はそのまま残る", func() {
+		for _, text := range []string{
+			"reservation on 2025-04-01",
+			"id 01234567-0123-4567-8901-234567890123",
+			"migrated 1500 customers",
+			"code=123456",
+		} {
+			assert.Equal(suite.T(), text, utils.RedactPII(text))
+		}
+	})
+}
+
+func (suite *LogRedactTestSuite) Test_ログ出力のマスク() {
+	suite.Run("個人情報のフィールドとメッセージ・エラーがマスクされる", func() {
+		var buf bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&buf)
+		logger.SetFormatter(&utils.RedactingFormatter{Formatter: &logrus.JSONFormatter{}})
+
+		logger.WithFields(logrus.Fields{
+			"name":        "山田 花子",
+			"destination": "09012345678",
+			"status":      400,
+		}).WithError(errors.New("duplicate email hanako@example.com")).
+			Info("login code sent to hanako@example.com")
+
+		var line map[string]interface{}
+		assert.NoError(suite.T(), json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(suite.T(), "[REDACTED]", line["name"])
+		assert.Equal(suite.T(), "[REDACTED]", line["destination"])
+		assert.Equal(suite.T(), float64(400), line["status"])
+		assert.Equal(suite.T(), "duplicate email [REDACTED]", line["error"])
+		assert.Equal(suite.T(), "login code sent to [REDACTED]", line["msg"])
+		assert.NotContains(suite.T(), buf.String(), "hanako")
+	})
+}
@@ -0,0 +1,65 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// NormalizeTestSuite は検索用の文字列正規化のテストスイート
+type NormalizeTestSuite struct {
+	suite.Suite
+}
+
+func TestNormalizeSuite(t *testing.T) {
+	suite.Run(t, new(NormalizeTestSuite))
+}
+
+func (suite *NormalizeTestSuite) Test_検索テキスト正規化() {
+	suite.Run("ひらがなと半角カナが全角カタカナにそろう", func() {
+		assert.Equal(suite.T(), "ヤマダタロウ", utils.NormalizeSearchText("やまだ たろう"))
+		assert.Equal(suite.T(), "ヤマダタロウ", utils.NormalizeSearchText("ﾔﾏﾀﾞ ﾀﾛｳ"))
+	})
+
+	suite.Run("全角英字と大文字が半角小文字にそろう", func() {
+		assert.Equal(suite.T(), "taro", utils.NormalizeSearchText("ＴＡＲＯ"))
+	})
+
+	suite.Run("漢字と全角空白はそのまま・空白は除かれる", func() {
+		assert.Equal(suite.T(), "山田太郎", utils.NormalizeSearchText("山田　太郎"))
+	})
+}
+
+func (suite *NormalizeTestSuite) Test_電話番号正規化() {
+	suite.Run("ハイフンと括弧が除かれる", func() {
+		assert.Equal(suite.T(), "0312345678", utils.NormalizePhoneDigits("(03)-1234-5678"))
+	})
+
+	suite.Run("全角数字が半角にそろう", func() {
+		assert.Equal(suite.T(), "09012345678", utils.NormalizePhoneDigits("０９０－１２３４－５６７８"))
+	})
+
+	suite.Run("数字を含まない場合_空文字が返される", func() {
+		assert.Equal(suite.T(), "", utils.NormalizePhoneDigits("山田"))
+	})
+}
+
+func (suite *NormalizeTestSuite) Test_名前の類似度() {
+	suite.Run("同じ名前の場合_1が返される", func() {
+		assert.Equal(suite.T(), 1.0, utils.NameSimilarity("ヤマダタロウ", "ヤマダタロウ"))
+	})
+
+	suite.Run("一文字違いの場合_しきい値以上の類似度が返される", func() {
+		assert.GreaterOrEqual(suite.T(), utils.NameSimilarity("ヤマダタロウ", "ヤマダタロー"), 0.8)
+	})
+
+	suite.Run("別人の名前の場合_低い類似度が返される", func() {
+		assert.Less(suite.T(), utils.NameSimilarity("ヤマダタロウ", "スズキハナコ"), 0.2)
+	})
+
+	suite.Run("空文字の場合_0が返される", func() {
+		assert.Equal(suite.T(), 0.0, utils.NameSimilarity("", "ヤマダ"))
+	})
+}
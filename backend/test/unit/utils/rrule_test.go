@@ -0,0 +1,85 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// RRuleTestSuite は繰り返しルール解析のテストスイート
+type RRuleTestSuite struct {
+	suite.Suite
+}
+
+func TestRRuleSuite(t *testing.T) {
+	suite.Run(t, new(RRuleTestSuite))
+}
+
+// エラーケース優先実装（TDDガイドラインに従い）
+func (suite *RRuleTestSuite) Test_ルール解析_エラーケース() {
+	suite.Run("FREQが無い場合_エラーが返される", func() {
+		_, err := utils.ParseRRule("INTERVAL=2;COUNT=3")
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("COUNTもUNTILも無い場合_エラーが返される", func() {
+		_, err := utils.ParseRRule("FREQ=WEEKLY;INTERVAL=5")
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("未対応の項目が含まれる場合_エラーが返される", func() {
+		_, err := utils.ParseRRule("FREQ=WEEKLY;BYDAY=MO;COUNT=3")
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("COUNTとUNTILが両方ある場合_エラーが返される", func() {
+		_, err := utils.ParseRRule("FREQ=DAILY;COUNT=3;UNTIL=20261231")
+		assert.Error(suite.T(), err)
+	})
+}
+
+func (suite *RRuleTestSuite) Test_発生日展開_正常系() {
+	start := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	suite.Run("5週間ごと3回の場合_35日間隔で3件返される", func() {
+		rule, err := utils.ParseRRule("RRULE:FREQ=WEEKLY;INTERVAL=5;COUNT=3")
+		assert.NoError(suite.T(), err)
+
+		dates := rule.Occurrences(start, 10)
+		assert.Equal(suite.T(), []time.Time{
+			start,
+			start.AddDate(0, 0, 35),
+			start.AddDate(0, 0, 70),
+		}, dates)
+	})
+
+	suite.Run("UNTILを指定した場合_UNTIL以前の日付のみ返される", func() {
+		rule, err := utils.ParseRRule("FREQ=DAILY;INTERVAL=10;UNTIL=20260220")
+		assert.NoError(suite.T(), err)
+
+		dates := rule.Occurrences(start, 10)
+		assert.Len(suite.T(), dates, 3)
+	})
+
+	suite.Run("毎月31日の場合_31日が存在しない月はスキップされる", func() {
+		rule, err := utils.ParseRRule("FREQ=MONTHLY;COUNT=3")
+		assert.NoError(suite.T(), err)
+
+		dates := rule.Occurrences(start, 10)
+		assert.Equal(suite.T(), []time.Time{
+			start,
+			time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+		}, dates)
+	})
+
+	suite.Run("上限件数を超える場合_上限件数で打ち切られる", func() {
+		rule, err := utils.ParseRRule("FREQ=WEEKLY;COUNT=100")
+		assert.NoError(suite.T(), err)
+
+		assert.Len(suite.T(), rule.Occurrences(start, 26), 26)
+	})
+}
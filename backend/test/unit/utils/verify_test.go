@@ -0,0 +1,96 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// VerifyTokenTestSuite はトークン検証のテストスイート
+type VerifyTokenTestSuite struct {
+	suite.Suite
+	secret string
+}
+
+func TestVerifyTokenSuite(t *testing.T) {
+	suite.Run(t, new(VerifyTokenTestSuite))
+}
+
+func (suite *VerifyTokenTestSuite) SetupTest() {
+	suite.secret = "test-secret"
+}
+
+func (suite *VerifyTokenTestSuite) sign(method jwt.SigningMethod, claims jwt.MapClaims) string {
+	tokenStr, err := jwt.NewWithClaims(method, claims).SignedString([]byte(suite.secret))
+	suite.Require().NoError(err)
+	return tokenStr
+}
+
+// エラーケース優先実装（TDDガイドラインに従い）
+func (suite *VerifyTokenTestSuite) Test_ロール付きトークン検証_エラーケース() {
+	suite.Run("ロールが異なる場合_エラーが返される", func() {
+		tokenStr := suite.sign(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "customer-1", "type": "access", "role": "admin", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		_, _, err := utils.VerifyTokenRole(tokenStr, suite.secret, "access", "customer")
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("トークン種別が異なる場合_エラーが返される", func() {
+		tokenStr := suite.sign(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "customer-1", "type": "refresh", "role": "customer", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		_, _, err := utils.VerifyTokenRole(tokenStr, suite.secret, "access", "customer")
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("期限切れの場合_エラーが返される", func() {
+		tokenStr := suite.sign(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "customer-1", "type": "access", "role": "customer", "exp": time.Now().Add(-time.Minute).Unix(),
+		})
+		_, _, err := utils.VerifyTokenRole(tokenStr, suite.secret, "access", "customer")
+		assert.Error(suite.T(), err)
+	})
+
+	suite.Run("HS256以外で署名された場合_エラーが返される", func() {
+		tokenStr := suite.sign(jwt.SigningMethodHS512, jwt.MapClaims{
+			"sub": "customer-1", "type": "access", "role": "customer", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		_, _, err := utils.VerifyTokenRole(tokenStr, suite.secret, "access", "customer")
+		assert.Error(suite.T(), err)
+	})
+}
+
+func (suite *VerifyTokenTestSuite) Test_ロール付きトークン検証_正常系() {
+	suite.Run("顧客のアクセストークンの場合_subが返される", func() {
+		tokenStr := suite.sign(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "customer-1", "type": "access", "role": "customer", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		sub, role, err := utils.VerifyTokenRole(tokenStr, suite.secret, "access", "customer")
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "customer-1", sub)
+		assert.Equal(suite.T(), "customer", role)
+	})
+	suite.Run("GenerateRoleTokenで発行した場合_同じロールで検証できる", func() {
+		tokenStr, err := utils.GenerateRoleToken("customer-2", "customer", "refresh", suite.secret, time.Now().Add(time.Hour))
+		assert.NoError(suite.T(), err)
+
+		sub, role, err := utils.VerifyTokenRole(tokenStr, suite.secret, "refresh", "customer")
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "customer-2", sub)
+		assert.Equal(suite.T(), "customer", role)
+	})
+	suite.Run("複数のロールを指定した場合_いずれかに一致すればロールが返される", func() {
+		tokenStr := suite.sign(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "staff-1", "type": "access", "role": "admin", "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		sub, role, err := utils.VerifyTokenRole(tokenStr, suite.secret, "access", "staff", "admin")
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "staff-1", sub)
+		assert.Equal(suite.T(), "admin", role)
+	})
+}
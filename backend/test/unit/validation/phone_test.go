@@ -0,0 +1,102 @@
+package validation_test
+
+import (
+	"app/src/i18n"
+	"app/src/validation"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// PhoneValidationTestSuite は電話番号の正規化・検証のテストスイート
+type PhoneValidationTestSuite struct {
+	suite.Suite
+}
+
+func TestPhoneValidationSuite(t *testing.T) {
+	suite.Run(t, new(PhoneValidationTestSuite))
+}
+
+// エラーケース優先実装（TDDガイドラインに従い）
+func (suite *PhoneValidationTestSuite) Test_電話番号解析_エラーケース() {
+	invalid := map[string]string{
+		"桁数が足りない場合":    "090-1234-567",
+		"携帯電話の桁数が多い場合": "090-1234-56789",
+		"海外の番号の場合":     "+1 212 555 0100",
+		"0で始まらない場合":    "9012345678",
+		"数字以外が含まれる場合":  "090-1234-abcd",
+		"空文字の場合":       "",
+		"050が10桁の場合":   "050-1234-567",
+		"0120が11桁の場合":  "0120-123-4567",
+		"00から始まる場合":    "0012345678",
+	}
+	for name, value := range invalid {
+		suite.Run(name+"_エラーが返される", func() {
+			_, err := validation.ParsePhone(value)
+			assert.Error(suite.T(), err)
+		})
+	}
+}
+
+func (suite *PhoneValidationTestSuite) Test_電話番号解析_正常系() {
+	suite.Run("表記の異なる同じ携帯電話番号が同じE164にそろう", func() {
+		for _, value := range []string{"090-1234-5678", "09012345678", "+81 90 1234 5678", "+81 (0)90-1234-5678", "０９０－１２３４－５６７８"} {
+			phone, err := validation.ParsePhone(value)
+			suite.Require().NoError(err, value)
+			assert.Equal(suite.T(), "+819012345678", phone.E164, value)
+			assert.Equal(suite.T(), "090-1234-5678", phone.Display, value)
+			assert.True(suite.T(), phone.Mobile, value)
+		}
+	})
+
+	suite.Run("東京の固定電話は2-4-4で区切られる", func() {
+		phone, err := validation.ParsePhone("0312345678")
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "+81312345678", phone.E164)
+		assert.Equal(suite.T(), "03-1234-5678", phone.Display)
+		assert.False(suite.T(), phone.Mobile)
+	})
+
+	suite.Run("その他の固定電話は3-3-4で区切られる", func() {
+		phone, err := validation.ParsePhone("(045)123-4567")
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "045-123-4567", phone.Display)
+	})
+
+	suite.Run("フリーダイヤルとIP電話が解析できる", func() {
+		phone, err := validation.ParsePhone("0120123456")
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "0120-123-456", phone.Display)
+
+		phone, err = validation.ParsePhone("05012345678")
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "050-1234-5678", phone.Display)
+	})
+}
+
+func (suite *PhoneValidationTestSuite) Test_カスタムバリデータ() {
+	type target struct {
+		Phone string `validate:"omitempty,phone"`
+	}
+	validate := validation.Validator()
+
+	suite.Run("日本の電話番号の場合_検証に成功する", func() {
+		assert.NoError(suite.T(), validate.Struct(target{Phone: "090-1234-5678"}))
+	})
+
+	suite.Run("空の場合_検証に成功する", func() {
+		assert.NoError(suite.T(), validate.Struct(target{}))
+	})
+
+	suite.Run("電話番号として解析できない場合_検証に失敗する", func() {
+		err := validate.Struct(target{Phone: "12345"})
+		assert.Error(suite.T(), err)
+		assert.Contains(suite.T(), validation.CustomErrorMessages(err, i18n.English)["target.Phone"], "Japanese phone number")
+	})
+
+	suite.Run("正規化できない値はそのまま残る", func() {
+		assert.Equal(suite.T(), "12345", validation.NormalizePhone("12345"))
+		assert.Equal(suite.T(), "+819012345678", validation.NormalizePhone("090-1234-5678"))
+	})
+}